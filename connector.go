@@ -0,0 +1,182 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+This file handles opening a connection pool where some setup needs to be run on
+each connection the pool opens. The [database/sql] package pools connections and
+opens new connections as needed, so anything that is set per-connection (i.e.: some
+SQLite PRAGMAs) must be applied to every connection, not just once after Connect().
+*/
+
+// connectHook is a function that is run on each new connection in the connection pool
+// before the connection is used for running queries. The context is the context
+// provided when the connection was opened and should be used for any queries run.
+type connectHook func(context.Context, driver.Conn) error
+
+// connector is a [database/sql/driver.Connector] that opens connections with a driver
+// and then runs the connectHooks on each new connection.
+type connector struct {
+	driver driver.Driver
+	dsn    string
+	hooks  []connectHook
+
+	//base is the driver's own connector, used to open connections with a context,
+	//if the driver implements [database/sql/driver.DriverContext].
+	base driver.Connector
+}
+
+// Connect opens a new connection and runs each connectHook on it. This implements
+// the [database/sql/driver.Connector] interface.
+//
+// The context is checked before opening the connection and between each connectHook
+// so that a canceled or timed out context stops opening a connection.
+func (c *connector) Connect(ctx context.Context) (conn driver.Conn, err error) {
+	err = ctx.Err()
+	if err != nil {
+		return
+	}
+
+	if c.base != nil {
+		conn, err = c.base.Connect(ctx)
+	} else {
+		conn, err = c.driver.Open(c.dsn)
+	}
+	if err != nil {
+		return
+	}
+
+	for _, h := range c.hooks {
+		err = ctx.Err()
+		if err == nil {
+			err = h(ctx, conn)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// Driver returns the underlying driver. This implements the
+// [database/sql/driver.Connector] interface.
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// connectHooks returns the list of functions to run on each new connection in the
+// connection pool. A nil list means sqlx.Open() can be used as-is.
+func (c *Config) connectHooks() (hooks []connectHook, err error) {
+	if !c.IsSQLite() {
+		return
+	}
+
+	lib := c.GetSQLiteLibrary()
+
+	//Run any PRAGMAs that the SQLite library does not support setting via the
+	//filepath.
+	for _, p := range pragmasNotInURL(c.sqlitePragmas(), lib) {
+		hooks = append(hooks, execHook(p))
+	}
+
+	//Attach additional databases.
+	hooks = append(hooks, c.sqliteAttachHooks()...)
+
+	//Register custom SQL functions and collations.
+	if len(c.sqliteFuncs) > 0 || len(c.sqliteCollations) > 0 {
+		h, innerErr := sqliteDrivers[lib].registerFuncs(c.sqliteFuncs, c.sqliteCollations)
+		if innerErr != nil {
+			err = innerErr
+			return
+		}
+		if h != nil {
+			hooks = append(hooks, h)
+		}
+	}
+
+	return
+}
+
+// open opens the connection pool to the database using the provided connection
+// string. If the database type requires setup to be run on each connection, the pool
+// is opened with a connector that runs the setup, otherwise this is the same as
+// calling sqlx.Open().
+func (c *Config) open(connString string) (conn *sqlx.DB, err error) {
+	driverName := getDriver(c.Type, c.GetSQLiteLibrary())
+
+	hooks, err := c.connectHooks()
+	if err != nil {
+		return
+	}
+	if len(hooks) == 0 {
+		return sqlx.Open(driverName, connString)
+	}
+
+	//Get the driver registered for the driver name. Opening a database does not
+	//connect to it, this just looks up the driver.
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return
+	}
+	d := db.Driver()
+	db.Close()
+
+	ctr := &connector{
+		driver: d,
+		dsn:    connString,
+		hooks:  hooks,
+	}
+	if dc, ok := d.(driver.DriverContext); ok {
+		ctr.base, err = dc.OpenConnector(connString)
+		if err != nil {
+			return
+		}
+	}
+
+	conn = sqlx.NewDb(sql.OpenDB(ctr), driverName)
+	return
+}
+
+// execHook returns a connectHook that runs a query, with optional arguments, on a
+// connection.
+func execHook(query string, args ...driver.NamedValue) connectHook {
+	return func(ctx context.Context, conn driver.Conn) error {
+		return execOnConn(ctx, conn, query, args...)
+	}
+}
+
+// execOnConn runs a query on a driver-level connection. This is needed since a
+// connectHook runs before the connection is part of the [database/sql] pool.
+func execOnConn(ctx context.Context, conn driver.Conn, query string, args ...driver.NamedValue) (err error) {
+	//Use the driver's Exec if implemented, otherwise prepare and then exec.
+	if e, ok := conn.(driver.ExecerContext); ok {
+		_, err = e.ExecContext(ctx, query, args)
+		if !errors.Is(err, driver.ErrSkip) {
+			return
+		}
+	}
+
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return
+	}
+	defer stmt.Close()
+
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+
+	//lint:ignore SA1019 - driver.Stmt only guarantees Exec, not ExecContext.
+	_, err = stmt.Exec(values)
+	return
+}
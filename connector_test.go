@@ -0,0 +1,79 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestConnectorContext(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	db, err := sql.Open(getDriver(c.Type, c.GetSQLiteLibrary()), "")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	d := db.Driver()
+	db.Close()
+
+	//The context is provided to each hook and hooks after the context is canceled
+	//aren't run.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got context.Context
+	ranAfterCancel := false
+	ctr := &connector{
+		driver: d,
+		dsn:    ":memory:",
+		hooks: []connectHook{
+			func(ctx context.Context, _ driver.Conn) error {
+				got = ctx
+				cancel()
+				return nil
+			},
+			func(context.Context, driver.Conn) error {
+				ranAfterCancel = true
+				return nil
+			},
+		},
+	}
+
+	_, err = ctr.Connect(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("context.Canceled should have occured.", err)
+		return
+	}
+	if got != ctx {
+		t.Fatal("Context not provided to hook.")
+		return
+	}
+	if ranAfterCancel {
+		t.Fatal("Hook should not run after context is canceled.")
+		return
+	}
+
+	//A canceled context stops opening a connection from the pool.
+	c = NewSQLite(filepath.Join(t.TempDir(), "main.db"))
+	c.SQLiteAttachments = map[string]string{
+		"archive": filepath.Join(t.TempDir(), "archive.db"),
+	}
+	err = c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	c.Connection().SetMaxIdleConns(0)
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	_, err = c.Connection().Conn(canceled)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("context.Canceled should have occured.", err)
+		return
+	}
+}
@@ -0,0 +1,82 @@
+package sqldb
+
+import "slices"
+
+/*
+This file handles DeployQueries and UpdateQueries that are written differently for
+each database type. Most queries can be written once and translated with
+DeployQueryTranslators or UpdateQueryTranslators, but some statements cannot be
+translated (ex.: a trigger or a MSSQL specific ALTER) and have to be written
+separately for each database type, or only run for some database types.
+*/
+
+// DialectQuery is a DeployQuery or UpdateQuery that has a different query for some
+// database types, or that is only run for some database types. Use with
+// DeployDialectQueries or UpdateDialectQueries.
+//
+// The query to run is chosen based on the config's Type before the query is
+// translated. Note that the chosen query is still run through the translators, so a
+// variant should be written so that the translators leave it as-is.
+//
+// Example:
+//
+//	sqldb.DialectQuery{
+//	    Default: "ALTER TABLE users ADD COLUMN Notes TEXT",
+//	    Variants: map[sqldb.DBTypeName]string{
+//	        sqldb.DBTypeMSSQL: "ALTER TABLE users ADD Notes NVARCHAR(MAX)",
+//	    },
+//	    Except: []sqldb.DBTypeName{sqldb.DBTypeSQLite},
+//	}
+type DialectQuery struct {
+	//Default is the query used when a variant isn't provided for the database type.
+	//If Default is blank, the query is only run for database types with a variant.
+	Default string
+
+	//Variants are the queries to use instead of Default for a database type.
+	Variants map[DBTypeName]string
+
+	//Only limits the query to these database types. If blank, the query is run for
+	//every database type.
+	Only []DBTypeName
+
+	//Except are database types the query is never run for.
+	Except []DBTypeName
+}
+
+// For returns the query to run for a database type. False is returned if the query
+// should not be run for the database type.
+func (d DialectQuery) For(t DBTypeName) (query string, ok bool) {
+	if len(d.Only) > 0 && !slices.Contains(d.Only, t) {
+		return
+	}
+	if slices.Contains(d.Except, t) {
+		return
+	}
+
+	query, ok = d.Variants[t]
+	if !ok {
+		query = d.Default
+	}
+
+	ok = query != ""
+	return
+}
+
+// dialectQueries returns the queries to run for the config's database type, from
+// both the plain queries and the DialectQuery queries. The plain queries are
+// returned first.
+func (c *Config) dialectQueries(caller string, queries []string, dqs []DialectQuery) (out []string) {
+	out = append(out, queries...)
+
+	for i, dq := range dqs {
+		q, ok := dq.For(c.Type)
+		if !ok {
+			c.debugLn(caller, "Skipping DialectQuery", i, "for database type", c.Type)
+			continue
+		}
+
+		out = append(out, q)
+	}
+
+	return
+}
@@ -0,0 +1,46 @@
+package sqldb_test
+
+import (
+	"testing"
+
+	"github.com/c9845/sqldb/v3"
+)
+
+// TestDialectQueryExternal checks that a DialectQuery can be built outside of this
+// package.
+func TestDialectQueryExternal(t *testing.T) {
+	dq := sqldb.DialectQuery{
+		Default: "ALTER TABLE users ADD COLUMN Notes TEXT",
+		Variants: map[sqldb.DBTypeName]string{
+			sqldb.DBTypeMSSQL: "ALTER TABLE users ADD Notes NVARCHAR(MAX)",
+		},
+		Except: []sqldb.DBTypeName{sqldb.DBTypeSQLite},
+	}
+
+	got, ok := dq.For(sqldb.DBTypeMSSQL)
+	if !ok || got != "ALTER TABLE users ADD Notes NVARCHAR(MAX)" {
+		t.Fatal("Bad variant.", got, ok)
+		return
+	}
+
+	got, ok = dq.For(sqldb.DBTypeMariaDB)
+	if !ok || got != dq.Default {
+		t.Fatal("Bad default.", got, ok)
+		return
+	}
+
+	if _, ok := dq.For(sqldb.DBTypeSQLite); ok {
+		t.Fatal("Query should not be run for SQLite.")
+		return
+	}
+
+	//Only, with the database type from a config.
+	dq = sqldb.DialectQuery{
+		Default: "SELECT 1",
+		Only:    []sqldb.DBTypeName{sqldb.DBType("mariadb")},
+	}
+	if _, ok := dq.For(sqldb.NewSQLite(sqldb.SQLiteInMemoryFilepathRaceSafe).Type); ok {
+		t.Fatal("Query should only be run for MariaDB.")
+		return
+	}
+}
@@ -0,0 +1,104 @@
+package sqldb
+
+import "testing"
+
+func TestDialectQueryFor(t *testing.T) {
+	tt := []struct {
+		name     string
+		dq       DialectQuery
+		dbType   dbType
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "default",
+			dq:       DialectQuery{Default: "a"},
+			dbType:   DBTypeSQLite,
+			expected: "a",
+			ok:       true,
+		},
+		{
+			name:     "variant",
+			dq:       DialectQuery{Default: "a", Variants: map[dbType]string{DBTypeMSSQL: "b"}},
+			dbType:   DBTypeMSSQL,
+			expected: "b",
+			ok:       true,
+		},
+		{
+			name:   "variant only, no default",
+			dq:     DialectQuery{Variants: map[dbType]string{DBTypeMSSQL: "b"}},
+			dbType: DBTypeSQLite,
+			ok:     false,
+		},
+		{
+			name:   "not in only",
+			dq:     DialectQuery{Default: "a", Only: []dbType{DBTypeMariaDB, DBTypeMySQL}},
+			dbType: DBTypeSQLite,
+			ok:     false,
+		},
+		{
+			name:     "in only",
+			dq:       DialectQuery{Default: "a", Only: []dbType{DBTypeMariaDB, DBTypeMySQL}},
+			dbType:   DBTypeMySQL,
+			expected: "a",
+			ok:       true,
+		},
+		{
+			name:   "except",
+			dq:     DialectQuery{Default: "a", Variants: map[dbType]string{DBTypeSQLite: "b"}, Except: []dbType{DBTypeSQLite}},
+			dbType: DBTypeSQLite,
+			ok:     false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := tc.dq.For(tc.dbType)
+			if ok != tc.ok || got != tc.expected {
+				t.Log("Got:", got, ok)
+				t.Log("Exp:", tc.expected, tc.ok)
+				t.Fatal("Bad query.")
+				return
+			}
+		})
+	}
+}
+
+func TestDeploySchemaDialectQueries(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueries = []string{"CREATE TABLE IF NOT EXISTS users (ID INTEGER PRIMARY KEY, Name TEXT)"}
+	c.DeployDialectQueries = []DialectQuery{
+		{
+			//Would fail on SQLite.
+			Default: "ALTER TABLE users ADD INDEX Name_idx (Name)",
+			Variants: map[dbType]string{
+				DBTypeSQLite: "CREATE INDEX IF NOT EXISTS Name_idx ON users (Name)",
+			},
+		},
+		{
+			Default: "THIS IS NOT VALID SQL",
+			Except:  []dbType{DBTypeSQLite},
+		},
+		{
+			Default: "THIS IS NOT VALID SQL",
+			Only:    []dbType{DBTypeMSSQL},
+		},
+	}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	var count int
+	err = c.Connection().Get(&count, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'Name_idx'")
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if count != 1 {
+		t.Fatal("Index not created.")
+		return
+	}
+}
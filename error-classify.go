@@ -0,0 +1,277 @@
+package sqldb
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+)
+
+/*
+This file handles classifying errors returned by each database driver into a common
+set of errors. Each driver returns errors differently (MariaDB/MySQL and MSSQL use
+error numbers, SQLite uses result codes and messages), so checking for a specific
+error, for example a duplicate key, would otherwise require knowing which driver is
+being used and matching on the driver's error.
+*/
+
+var (
+	//ErrDuplicateKey is returned when a row with the same primary key or unique
+	//index value already exists.
+	ErrDuplicateKey = errors.New("sqldb: duplicate key")
+
+	//ErrForeignKeyViolation is returned when a foreign key constraint fails.
+	ErrForeignKeyViolation = errors.New("sqldb: foreign key violation")
+
+	//ErrNoSuchTable is returned when a table does not exist.
+	ErrNoSuchTable = errors.New("sqldb: no such table")
+
+	//ErrNoSuchColumn is returned when a column does not exist. For MariaDB/MySQL,
+	//this is also returned when dropping a key that does not exist since the same
+	//error number is used.
+	ErrNoSuchColumn = errors.New("sqldb: no such column")
+
+	//ErrDuplicateColumn is returned when adding a column that already exists.
+	ErrDuplicateColumn = errors.New("sqldb: duplicate column")
+
+	//ErrDeadlock is returned when a transaction was rolled back because of a
+	//deadlock. The transaction can be retried.
+	ErrDeadlock = errors.New("sqldb: deadlock")
+
+	//ErrBusy is returned when a SQLite database is locked by another connection. The
+	//query can be retried.
+	ErrBusy = errors.New("sqldb: database busy")
+
+	//ErrLockTimeout is returned when waiting for a lock timed out. The query can be
+	//retried.
+	ErrLockTimeout = errors.New("sqldb: lock timeout")
+
+	//ErrNotNullViolation is returned when a NULL value is used for a NOT NULL
+	//column.
+	ErrNotNullViolation = errors.New("sqldb: not null violation")
+)
+
+// classifiedError is an error returned by a driver along with the matching error
+// defined in this package. Both errors can be checked with errors.Is and errors.As.
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+// Error returns the driver's error message.
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns both the matching error defined in this package and the driver's
+// error.
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// Classify checks if an error returned by a database driver is one of the errors
+// defined in this package (ex.: ErrDuplicateKey) and, if so, returns the error
+// wrapped so that errors.Is works with the error defined in this package. The
+// driver's error is still available via errors.As. If the error is not recognized,
+// or is nil, it is returned as-is.
+//
+// Errors from the MariaDB/MySQL, MSSQL, and each SQLite library are recognized.
+//
+// Example:
+//
+//	_, err := c.Connection().Exec(q, email)
+//	if errors.Is(sqldb.Classify(err), sqldb.ErrDuplicateKey) {
+//	    //handle user already exists.
+//	}
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	//Already classified.
+	var ce *classifiedError
+	if errors.As(err, &ce) {
+		return err
+	}
+
+	kind := classify(err)
+	if kind == nil {
+		return err
+	}
+
+	return &classifiedError{kind: kind, err: err}
+}
+
+// classify returns the error defined in this package that matches an error returned
+// by a database driver. Nil is returned if the error is not recognized.
+func classify(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return classifyMySQL(me)
+	}
+
+	for _, se := range mssqlErrors(err) {
+		if kind := classifyMSSQL(se); kind != nil {
+			return kind
+		}
+	}
+
+	for _, d := range sqliteDrivers {
+		if d.errorCode == nil {
+			continue
+		}
+		if code, ok := d.errorCode(err); ok {
+			return classifySQLite(code, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// classifyMySQL returns the matching error for a MariaDB/MySQL error number.
+//
+// https://mariadb.com/kb/en/mariadb-error-code-reference/
+func classifyMySQL(err *mysql.MySQLError) error {
+	switch err.Number {
+	case 1062, 1586: //ER_DUP_ENTRY, ER_DUP_ENTRY_WITH_KEY_NAME
+		return ErrDuplicateKey
+	case 1216, 1217, 1451, 1452: //ER_NO_REFERENCED_ROW, ER_ROW_IS_REFERENCED, and _2 versions
+		return ErrForeignKeyViolation
+	case 1051, 1146: //ER_BAD_TABLE_ERROR, ER_NO_SUCH_TABLE
+		return ErrNoSuchTable
+	case 1054, 1091: //ER_BAD_FIELD_ERROR, ER_CANT_DROP_FIELD_OR_KEY
+		return ErrNoSuchColumn
+	case 1060: //ER_DUP_FIELDNAME
+		return ErrDuplicateColumn
+	case 1213: //ER_LOCK_DEADLOCK
+		return ErrDeadlock
+	case 1205: //ER_LOCK_WAIT_TIMEOUT
+		return ErrLockTimeout
+	case 1048: //ER_BAD_NULL_ERROR
+		return ErrNotNullViolation
+	}
+
+	return nil
+}
+
+// classifyMSSQL returns the matching error for a MSSQL error number.
+//
+// https://learn.microsoft.com/en-us/sql/relational-databases/errors-events/database-engine-events-and-errors
+func classifyMSSQL(err mssql.Error) error {
+	switch err.Number {
+	case 2601, 2627: //duplicate key in unique index, violation of unique constraint
+		return ErrDuplicateKey
+	case 547:
+		//Also used for CHECK constraints.
+		if strings.Contains(err.Message, "FOREIGN KEY") || strings.Contains(err.Message, "REFERENCE") {
+			return ErrForeignKeyViolation
+		}
+	case 208: //invalid object name
+		return ErrNoSuchTable
+	case 3701:
+		//Cannot drop because it doesn't exist. Also used for indexes, constraints,
+		//and other objects.
+		if strings.Contains(err.Message, "the table") {
+			return ErrNoSuchTable
+		}
+	case 207, 4924: //invalid column name, DROP COLUMN failed because column doesn't exist
+		return ErrNoSuchColumn
+	case 2705: //column names in each table must be unique
+		return ErrDuplicateColumn
+	case 1205: //deadlock victim
+		return ErrDeadlock
+	case 1222: //lock request time out period exceeded
+		return ErrLockTimeout
+	case 515: //cannot insert the value NULL
+		return ErrNotNullViolation
+	}
+
+	return nil
+}
+
+// mssqlErrors returns each error message received from MSSQL for an error, from first
+// to last. MSSQL can return more than one error message for a query (ex.: 3728 "is
+// not a constraint" followed by 3727 "could not drop constraint") and the returned
+// error only describes the last one.
+func mssqlErrors(err error) []mssql.Error {
+	var se mssql.Error
+	if !errors.As(err, &se) {
+		return nil
+	}
+	if len(se.All) == 0 {
+		return []mssql.Error{se}
+	}
+
+	return se.All
+}
+
+// isMSSQLError returns true if an error is from MSSQL and any of the error messages
+// are one of the provided error numbers.
+func isMSSQLError(err error, numbers ...int32) bool {
+	for _, se := range mssqlErrors(err) {
+		if slices.Contains(numbers, se.Number) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SQLite result codes used to classify errors.
+//
+// https://www.sqlite.org/rescode.html
+const (
+	sqliteError                = 1
+	sqliteBusy                 = 5
+	sqliteLocked               = 6
+	sqliteConstraint           = 19
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintNotNull    = 1299
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+)
+
+// classifySQLite returns the matching error for a SQLite result code. The message
+// is used for errors that SQLite doesn't provide a specific result code for (ex.:
+// no such table) and for constraint errors when extended result codes are not
+// provided.
+func classifySQLite(code int, msg string) error {
+	switch code {
+	case sqliteConstraintPrimaryKey, sqliteConstraintUnique:
+		return ErrDuplicateKey
+	case sqliteConstraintForeignKey:
+		return ErrForeignKeyViolation
+	case sqliteConstraintNotNull:
+		return ErrNotNullViolation
+	}
+
+	//Primary result code is the lower 8 bits of the extended result code.
+	switch code & 0xff {
+	case sqliteBusy, sqliteLocked:
+		return ErrBusy
+
+	case sqliteConstraint:
+		switch {
+		case strings.Contains(msg, "UNIQUE constraint failed"), strings.Contains(msg, "PRIMARY KEY constraint failed"):
+			return ErrDuplicateKey
+		case strings.Contains(msg, "FOREIGN KEY constraint failed"):
+			return ErrForeignKeyViolation
+		case strings.Contains(msg, "NOT NULL constraint failed"):
+			return ErrNotNullViolation
+		}
+
+	case sqliteError:
+		switch {
+		case strings.Contains(msg, "no such table"):
+			return ErrNoSuchTable
+		case strings.Contains(msg, "no such column"):
+			return ErrNoSuchColumn
+		case strings.Contains(msg, "duplicate column name"):
+			return ErrDuplicateColumn
+		}
+	}
+
+	return nil
+}
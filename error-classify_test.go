@@ -0,0 +1,142 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestClassify(t *testing.T) {
+	tt := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"mysql duplicate key", &mysql.MySQLError{Number: 1062}, ErrDuplicateKey},
+		{"mysql foreign key", &mysql.MySQLError{Number: 1452}, ErrForeignKeyViolation},
+		{"mysql no such table", &mysql.MySQLError{Number: 1146}, ErrNoSuchTable},
+		{"mysql no such column", &mysql.MySQLError{Number: 1054}, ErrNoSuchColumn},
+		{"mysql duplicate column", &mysql.MySQLError{Number: 1060}, ErrDuplicateColumn},
+		{"mysql deadlock", &mysql.MySQLError{Number: 1213}, ErrDeadlock},
+		{"mysql lock timeout", &mysql.MySQLError{Number: 1205}, ErrLockTimeout},
+		{"mysql not null", &mysql.MySQLError{Number: 1048}, ErrNotNullViolation},
+		{"mysql wrapped", fmt.Errorf("inserting: %w", &mysql.MySQLError{Number: 1062}), ErrDuplicateKey},
+		{"mysql unknown", &mysql.MySQLError{Number: 1064}, nil},
+		{"mssql duplicate key", mssql.Error{Number: 2627}, ErrDuplicateKey},
+		{"mssql foreign key", mssql.Error{Number: 547, Message: "The INSERT statement conflicted with the FOREIGN KEY constraint"}, ErrForeignKeyViolation},
+		{"mssql check constraint", mssql.Error{Number: 547, Message: "The INSERT statement conflicted with the CHECK constraint"}, nil},
+		{"mssql no such table", mssql.Error{Number: 208}, ErrNoSuchTable},
+		{"mssql drop table", mssql.Error{Number: 3701, Message: "Cannot drop the table 'nope', because it does not exist or you do not have permission."}, ErrNoSuchTable},
+		{"mssql drop index", mssql.Error{Number: 3701, Message: "Cannot drop the index 'users.nope', because it does not exist or you do not have permission."}, nil},
+		{"mssql no such column", mssql.Error{Number: 207}, ErrNoSuchColumn},
+		{"mssql duplicate column", mssql.Error{Number: 2705}, ErrDuplicateColumn},
+		{"mssql deadlock", mssql.Error{Number: 1205}, ErrDeadlock},
+		{"mssql lock timeout", mssql.Error{Number: 1222}, ErrLockTimeout},
+		{"mssql not null", mssql.Error{Number: 515}, ErrNotNullViolation},
+		{"sqlite busy", classifySQLiteTestError(5, "database is locked"), ErrBusy},
+		{"unknown", errors.New("something"), nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Classify(tc.err)
+			if tc.expected == nil {
+				var ce *classifiedError
+				if errors.As(got, &ce) {
+					t.Fatal("Error should be returned as-is.", got)
+				}
+				return
+			}
+
+			if !errors.Is(got, tc.expected) {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad classification.")
+				return
+			}
+			if got.Error() != tc.err.Error() {
+				t.Fatal("Message should not change.", got.Error())
+				return
+			}
+		})
+	}
+
+	//Driver error is still available.
+	var me *mysql.MySQLError
+	if !errors.As(Classify(&mysql.MySQLError{Number: 1062}), &me) || me.Number != 1062 {
+		t.Fatal("Driver error not available.")
+		return
+	}
+
+	if Classify(nil) != nil {
+		t.Fatal("Nil should be returned as-is.")
+		return
+	}
+}
+
+// classifySQLiteTestError is used to test classifying a SQLite result code without
+// needing a specific SQLite library.
+func classifySQLiteTestError(code int, msg string) error {
+	return &classifiedError{kind: classifySQLite(code, msg), err: errors.New(msg)}
+}
+
+func TestClassifySQLite(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueries = []string{
+		"CREATE TABLE IF NOT EXISTS users (ID INTEGER PRIMARY KEY, Email TEXT NOT NULL UNIQUE)",
+	}
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	db := c.Connection()
+	_, err = db.Exec("INSERT INTO users (Email) VALUES (?)", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	tt := []struct {
+		name     string
+		query    string
+		args     []any
+		expected error
+	}{
+		{"duplicate key", "INSERT INTO users (Email) VALUES (?)", []any{"a@example.com"}, ErrDuplicateKey},
+		{"not null", "INSERT INTO users (Email) VALUES (?)", []any{nil}, ErrNotNullViolation},
+		{"no such table", "SELECT * FROM not_a_table", nil, ErrNoSuchTable},
+		{"no such column", "SELECT NotAColumn FROM users", nil, ErrNoSuchColumn},
+		{"duplicate column", "ALTER TABLE users ADD COLUMN Email TEXT", nil, ErrDuplicateColumn},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := db.Exec(tc.query, tc.args...)
+			if err == nil {
+				t.Fatal("Error should have occured.")
+				return
+			}
+
+			if !errors.Is(Classify(err), tc.expected) {
+				t.Log("Got:", err)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad classification.")
+				return
+			}
+		})
+	}
+
+	//Error handlers.
+	q := "DROP TABLE not_a_table"
+	_, err = db.Exec(q)
+	if !IgnoreErrorDropTable(q, err) {
+		t.Fatal("Error should have been ignored.", err)
+		return
+	}
+}
@@ -0,0 +1,253 @@
+package sqldb
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+/*
+This file handles building an error handler from a set of conditions instead of
+writing an ErrorHandler func that checks the query and error message for each
+database type.
+*/
+
+// ErrorHandlerBuilder builds an error handler that ignores an error when the query and
+// error match the provided conditions. Each condition added narrows when the error is
+// ignored; within one condition (ex.: WhenQuery("a", "b")) any value may match.
+//
+// Example:
+//
+//	eh := sqldb.Handle("drop-old-column").
+//	    WhenQuery("DROP COLUMN").
+//	    WhenError(sqldb.ErrNoSuchColumn).
+//	    ForTypes(sqldb.DBTypeMariaDB, sqldb.DBTypeSQLite).
+//	    Build()
+//	c.UpdateQueryNamedErrorHandlers = append(c.UpdateQueryNamedErrorHandlers, eh)
+type ErrorHandlerBuilder struct {
+	name string
+
+	keywords []string
+	patterns []*regexp.Regexp
+	errs     []error
+	codes    []int
+	types    []dbType
+}
+
+// Handle starts building an error handler. The name is used when logging and
+// reporting (see IgnoredErrorReporter) that the handler ignored an error so that you
+// can tell which handler ignored what.
+//
+// The handler isn't tied to a config, the database type is checked, and logging is
+// done, using the config running the query that resulted in an error.
+func Handle(name string) *ErrorHandlerBuilder {
+	return &ErrorHandlerBuilder{
+		name: name,
+	}
+}
+
+// WhenQuery only ignores an error if the query contains one of the keywords. The
+// comparison is case-insensitive.
+func (b *ErrorHandlerBuilder) WhenQuery(keywords ...string) *ErrorHandlerBuilder {
+	b.keywords = append(b.keywords, keywords...)
+	return b
+}
+
+// WhenQueryMatches only ignores an error if the query matches one of the regular
+// expressions.
+func (b *ErrorHandlerBuilder) WhenQueryMatches(patterns ...*regexp.Regexp) *ErrorHandlerBuilder {
+	b.patterns = append(b.patterns, patterns...)
+	return b
+}
+
+// WhenError only ignores an error if the error, after being classified, is one of
+// the provided errors (ex.: ErrNoSuchColumn). See Classify().
+func (b *ErrorHandlerBuilder) WhenError(errs ...error) *ErrorHandlerBuilder {
+	b.errs = append(b.errs, errs...)
+	return b
+}
+
+// WhenErrorCode only ignores an error if the error's number or code, as returned by
+// the driver, is one of the provided codes. This is the error number for MariaDB,
+// MySQL, and MSSQL, and the result code, primary or extended, for SQLite.
+func (b *ErrorHandlerBuilder) WhenErrorCode(codes ...int) *ErrorHandlerBuilder {
+	b.codes = append(b.codes, codes...)
+	return b
+}
+
+// ForTypes only ignores an error if the database type of the config running the query
+// is one of the provided database types.
+func (b *ErrorHandlerBuilder) ForTypes(types ...DBTypeName) *ErrorHandlerBuilder {
+	b.types = append(b.types, types...)
+	return b
+}
+
+// Build returns the error handler. Add the handler to a config's
+// DeployQueryNamedErrorHandlers or UpdateQueryNamedErrorHandlers.
+//
+// At least one of WhenError() or WhenErrorCode() must be used, otherwise the handler
+// never ignores an error. This prevents ignoring every error for a query by mistake.
+func (b *ErrorHandlerBuilder) Build() *NamedErrorHandler {
+	//Copy so that modifying the builder after Build() doesn't change the handler.
+	h := *b
+	h.keywords = slices.Clone(b.keywords)
+	h.patterns = slices.Clone(b.patterns)
+	h.errs = slices.Clone(b.errs)
+	h.codes = slices.Clone(b.codes)
+	h.types = slices.Clone(b.types)
+
+	return &NamedErrorHandler{b: h}
+}
+
+// NamedErrorHandler is an error handler built with Handle(). Unlike an ErrorHandler,
+// a NamedErrorHandler has a name and is checked against the database type of the
+// config running the query.
+//
+// NamedErrorHandlers are set in a config's DeployQueryNamedErrorHandlers and
+// UpdateQueryNamedErrorHandlers fields. When an error is ignored, the handler's name
+// and the conditions that matched are logged at the info logging level.
+type NamedErrorHandler struct {
+	b ErrorHandlerBuilder
+}
+
+// Name returns the name provided to Handle().
+func (h *NamedErrorHandler) Name() string {
+	return h.b.name
+}
+
+// HandleError checks if an error should be ignored when the handler isn't run by a
+// config. The database type is determined from the type of the error instead of
+// from a config; MariaDB and MySQL return the same type of error so either type
+// matches.
+func (h *NamedErrorHandler) HandleError(query string, err error) bool {
+	t := errorDBType(err)
+	if t == DBTypeMariaDB && slices.Contains(h.b.types, DBTypeMySQL) {
+		t = DBTypeMySQL
+	}
+
+	_, ok := h.b.match(t, query, err)
+	return ok
+}
+
+// ErrorHandler returns the NamedErrorHandler as an ErrorHandler so that it can be
+// used where only an ErrorHandler can be, such as DeployQueryErrorHandlers. The
+// database type is determined from the error, see HandleError(), and the handler's
+// name isn't used when logging or reporting an ignored error.
+func (h *NamedErrorHandler) ErrorHandler() ErrorHandler {
+	return h.HandleError
+}
+
+// errorDBType returns the database type that returned an error based on the type of
+// the error. DBTypeMariaDB is returned for MariaDB and MySQL errors since the type of
+// error is the same. An empty type is returned if the error isn't from a driver.
+func errorDBType(err error) dbType {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return DBTypeMariaDB
+	}
+
+	if len(mssqlErrors(err)) > 0 {
+		return DBTypeMSSQL
+	}
+
+	for _, d := range sqliteDrivers {
+		if d.errorCode == nil {
+			continue
+		}
+		if _, ok := d.errorCode(err); ok {
+			return DBTypeSQLite
+		}
+	}
+
+	return ""
+}
+
+// match checks if a query and error, from running the query against database type t,
+// match each of the conditions. The reason the error is ignored, the conditions that
+// matched, is returned.
+func (b *ErrorHandlerBuilder) match(t dbType, query string, err error) (reason string, ok bool) {
+	if err == nil || (len(b.errs) == 0 && len(b.codes) == 0) {
+		return
+	}
+
+	var reasons []string
+
+	if len(b.types) > 0 {
+		if !slices.Contains(b.types, t) {
+			return
+		}
+		reasons = append(reasons, "database type is "+string(t))
+	}
+
+	if len(b.keywords) > 0 {
+		q := strings.ToUpper(query)
+		i := slices.IndexFunc(b.keywords, func(k string) bool {
+			return strings.Contains(q, strings.ToUpper(k))
+		})
+		if i == -1 {
+			return
+		}
+		reasons = append(reasons, "query contains "+strconv.Quote(b.keywords[i]))
+	}
+
+	if len(b.patterns) > 0 {
+		i := slices.IndexFunc(b.patterns, func(re *regexp.Regexp) bool {
+			return re.MatchString(query)
+		})
+		if i == -1 {
+			return
+		}
+		reasons = append(reasons, "query matches "+strconv.Quote(b.patterns[i].String()))
+	}
+
+	if len(b.errs) > 0 {
+		classified := Classify(err)
+		i := slices.IndexFunc(b.errs, func(e error) bool {
+			return errors.Is(classified, e)
+		})
+		if i == -1 {
+			return
+		}
+		reasons = append(reasons, "error is "+strconv.Quote(b.errs[i].Error()))
+	}
+
+	if len(b.codes) > 0 {
+		i := slices.IndexFunc(b.codes, func(code int) bool {
+			return hasErrorCode(err, code)
+		})
+		if i == -1 {
+			return
+		}
+		reasons = append(reasons, "error code is "+strconv.Itoa(b.codes[i]))
+	}
+
+	return strings.Join(reasons, ", "), true
+}
+
+// hasErrorCode returns true if an error returned by a driver has the error number or
+// result code.
+func hasErrorCode(err error, code int) bool {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return int(me.Number) == code
+	}
+
+	if isMSSQLError(err, int32(code)) {
+		return true
+	}
+
+	for _, d := range sqliteDrivers {
+		if d.errorCode == nil {
+			continue
+		}
+		if c, ok := d.errorCode(err); ok {
+			return c == code || c&0xff == code
+		}
+	}
+
+	return false
+}
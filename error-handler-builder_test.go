@@ -0,0 +1,212 @@
+package sqldb
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestErrorHandlerBuilder(t *testing.T) {
+	c := &Config{Type: DBTypeMariaDB}
+
+	dropColumn := Handle("drop-column").
+		WhenQuery("drop column").
+		WhenError(ErrNoSuchColumn).
+		ForTypes(DBTypeMariaDB, DBTypeMSSQL).
+		Build()
+
+	byCode := Handle("by-code").
+		WhenQueryMatches(regexp.MustCompile(`(?i)^\s*CREATE INDEX`)).
+		WhenErrorCode(1061).
+		Build()
+
+	noErrorCondition := Handle("no-error-condition").WhenQuery("DROP").Build()
+
+	tt := []struct {
+		name     string
+		handler  *NamedErrorHandler
+		dbType   dbType
+		query    string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "matches",
+			handler:  dropColumn,
+			dbType:   DBTypeMariaDB,
+			query:    "ALTER TABLE users DROP COLUMN Nope",
+			err:      &mysql.MySQLError{Number: 1091},
+			expected: true,
+		},
+		{
+			name:     "matches mssql",
+			handler:  dropColumn,
+			dbType:   DBTypeMSSQL,
+			query:    "ALTER TABLE users DROP COLUMN Nope",
+			err:      mssqlTestError(mssql.Error{Number: 4924}),
+			expected: true,
+		},
+		{
+			name:     "wrong database type",
+			handler:  dropColumn,
+			dbType:   DBTypeSQLite,
+			query:    "ALTER TABLE users DROP COLUMN Nope",
+			err:      &mysql.MySQLError{Number: 1091},
+			expected: false,
+		},
+		{
+			name:     "wrong query",
+			handler:  dropColumn,
+			dbType:   DBTypeMariaDB,
+			query:    "ALTER TABLE users ADD COLUMN Nope INT",
+			err:      &mysql.MySQLError{Number: 1091},
+			expected: false,
+		},
+		{
+			name:     "wrong error",
+			handler:  dropColumn,
+			dbType:   DBTypeMariaDB,
+			query:    "ALTER TABLE users DROP COLUMN Nope",
+			err:      &mysql.MySQLError{Number: 1064},
+			expected: false,
+		},
+		{
+			name:     "code and pattern",
+			handler:  byCode,
+			dbType:   DBTypeMariaDB,
+			query:    "create index Name_idx ON users (Name)",
+			err:      &mysql.MySQLError{Number: 1061},
+			expected: true,
+		},
+		{
+			name:     "no error condition",
+			handler:  noErrorCondition,
+			dbType:   DBTypeMariaDB,
+			query:    "DROP TABLE users",
+			err:      &mysql.MySQLError{Number: 1051},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c.Type = tc.dbType
+			got := c.runQueryErrorHandlers(nil, []*NamedErrorHandler{tc.handler}, StageUpdateQuery, 0, tc.query, tc.err)
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad result.")
+				return
+			}
+		})
+	}
+}
+
+func TestErrorHandlerBuilderLogging(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.LoggingLevel = LogLevelInfo
+	c.UpdateQueryNamedErrorHandlers = []*NamedErrorHandler{
+		Handle("already-dropped").WhenQuery("DROP TABLE").WhenError(ErrNoSuchTable).Build(),
+	}
+	c.UpdateQueries = []string{"DROP TABLE not_a_table"}
+
+	err := c.UpdateSchema(&UpdateSchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	if !strings.Contains(buf.String(), `Error ignored by handler "already-dropped", query contains "DROP TABLE", error is "sqldb: no such table".`) {
+		t.Fatal("Handler name and reason not logged.", buf.String())
+		return
+	}
+	if n := strings.Count(buf.String(), "Error ignored"); n != 1 {
+		t.Fatal("Ignored error should be logged once.", n)
+		return
+	}
+}
+
+func TestErrorHandlerBuilderConfig(t *testing.T) {
+	//Built before any config is used, and used with more than one config.
+	h := Handle("drop-column").WhenQuery("DROP COLUMN").WhenError(ErrNoSuchColumn).ForTypes(DBTypeSQLite).Build()
+
+	q := "ALTER TABLE users DROP COLUMN Nope"
+	err := &mysql.MySQLError{Number: 1091}
+	if (&Config{Type: DBTypeMariaDB}).runQueryErrorHandlers(nil, []*NamedErrorHandler{h}, StageUpdateQuery, 0, q, err) {
+		t.Fatal("Error should not be ignored for MariaDB.")
+		return
+	}
+
+	//Called directly, the database type is determined from the error.
+	if h.HandleError(q, err) {
+		t.Fatal("Error should not be ignored since the error is from MariaDB.")
+		return
+	}
+	mysqlOnly := Handle("mysql-only").WhenError(ErrNoSuchColumn).ForTypes(DBTypeMySQL).Build()
+	if !mysqlOnly.HandleError(q, err) {
+		t.Fatal("Error should be ignored since MariaDB and MySQL errors are the same.")
+		return
+	}
+
+	//Wrapped as an ErrorHandler, the handler still works but isn't named.
+	wrapped := func(query string, err error) bool {
+		return mysqlOnly.ErrorHandler()(query, err)
+	}
+	if !(&Config{Type: DBTypeMariaDB}).runQueryErrorHandlers([]ErrorHandler{wrapped}, nil, StageUpdateQuery, 0, q, err) {
+		t.Fatal("Error should be ignored by wrapped handler.")
+		return
+	}
+
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.UpdateQueryNamedErrorHandlers = []*NamedErrorHandler{
+		Handle("no-types").WhenQuery("DROP TABLE").WhenError(ErrNoSuchTable).Build(),
+		h,
+	}
+	c.UpdateQueries = []string{
+		"CREATE TABLE IF NOT EXISTS handler_users (ID INTEGER PRIMARY KEY)",
+		"DROP TABLE not_a_table",
+		"ALTER TABLE handler_users DROP COLUMN Nope",
+	}
+
+	var ignored []IgnoredError
+	c.IgnoredErrorReporter = func(ie IgnoredError) {
+		ignored = append(ignored, ie)
+	}
+
+	err2 := c.UpdateSchema(&UpdateSchemaOptions{CloseConnection: false})
+	if err2 != nil {
+		t.Fatal(err2)
+		return
+	}
+	defer c.Close()
+
+	if len(ignored) != 2 {
+		t.Fatal("Bad number of ignored errors.", ignored)
+		return
+	}
+	if ignored[0].Handler != "no-types" || ignored[0].Step != 1 || ignored[0].Stage != StageUpdateQuery {
+		t.Fatal("Bad ignored error.", ignored[0])
+		return
+	}
+	if ignored[1].Handler != "drop-column" || ignored[1].Step != 2 {
+		t.Fatal("Bad ignored error.", ignored[1])
+		return
+	}
+
+	//Predefined handlers are named after the func.
+	if n := funcName(ErrorHandler(IgnoreErrorDropTable)); n != "sqldb.IgnoreErrorDropTable" {
+		t.Fatal("Bad handler name.", n)
+		return
+	}
+}
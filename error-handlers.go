@@ -1,6 +1,10 @@
 package sqldb
 
-import "strings"
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
 
 /*
 This file lists a bunch of example ErrorHandler funcs. These funcs are used to
@@ -12,13 +16,16 @@ ignore/bypass errors returned from running a DeployQuery or UpdateQuery.
 // is typically used to ignore errors that arise from a query being run multiple times
 // but the result already being applied (think, renaming a table or column).
 //
+// Use Classify() to check for a specific error regardless of the database type, or
+// use Handle() to build an error handler from a set of conditions.
+//
 // Error handlers typically have an "is this error handler applicable, if so check if
 // the error should be ignore, and if so, ignore the error"
 //
 // Ex:
 //
 //	func IgnoreDuplicateColumnError (q query, err error) bool {
-//	  if !strings.Contains(q, "ADD COLUMN") && strings.Contains(err.Error(), "duplicate column") {
+//	  if strings.Contains(q, "ADD COLUMN") && errors.Is(Classify(err), ErrDuplicateColumn) {
 //		    return true
 //	  }
 //
@@ -26,6 +33,88 @@ ignore/bypass errors returned from running a DeployQuery or UpdateQuery.
 //	 }
 type ErrorHandler func(string, error) bool
 
+// IgnoredError is a query error that was ignored by an error handler. See
+// IgnoredErrorReporter.
+type IgnoredError struct {
+	//Handler is the name of the error handler that ignored the error. This is the
+	//name provided to Handle() or the name of the ErrorHandler func, ex.:
+	//sqldb.IgnoreErrorDropColumn.
+	Handler string
+
+	Stage queryStage //StageDeployQuery or StageUpdateQuery.
+	Step  int        //index of the query, see QueryError.
+	Query string
+	Err   error
+}
+
+// queryErrorHandler is implemented by ErrorHandler and NamedErrorHandler so that both
+// types of handlers in a config can be run together, in order.
+type queryErrorHandler interface {
+	//handleQueryError returns the name of the handler, and the reason the error was
+	//ignored if known, if the error from running a query for the config should be
+	//ignored.
+	handleQueryError(c *Config, query string, err error) (name, reason string, ignore bool)
+}
+
+func (h ErrorHandler) handleQueryError(c *Config, query string, err error) (name, reason string, ignore bool) {
+	if !h(query, err) {
+		return
+	}
+
+	return funcName(h), "", true
+}
+
+func (h *NamedErrorHandler) handleQueryError(c *Config, query string, err error) (name, reason string, ignore bool) {
+	reason, ignore = h.b.match(c.Type, query, err)
+	if !ignore {
+		return
+	}
+
+	return h.b.name, ", " + reason, true
+}
+
+// runQueryErrorHandlers runs a list of ErrorHandlers followed by a list of
+// NamedErrorHandlers when an error occured from running a DeployQuery or
+// UpdateQuery. True is returned if a handler ignored the error. The ignored error is
+// logged and reported to the IgnoredErrorReporter.
+func (c *Config) runQueryErrorHandlers(plain []ErrorHandler, named []*NamedErrorHandler, stage queryStage, step int, query string, err error) (ignoreError bool) {
+	//Make sure an error occured.
+	if err == nil {
+		return true
+	}
+
+	handlers := make([]queryErrorHandler, 0, len(plain)+len(named))
+	for _, h := range plain {
+		handlers = append(handlers, h)
+	}
+	for _, h := range named {
+		handlers = append(handlers, h)
+	}
+
+	//Run each handler and see if any return true to ignore this error.
+	for _, eh := range handlers {
+		name, reason, ok := eh.handleQueryError(c, query, err)
+		if !ok {
+			continue
+		}
+
+		c.infoLn("sqldb.runQueryErrorHandlers", "Error ignored by handler "+strconv.Quote(name)+reason+".", "Error:", err)
+		if c.IgnoredErrorReporter != nil {
+			c.IgnoredErrorReporter(IgnoredError{
+				Handler: name,
+				Stage:   stage,
+				Step:    step,
+				Query:   query,
+				Err:     err,
+			})
+		}
+
+		return true
+	}
+
+	return false
+}
+
 // IgnoreErrorDuplicateColumn checks if an error occurred because a column with the
 // same name already exists. This is useful to for running ALTER TABLE ADD COLUMN or
 // RENAME COLUMN.
@@ -33,8 +122,15 @@ type ErrorHandler func(string, error) bool
 // This error usually occurs because UpdateSchema() is being rerun.
 //
 //Ex.: ALTER TABLE my_table RENAME COLUMN old_column_name TO new_column_name.
+//Ex.: MSSQL: ALTER TABLE my_table ADD my_column INT.
 func IgnoreErrorDuplicateColumn(query string, err error) bool {
-	if strings.Contains(strings.ToUpper(query), "ADD COLUMN") && strings.Contains(strings.ToLower(err.Error()), "duplicate column") {
+	q := strings.ToUpper(query)
+	if strings.Contains(q, "ADD COLUMN") && errors.Is(Classify(err), ErrDuplicateColumn) {
+		return true
+	}
+
+	//MSSQL doesn't use the COLUMN keyword.
+	if strings.Contains(q, "ALTER TABLE") && strings.Contains(q, " ADD ") && isMSSQLError(err, 2705) {
 		return true
 	}
 
@@ -52,13 +148,8 @@ func IgnoreErrorDropColumn(query string, err error) bool {
 		return false
 	}
 
-	//MariaDB.
-	if strings.Contains(err.Error(), "Error 1091") && strings.Contains(err.Error(), "check that it exists") {
-		return true
-	}
-
-	//SQLite.
-	if strings.Contains(err.Error(), "no such column") {
+	//lint:ignore S1008 - i don't really like "return errors.Is()", i don't think it is as clear.
+	if errors.Is(Classify(err), ErrNoSuchColumn) {
 		return true
 	}
 
@@ -73,15 +164,23 @@ func IgnoreErrorDropColumn(query string, err error) bool {
 //
 //Ex.:
 // - MariaDB: ALTER TABLE my_table DROP FOREIGN KEY my_foreign_key.
+// - MSSQL: ALTER TABLE my_table DROP CONSTRAINT my_foreign_key.
 // - SQLite: You will have to turn foreign keys off, create a new table, and copy over
 //   data.
 func IgnoreErrorDropForeignKey(query string, err error) bool {
+	//MSSQL.
+	if strings.Contains(strings.ToUpper(query), "DROP CONSTRAINT") && isMSSQLError(err, 3728) {
+		return true
+	}
+
 	if !strings.Contains(strings.ToUpper(query), "DROP FOREIGN KEY") {
 		return false
 	}
 
-	//MariaDB.
-	if strings.Contains(err.Error(), "Error 1091") && strings.Contains(err.Error(), "check that it exists") {
+	//MariaDB uses the same error number for a column or a key that doesn't exist.
+	//
+	//lint:ignore S1008 - i don't really like "return errors.Is()", i don't think it is as clear.
+	if errors.Is(Classify(err), ErrNoSuchColumn) {
 		return true
 	}
 
@@ -99,13 +198,8 @@ func IgnoreErrorDropTable(query string, err error) bool {
 		return false
 	}
 
-	//MariaDB.
-	if strings.Contains(err.Error(), "Error 1051") && strings.Contains(err.Error(), "Unknown table") {
-		return true
-	}
-
-	//SQLite.
-	if strings.Contains(err.Error(), "no such table") {
+	//lint:ignore S1008 - i don't really like "return errors.Is()", i don't think it is as clear.
+	if errors.Is(Classify(err), ErrNoSuchTable) {
 		return true
 	}
 
@@ -115,13 +209,8 @@ func IgnoreErrorDropTable(query string, err error) bool {
 // IgnoreErrorTableDoesNotExist checks if an error occurred because you are trying to
 // modify a table in some manner but the table does not exist in the database.
 func IgnoreErrorTableDoesNotExist(query string, err error) bool {
-	//MariaDB.
-	if strings.Contains(err.Error(), "Error 1146") && strings.Contains(err.Error(), "Table") && strings.Contains(err.Error(), "doesn't exist") {
-		return true
-	}
-
-	//SQLite.
-	if strings.Contains(err.Error(), "no such table") {
+	//lint:ignore S1008 - i don't really like "return errors.Is()", i don't think it is as clear.
+	if errors.Is(Classify(err), ErrNoSuchTable) {
 		return true
 	}
 
@@ -131,13 +220,7 @@ func IgnoreErrorTableDoesNotExist(query string, err error) bool {
 // IgnoreErrorColumnDoesNotExist checks if an error occurred because you are trying to
 // update a column in some manner but the column does not exist.
 func IgnoreErrorColumnDoesNotExist(query string, err error) bool {
-	//MariaDB.
-	if strings.Contains(query, "UPDATE") && strings.Contains(err.Error(), "Unknown column") {
-		return true
-	}
-
-	//SQLite.
-	if strings.Contains(query, "UPDATE") && strings.Contains(err.Error(), "no such column") {
+	if strings.Contains(query, "UPDATE") && errors.Is(Classify(err), ErrNoSuchColumn) {
 		return true
 	}
 
@@ -166,20 +249,25 @@ func IgnoreErrorSQLiteModify(query string, err error) bool {
 // that does not exist is trying to be renamed.
 //
 // This error usually occurs because UpdateSchema() is being rerun.
+//
+//Ex.: MSSQL: EXEC sp_rename 'my_table.old_column_name', 'new_column_name', 'COLUMN'.
 func IgnoreErrorRenameDoesNotExist(query string, err error) bool {
-	//MariaDB.
-	if strings.Contains(query, "RENAME COLUMN") && strings.Contains(err.Error(), "Unknown column") {
+	if strings.Contains(query, "RENAME COLUMN") && errors.Is(Classify(err), ErrNoSuchColumn) {
 		return true
 	}
-	if strings.Contains(query, "RENAME TO") && strings.Contains(err.Error(), "Table") && strings.Contains(err.Error(), "already exists") {
+	if strings.Contains(query, "RENAME TO") && errors.Is(Classify(err), ErrNoSuchTable) {
 		return true
 	}
 
-	//SQLite.
-	if strings.Contains(query, "RENAME COLUMN") && strings.Contains(err.Error(), "no such column") {
+	//MariaDB.
+	if strings.Contains(query, "RENAME TO") && strings.Contains(err.Error(), "Table") && strings.Contains(err.Error(), "already exists") {
 		return true
 	}
-	if strings.Contains(query, "RENAME TO") && strings.Contains(err.Error(), "no such table") {
+
+	//MSSQL renames tables and columns with sp_rename which errors with "Either the
+	//parameter @objname is ambiguous or the claimed @objtype is wrong" if the table
+	//or column does not exist.
+	if strings.Contains(strings.ToLower(query), "sp_rename") && isMSSQLError(err, 15248, 15225) {
 		return true
 	}
 
@@ -0,0 +1,192 @@
+package sqldb
+
+import (
+	"testing"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+)
+
+// mssqlTestError returns an error as returned by the MSSQL driver when each message
+// is received for a query.
+func mssqlTestError(all ...mssql.Error) error {
+	last := all[len(all)-1]
+	last.All = all
+	return last
+}
+
+func TestErrorHandlers(t *testing.T) {
+	//Errors captured from each database.
+	tt := []struct {
+		name     string
+		handler  ErrorHandler
+		query    string
+		err      error
+		expected bool
+	}{
+		//MariaDB.
+		{
+			name:     "mariadb drop column",
+			handler:  IgnoreErrorDropColumn,
+			query:    "ALTER TABLE users DROP COLUMN Nope",
+			err:      &mysql.MySQLError{Number: 1091, Message: "Can't DROP COLUMN `Nope`; check that it exists"},
+			expected: true,
+		},
+		{
+			name:     "mariadb drop table",
+			handler:  IgnoreErrorDropTable,
+			query:    "DROP TABLE nope",
+			err:      &mysql.MySQLError{Number: 1051, Message: "Unknown table 'db.nope'"},
+			expected: true,
+		},
+		{
+			name:     "mariadb duplicate column",
+			handler:  IgnoreErrorDuplicateColumn,
+			query:    "ALTER TABLE users ADD COLUMN Name TEXT",
+			err:      &mysql.MySQLError{Number: 1060, Message: "Duplicate column name 'Name'"},
+			expected: true,
+		},
+		{
+			name:     "mariadb drop foreign key",
+			handler:  IgnoreErrorDropForeignKey,
+			query:    "ALTER TABLE users DROP FOREIGN KEY fk",
+			err:      &mysql.MySQLError{Number: 1091, Message: "Can't DROP FOREIGN KEY `fk`; check that it exists"},
+			expected: true,
+		},
+		{
+			name:     "mariadb table does not exist",
+			handler:  IgnoreErrorTableDoesNotExist,
+			query:    "ALTER TABLE nope ADD COLUMN Name TEXT",
+			err:      &mysql.MySQLError{Number: 1146, Message: "Table 'db.nope' doesn't exist"},
+			expected: true,
+		},
+		{
+			name:     "mariadb rename column",
+			handler:  IgnoreErrorRenameDoesNotExist,
+			query:    "ALTER TABLE users RENAME COLUMN Old TO New",
+			err:      &mysql.MySQLError{Number: 1054, Message: "Unknown column 'Old' in 'users'"},
+			expected: true,
+		},
+		{
+			name:     "mariadb syntax error",
+			handler:  IgnoreErrorDropColumn,
+			query:    "ALTER TABLE users DROP COLUMN",
+			err:      &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"},
+			expected: false,
+		},
+
+		//MSSQL.
+		{
+			name:     "mssql drop column",
+			handler:  IgnoreErrorDropColumn,
+			query:    "ALTER TABLE users DROP COLUMN Nope",
+			err:      mssqlTestError(mssql.Error{Number: 4924, Message: "ALTER TABLE DROP COLUMN failed because column 'Nope' does not exist in table 'users'."}),
+			expected: true,
+		},
+		{
+			name:     "mssql drop table",
+			handler:  IgnoreErrorDropTable,
+			query:    "DROP TABLE nope",
+			err:      mssqlTestError(mssql.Error{Number: 3701, Message: "Cannot drop the table 'nope', because it does not exist or you do not have permission."}),
+			expected: true,
+		},
+		{
+			name:     "mssql duplicate column",
+			handler:  IgnoreErrorDuplicateColumn,
+			query:    "ALTER TABLE users ADD Name NVARCHAR(MAX)",
+			err:      mssqlTestError(mssql.Error{Number: 2705, Message: "Column names in each table must be unique. Column name 'Name' in table 'users' is specified more than once."}),
+			expected: true,
+		},
+		{
+			name:    "mssql drop foreign key",
+			handler: IgnoreErrorDropForeignKey,
+			query:   "ALTER TABLE users DROP CONSTRAINT fk",
+			err: mssqlTestError(
+				mssql.Error{Number: 3728, Message: "'fk' is not a constraint."},
+				mssql.Error{Number: 3727, Message: "Could not drop constraint. See previous errors."},
+			),
+			expected: true,
+		},
+		{
+			name:     "mssql table does not exist",
+			handler:  IgnoreErrorTableDoesNotExist,
+			query:    "ALTER TABLE nope ADD Name INT",
+			err:      mssqlTestError(mssql.Error{Number: 208, Message: "Invalid object name 'nope'."}),
+			expected: true,
+		},
+		{
+			name:     "mssql column does not exist",
+			handler:  IgnoreErrorColumnDoesNotExist,
+			query:    "UPDATE users SET Nope = 1",
+			err:      mssqlTestError(mssql.Error{Number: 207, Message: "Invalid column name 'Nope'."}),
+			expected: true,
+		},
+		{
+			name:     "mssql rename",
+			handler:  IgnoreErrorRenameDoesNotExist,
+			query:    "EXEC sp_rename 'users.Old', 'New', 'COLUMN'",
+			err:      mssqlTestError(mssql.Error{Number: 15248, Message: "Either the parameter @objname is ambiguous or the claimed @objtype (COLUMN) is wrong."}),
+			expected: true,
+		},
+		{
+			name:     "mssql rename other error",
+			handler:  IgnoreErrorRenameDoesNotExist,
+			query:    "EXEC sp_rename 'users.Old', 'New', 'COLUMN'",
+			err:      mssqlTestError(mssql.Error{Number: 15335, Message: "Error: The new name 'New' is already in use as a COLUMN name and would cause a duplicate that is not permitted."}),
+			expected: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.handler(tc.query, tc.err)
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad result.")
+				return
+			}
+		})
+	}
+}
+
+func TestErrorHandlersSQLite(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueries = []string{"CREATE TABLE IF NOT EXISTS users (ID INTEGER PRIMARY KEY, Name TEXT)"}
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	//Errors returned by the SQLite library being used.
+	tt := []struct {
+		name    string
+		handler ErrorHandler
+		query   string
+	}{
+		{"drop column", IgnoreErrorDropColumn, "ALTER TABLE users DROP COLUMN Nope"},
+		{"drop table", IgnoreErrorDropTable, "DROP TABLE nope"},
+		{"duplicate column", IgnoreErrorDuplicateColumn, "ALTER TABLE users ADD COLUMN Name TEXT"},
+		{"table does not exist", IgnoreErrorTableDoesNotExist, "ALTER TABLE nope ADD COLUMN Name TEXT"},
+		{"column does not exist", IgnoreErrorColumnDoesNotExist, "UPDATE users SET Nope = 1"},
+		{"rename column", IgnoreErrorRenameDoesNotExist, "ALTER TABLE users RENAME COLUMN Nope TO New"},
+		{"rename table", IgnoreErrorRenameDoesNotExist, "ALTER TABLE nope RENAME TO new"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := c.Connection().Exec(tc.query)
+			if err == nil {
+				t.Fatal("Error should have occured.")
+				return
+			}
+
+			if !tc.handler(tc.query, err) {
+				t.Fatal("Error should have been ignored.", err)
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package sqldb
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	tt := []struct {
+		dbType   dbType
+		name     string
+		expected string
+	}{
+		{DBTypeMariaDB, "Order", "`Order`"},
+		{DBTypeMySQL, "we`ird", "`we``ird`"},
+		{DBTypeSQLite, "Order", `"Order"`},
+		{DBTypeSQLite, `we"ird`, `"we""ird"`},
+		{DBTypeMSSQL, "Order", "[Order]"},
+		{DBTypeMSSQL, "we]ird", "[we]]ird]"},
+		{DBTypeMariaDB, "users.Key", "`users`.`Key`"},
+		{DBTypeSQLite, "users.*", `"users".*`},
+		{DBTypeSQLite, "", ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(string(tc.dbType)+" "+tc.name, func(t *testing.T) {
+			c := &Config{Type: tc.dbType}
+			got := c.QuoteIdent(tc.name)
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad quoting.")
+				return
+			}
+		})
+	}
+}
+
+func TestQuoteColumns(t *testing.T) {
+	c := &Config{Type: DBTypeMSSQL}
+	cols := Columns{"Name", "Order"}
+
+	colString, err := c.QuoteColumns(cols).ForSelect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if colString != "[Name],[Order]" {
+		t.Fatal("Bad column string.", colString)
+		return
+	}
+	if cols[1] != "Order" {
+		t.Fatal("Columns should not be modified.", cols)
+		return
+	}
+
+	//Blank columns should still be caught.
+	_, err = c.QuoteColumns(Columns{"Name", ""}).ForSelect()
+	if err != ErrExtraCommaInColumnString {
+		t.Fatal("ErrExtraCommaInColumnString should have occured.", err)
+		return
+	}
+}
+
+func TestIsReservedWord(t *testing.T) {
+	tt := []struct {
+		dbType   dbType
+		word     string
+		expected bool
+	}{
+		{DBTypeMariaDB, "order", true},
+		{DBTypeMariaDB, "Name", false},
+		{DBTypeMySQL, "Rank", true},
+		{DBTypeMariaDB, "Rank", false},
+		{DBTypeMySQL, "OFFSET", false},
+		{DBTypeMariaDB, "OFFSET", true},
+		{DBTypeMySQL, "Returning", false},
+		{DBTypeMySQL, "General", false},
+		{DBTypeMySQL, "Slow", false},
+		{DBTypeSQLite, "Group", true},
+		{DBTypeSQLite, "Key", false},
+		{DBTypeMSSQL, "Key", true},
+		{DBTypeMSSQL, "User", true},
+	}
+
+	for _, tc := range tt {
+		got := IsReservedWord(tc.dbType, tc.word)
+		if got != tc.expected {
+			t.Fatal("Bad result for", tc.dbType, tc.word, got)
+			return
+		}
+	}
+}
+
+func TestWarnReservedIdentifiers(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.warnReservedIdentifiers("test", `CREATE TABLE "Group" (ID INTEGER, "Order" TEXT, Key TEXT)`)
+	if buf.Len() != 0 {
+		t.Fatal("No warnings should be logged.", buf.String())
+		return
+	}
+
+	c.warnReservedIdentifiers("test", `CREATE TABLE Group (ID INTEGER, Order TEXT); ALTER TABLE users ADD COLUMN Default TEXT`)
+	for _, w := range []string{"Group", "Order", "Default"} {
+		if !strings.Contains(buf.String(), "reserved word "+w+" ") {
+			t.Fatal("Warning not logged for", w, buf.String())
+			return
+		}
+	}
+}
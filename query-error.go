@@ -0,0 +1,131 @@
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+/*
+This file handles wrapping errors returned when running a query with the context the
+query was run in (the query, the database type, etc.) since the error returned by a
+driver usually doesn't include the query. Bind values are never stored in the error
+so that sensitive values aren't logged.
+*/
+
+// queryStage is where a query that returned an error was run.
+type queryStage string
+
+const (
+	StageDeployQuery  queryStage = "deploy query"  //a DeployQuery or DeployDialectQuery run by DeploySchema().
+	StageDeployFunc   queryStage = "deploy func"   //a DeployFunc run by DeploySchema().
+	StageUpdateQuery  queryStage = "update query"  //an UpdateQuery or UpdateDialectQuery run by UpdateSchema().
+	StageUpdateFunc   queryStage = "update func"   //an UpdateFunc run by UpdateSchema().
+	StageRuntimeQuery queryStage = "runtime query" //a query run with DB().
+)
+
+// QueryError is returned when running a query results in an error. The error returned
+// by the driver, after being classified (see Classify()), is available via
+// errors.Is and errors.As. For DeploySchema() and UpdateSchema(), errors from
+// rendering a query template or translating a query (ex.: a *TranslationError) are
+// also returned as a QueryError.
+//
+// Bind values are not stored, only the number of bind values, so that sensitive
+// values aren't included when the error is logged.
+type QueryError struct {
+	Stage queryStage
+
+	//Query is the query, after being translated, that returned the error. For a
+	//DeployQuery or UpdateQuery that was translated into more than one statement,
+	//this is just the statement that returned the error. If rendering the query
+	//template or translating the query failed, this is the query before being
+	//rendered or translated. Blank for a DeployFunc or UpdateFunc.
+	Query string
+
+	//Func is the name of the DeployFunc or UpdateFunc that returned the error.
+	Func string
+
+	//Dialect is the database type the query was run against.
+	Dialect dbType
+
+	//Step is the index of the query or func in the list of queries or funcs being
+	//run. For DeploySchema() and UpdateSchema(), DeployDialectQueries and
+	//UpdateDialectQueries are counted after DeployQueries and UpdateQueries. Always
+	//0 for StageRuntimeQuery.
+	Step int
+
+	//Args is the number of bind values provided with the query.
+	Args int
+
+	Err error
+}
+
+// Error returns the error message with the stage, step, database type, and query.
+// Long queries are shortened.
+func (e *QueryError) Error() string {
+	var b strings.Builder
+	b.WriteString("sqldb: ")
+	b.WriteString(string(e.Stage))
+	if e.Stage != StageRuntimeQuery {
+		b.WriteString(" ")
+		b.WriteString(strconv.Itoa(e.Step))
+	}
+	if e.Func != "" {
+		b.WriteString(" ")
+		b.WriteString(e.Func)
+	}
+	b.WriteString(" (")
+	b.WriteString(string(e.Dialect))
+	b.WriteString("): ")
+	b.WriteString(e.Err.Error())
+
+	if e.Query != "" {
+		b.WriteString("; query: ")
+		b.WriteString(shortQuery(e.Query))
+	}
+	if e.Args > 0 {
+		b.WriteString("; args: ")
+		b.WriteString(strconv.Itoa(e.Args))
+		b.WriteString(" redacted")
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the error returned by the driver.
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// queryError returns err wrapped in a QueryError. Nil is returned if err is nil.
+// sql.ErrNoRows is returned as-is since it is commonly compared to directly and isn't
+// really an error with the query.
+func (c *Config) queryError(stage queryStage, step int, query, funcName string, args int, err error) error {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	return &QueryError{
+		Stage:   stage,
+		Query:   query,
+		Func:    funcName,
+		Dialect: c.Type,
+		Step:    step,
+		Args:    args,
+		Err:     Classify(err),
+	}
+}
+
+// shortQuery returns the first line of a query, or the first 70 characters of a
+// query that is one line, for logging.
+func shortQuery(q string) string {
+	q = strings.TrimSpace(q)
+	if first, _, found := strings.Cut(q, "\n"); found {
+		return first
+	} else if maxLen := 70; len(q) > maxLen {
+		return q[:maxLen] + "..."
+	}
+
+	return q
+}
@@ -0,0 +1,232 @@
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestQueryErrorError(t *testing.T) {
+	tt := []struct {
+		err      *QueryError
+		expected string
+	}{
+		{
+			err: &QueryError{
+				Stage:   StageDeployQuery,
+				Query:   "INSERT INTO users (Name) VALUES ('a')",
+				Dialect: DBTypeSQLite,
+				Step:    2,
+				Err:     errors.New("no such table: users"),
+			},
+			expected: "sqldb: deploy query 2 (sqlite): no such table: users; query: INSERT INTO users (Name) VALUES ('a')",
+		},
+		{
+			err: &QueryError{
+				Stage:   StageUpdateFunc,
+				Func:    "main.addColumn",
+				Dialect: DBTypeMariaDB,
+				Step:    0,
+				Err:     errors.New("bad"),
+			},
+			expected: "sqldb: update func 0 main.addColumn (mariadb): bad",
+		},
+		{
+			err: &QueryError{
+				Stage:   StageRuntimeQuery,
+				Query:   "SELECT *\nFROM users\nWHERE Email = ?",
+				Dialect: DBTypeMySQL,
+				Args:    1,
+				Err:     errors.New("bad"),
+			},
+			expected: "sqldb: runtime query (mysql): bad; query: SELECT *; args: 1 redacted",
+		},
+	}
+
+	for _, tc := range tt {
+		got := tc.err.Error()
+		if got != tc.expected {
+			t.Fatal("Mismatch.", "\nGot:", got, "\nExp:", tc.expected)
+			return
+		}
+	}
+}
+
+func TestDeploySchemaQueryError(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueries = []string{
+		"CREATE TABLE IF NOT EXISTS query_error_users (ID INTEGER PRIMARY KEY, Name TEXT)",
+		"INSERT INTO query_error_missing (Name) VALUES ('a')",
+	}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	defer c.Close()
+
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Fatal("Error should be a QueryError.", err)
+		return
+	}
+	if qe.Stage != StageDeployQuery || qe.Step != 1 || qe.Dialect != DBTypeSQLite {
+		t.Fatal("Bad context.", qe.Stage, qe.Step, qe.Dialect)
+		return
+	}
+	if qe.Query != c.DeployQueries[1] {
+		t.Fatal("Bad query.", qe.Query)
+		return
+	}
+	if !errors.Is(err, ErrNoSuchTable) {
+		t.Fatal("Error should be classified.", err)
+		return
+	}
+}
+
+func TestSchemaQueryErrorBeforeRunning(t *testing.T) {
+	tr, err := FunctionTranslator(DBTypeSQLite, DBTypeMariaDB)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//Translating fails for the second UpdateQuery.
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.UpdateQueries = []string{
+		"CREATE TABLE IF NOT EXISTS query_error_translate (Name TEXT)",
+		"ALTER TABLE query_error_translate ADD COLUMN Full TEXT DEFAULT ('a' || 'b')",
+	}
+	c.UpdateQueryCheckedTranslators = []CheckedTranslator{tr}
+
+	err = c.UpdateSchema(&UpdateSchemaOptions{CloseConnection: false})
+	defer c.Close()
+
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Fatal("Error should be a QueryError.", err)
+		return
+	}
+	if qe.Stage != StageUpdateQuery || qe.Step != 1 || qe.Query != c.UpdateQueries[1] {
+		t.Fatal("Bad context.", qe.Stage, qe.Step, qe.Query)
+		return
+	}
+	var te *TranslationError
+	if !errors.As(err, &te) {
+		t.Fatal("Error should unwrap to the TranslationError.", err)
+		return
+	}
+
+	//Rendering the template fails for the second DeployQuery.
+	c = NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.QueryTemplates = true
+	c.DeployQueries = []string{
+		"CREATE TABLE IF NOT EXISTS query_error_template (Name {{text 10}})",
+		"CREATE TABLE IF NOT EXISTS query_error_template2 (Name {{notAFunc}})",
+	}
+
+	err = c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	defer c.Close()
+
+	if !errors.As(err, &qe) {
+		t.Fatal("Error should be a QueryError.", err)
+		return
+	}
+	if qe.Stage != StageDeployQuery || qe.Step != 1 || qe.Query != c.DeployQueries[1] {
+		t.Fatal("Bad context.", qe.Stage, qe.Step, qe.Query)
+		return
+	}
+}
+
+func TestDeploySchemaFuncQueryError(t *testing.T) {
+	funcErr := errors.New("func failed")
+
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployFuncs = []QueryFunc{
+		func(*sqlx.DB) error { return nil },
+		func(*sqlx.DB) error { return funcErr },
+	}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	defer c.Close()
+
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Fatal("Error should be a QueryError.", err)
+		return
+	}
+	if qe.Stage != StageDeployFunc || qe.Step != 1 || qe.Func == "" || qe.Query != "" {
+		t.Fatal("Bad context.", qe.Stage, qe.Step, qe.Func, qe.Query)
+		return
+	}
+	if !errors.Is(err, funcErr) {
+		t.Fatal("Error should unwrap to the func's error.", err)
+		return
+	}
+}
+
+func TestTranslatedDBQueryError(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueries = []string{"CREATE TABLE IF NOT EXISTS query_error_accounts (ID INTEGER PRIMARY KEY, Password TEXT NOT NULL)"}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	//Bind values must not be included in the error.
+	secret := "hunter2-secret"
+	_, err = c.DB().Exec("INSERT INTO query_error_missing (Password) VALUES (?)", secret)
+
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Fatal("Error should be a QueryError.", err)
+		return
+	}
+	if qe.Stage != StageRuntimeQuery || qe.Args != 1 {
+		t.Fatal("Bad context.", qe.Stage, qe.Args)
+		return
+	}
+	if strings.Contains(err.Error(), secret) {
+		t.Fatal("Bind value should be redacted.", err)
+		return
+	}
+	if !errors.Is(err, ErrNoSuchTable) {
+		t.Fatal("Error should be classified.", err)
+		return
+	}
+
+	//sql.ErrNoRows is not wrapped so that it can still be compared directly.
+	var password string
+	err = c.DB().Get(&password, "SELECT Password FROM query_error_accounts WHERE ID = ?", 1)
+	if err != sql.ErrNoRows {
+		t.Fatal("sql.ErrNoRows should be returned as-is.", err)
+		return
+	}
+
+	//Errors from QueryRow() and QueryRowx() are returned by Scan(), etc.
+	err = c.DB().QueryRow("SELECT Password FROM query_error_missing WHERE ID = ?", 1).Scan(&password)
+	if !errors.As(err, &qe) || qe.Stage != StageRuntimeQuery || qe.Args != 1 {
+		t.Fatal("Error should be a QueryError.", err)
+		return
+	}
+	if !errors.Is(err, ErrNoSuchTable) {
+		t.Fatal("Error should be classified.", err)
+		return
+	}
+
+	m := make(map[string]any)
+	err = c.DB().QueryRowx("SELECT NotAColumn FROM query_error_accounts").MapScan(m)
+	if !errors.As(err, &qe) || !errors.Is(err, ErrNoSuchColumn) {
+		t.Fatal("Error should be a classified QueryError.", err)
+		return
+	}
+
+	err = c.DB().QueryRow("SELECT Password FROM query_error_accounts WHERE ID = ?", 1).Scan(&password)
+	if err != sql.ErrNoRows {
+		t.Fatal("sql.ErrNoRows should be returned as-is.", err)
+		return
+	}
+}
@@ -0,0 +1,161 @@
+package sqldb
+
+import (
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+/*
+This file handles rendering DeployQueries and UpdateQueries as text/template
+templates. Templates use helper funcs that output the correct SQL for the database
+type (ex.: {{datetime}} is DATETIME for MariaDB and TEXT for SQLite). This is an
+alternative to translators for cases where translating a query by modifying it is
+fragile; the output of a template is always the same for a database type.
+*/
+
+// RenderQueryTemplate renders a query as a text/template template using the helper
+// funcs for the config's database type, and any QueryTemplateFuncs.
+//
+// Predefined helper funcs:
+//   - {{autoIncrementPK "ID"}}: an auto incrementing integer primary key column.
+//   - {{datetime}}: the data type for a date and time column.
+//   - {{nowUTC}}: the current date and time in UTC, ex.: for a DEFAULT value. For
+//     MariaDB and MySQL, this requires MariaDB 10.2.1 or MySQL 8.0.13 or newer since
+//     older versions don't allow an expression as a DEFAULT value.
+//   - {{bool}}: the data type for a boolean column.
+//   - {{text 255}}: the data type for a text column with a maximum length. Without
+//     a length, {{text}}, the data type for a text column of any length.
+//   - {{blob}}: the data type for a binary column.
+//   - {{quote "Order"}}: quotes an identifier, see QuoteIdent().
+//   - {{dbType}}: the database type, ex.: {{if eq dbType "sqlite"}}...{{end}}.
+//
+// Example:
+//
+//	CREATE TABLE IF NOT EXISTS users (
+//	    {{autoIncrementPK "ID"}},
+//	    DatetimeCreated {{datetime}} NOT NULL DEFAULT {{nowUTC}},
+//	    Active {{bool}} NOT NULL DEFAULT 1,
+//	    Username {{text 255}} NOT NULL,
+//	    {{quote "Order"}} INT NOT NULL
+//	)
+func (c *Config) RenderQueryTemplate(query string) (rendered string, err error) {
+	tmpl, err := template.New("query").Funcs(queryTemplateFuncs(c.Type)).Funcs(c.QueryTemplateFuncs).Parse(query)
+	if err != nil {
+		return
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, nil)
+	if err != nil {
+		return
+	}
+
+	rendered = b.String()
+	return
+}
+
+// RenderQueryTemplate renders a query as a text/template template using the helper
+// funcs for the database type of the package level config.
+func RenderQueryTemplate(query string) (rendered string, err error) {
+	return cfg.RenderQueryTemplate(query)
+}
+
+// renderQueryTemplate renders a DeployQuery or UpdateQuery if QueryTemplates is
+// enabled, otherwise the query is returned as-is.
+func (c *Config) renderQueryTemplate(query string) (string, error) {
+	if !c.QueryTemplates {
+		return query, nil
+	}
+
+	return c.RenderQueryTemplate(query)
+}
+
+// queryTemplateFuncs returns the helper funcs used when rendering a query template
+// for a database type.
+func queryTemplateFuncs(t dbType) template.FuncMap {
+	return template.FuncMap{
+		"autoIncrementPK": func(name string) string {
+			switch t {
+			case DBTypeSQLite:
+				return name + " INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL"
+			case DBTypeMSSQL:
+				return name + " INT IDENTITY(1,1) NOT NULL PRIMARY KEY"
+			default:
+				return name + " INT NOT NULL AUTO_INCREMENT PRIMARY KEY"
+			}
+		},
+
+		"datetime": func() string {
+			switch t {
+			case DBTypeSQLite:
+				//See mariaDBToSQLiteType for why TEXT is used.
+				return "TEXT"
+			case DBTypeMSSQL:
+				return "DATETIME2"
+			default:
+				return "DATETIME"
+			}
+		},
+
+		"nowUTC": func() string {
+			switch t {
+			case DBTypeSQLite:
+				return "CURRENT_TIMESTAMP"
+			case DBTypeMSSQL:
+				return "SYSUTCDATETIME()"
+			case DBTypeMySQL, DBTypeMariaDB:
+				//MySQL requires an expression used as a DEFAULT value to be wrapped
+				//in parenthesis, MariaDB accepts either format.
+				return "(UTC_TIMESTAMP())"
+			default:
+				return "UTC_TIMESTAMP()"
+			}
+		},
+
+		"bool": func() string {
+			switch t {
+			case DBTypeSQLite:
+				return "INTEGER"
+			case DBTypeMSSQL:
+				return "BIT"
+			default:
+				return "BOOLEAN"
+			}
+		},
+
+		"text": func(length ...int) string {
+			switch {
+			case t == DBTypeSQLite:
+				return "TEXT"
+			case t == DBTypeMSSQL && len(length) == 0:
+				return "NVARCHAR(MAX)"
+			case t == DBTypeMSSQL:
+				return "NVARCHAR(" + strconv.Itoa(length[0]) + ")"
+			case len(length) == 0:
+				return "TEXT"
+			default:
+				return "VARCHAR(" + strconv.Itoa(length[0]) + ")"
+			}
+		},
+
+		"blob": func() string {
+			switch t {
+			case DBTypeMSSQL:
+				return "VARBINARY(MAX)"
+			case DBTypeSQLite:
+				return "BLOB"
+			default:
+				return "LONGBLOB"
+			}
+		},
+
+		"quote": func(name string) string {
+			return quoteIdent(t, name)
+		},
+
+		"dbType": func() string {
+			return string(t)
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package sqldb
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestRenderQueryTemplate(t *testing.T) {
+	query := `CREATE TABLE users ({{autoIncrementPK "ID"}}, Created {{datetime}} DEFAULT {{nowUTC}}, Active {{bool}}, Name {{text 255}}, Notes {{text}}, File {{blob}}, {{quote "Order"}} INT)`
+
+	tt := []struct {
+		dbType   dbType
+		expected string
+	}{
+		{
+			dbType:   DBTypeMariaDB,
+			expected: "CREATE TABLE users (ID INT NOT NULL AUTO_INCREMENT PRIMARY KEY, Created DATETIME DEFAULT (UTC_TIMESTAMP()), Active BOOLEAN, Name VARCHAR(255), Notes TEXT, File LONGBLOB, `Order` INT)",
+		},
+		{
+			dbType:   DBTypeMySQL,
+			expected: "CREATE TABLE users (ID INT NOT NULL AUTO_INCREMENT PRIMARY KEY, Created DATETIME DEFAULT (UTC_TIMESTAMP()), Active BOOLEAN, Name VARCHAR(255), Notes TEXT, File LONGBLOB, `Order` INT)",
+		},
+		{
+			dbType:   DBTypeSQLite,
+			expected: `CREATE TABLE users (ID INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL, Created TEXT DEFAULT CURRENT_TIMESTAMP, Active INTEGER, Name TEXT, Notes TEXT, File BLOB, "Order" INT)`,
+		},
+		{
+			dbType:   DBTypeMSSQL,
+			expected: "CREATE TABLE users (ID INT IDENTITY(1,1) NOT NULL PRIMARY KEY, Created DATETIME2 DEFAULT SYSUTCDATETIME(), Active BIT, Name NVARCHAR(255), Notes NVARCHAR(MAX), File VARBINARY(MAX), [Order] INT)",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(string(tc.dbType), func(t *testing.T) {
+			c := &Config{Type: tc.dbType}
+			got, err := c.RenderQueryTemplate(query)
+			if err != nil {
+				t.Fatal(err)
+				return
+			}
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad render.")
+				return
+			}
+		})
+	}
+
+	//Custom funcs and conditionals.
+	c := &Config{
+		Type: DBTypeSQLite,
+		QueryTemplateFuncs: template.FuncMap{
+			"datetime": func() string { return "INTEGER" },
+		},
+	}
+	got, err := c.RenderQueryTemplate(`{{if eq dbType "sqlite"}}{{datetime}}{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if got != "INTEGER" {
+		t.Fatal("Bad render.", got)
+		return
+	}
+
+	//Invalid template.
+	_, err = c.RenderQueryTemplate("{{unknownFunc}}")
+	if err == nil {
+		t.Fatal("Error should have occured for unknown func.")
+		return
+	}
+}
+
+func TestDeploySchemaQueryTemplates(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.QueryTemplates = true
+	c.DeployQueries = []string{
+		`CREATE TABLE IF NOT EXISTS users ({{autoIncrementPK "ID"}}, Created {{datetime}} NOT NULL DEFAULT {{nowUTC}}, {{quote "Order"}} {{text 10}})`,
+	}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	_, err = c.Connection().Exec(`INSERT INTO users ("Order") VALUES (?)`, "a")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	var created string
+	err = c.Connection().Get(&created, "SELECT Created FROM users")
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if !strings.HasPrefix(created, "20") {
+		t.Fatal("Bad default.", created)
+		return
+	}
+}
+
+func TestDeploySchemaQueryTemplatesMariaDBTranslation(t *testing.T) {
+	//Render for MariaDB and deploy to SQLite with the MariaDB to SQLite translator,
+	//the same as a schema written for MariaDB and deployed to SQLite.
+	mariadb := &Config{Type: DBTypeMariaDB}
+	q, err := mariadb.RenderQueryTemplate(`CREATE TABLE IF NOT EXISTS template_translation (ID INT NOT NULL AUTO_INCREMENT, Created {{datetime}} NOT NULL DEFAULT {{nowUTC}}, Name {{text 10}}, PRIMARY KEY (ID))`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueries = []string{q}
+	c.DeployQueryTranslators = []Translator{TranslateMariaDBToSQLite}
+
+	err = c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	_, err = c.Connection().Exec(`INSERT INTO template_translation (Name) VALUES (?)`, "a")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	var created string
+	err = c.Connection().Get(&created, "SELECT Created FROM template_translation")
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if !strings.HasPrefix(created, "20") {
+		t.Fatal("Bad default.", created)
+		return
+	}
+}
@@ -0,0 +1,162 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+This file handles retrying transactions that fail because of a transient error, an
+error that will most likely not occur if the transaction is run again. For example,
+SQLite returns SQLITE_BUSY when another connection is writing to the database, even
+with busy_timeout set, and MariaDB/MySQL return a deadlock error when two
+transactions lock the same rows in a different order.
+*/
+
+// RetryPolicy defines how many times, and how long to wait between each attempt,
+// a transaction is retried by RetryTx().
+type RetryPolicy struct {
+	//MaxAttempts is the maximum number of times the transaction is run, including
+	//the first attempt. Default is 5.
+	MaxAttempts int
+
+	//InitialDelay is how long to wait before the second attempt. The delay doubles
+	//for each attempt after that, up to MaxDelay. A random jitter of up to half the
+	//delay is subtracted so that concurrent transactions don't retry at the same
+	//time. Default is 50ms.
+	InitialDelay time.Duration
+
+	//MaxDelay is the maximum time to wait between attempts. Default is 2s.
+	MaxDelay time.Duration
+
+	//Backoff, if provided, returns how long to wait before the next attempt and is
+	//used instead of InitialDelay and MaxDelay. attempt is the attempt that just
+	//failed, starting at 1.
+	Backoff func(attempt int) time.Duration
+}
+
+// Defaults used for a RetryPolicy.
+const (
+	defaultRetryMaxAttempts  = 5
+	defaultRetryInitialDelay = 50 * time.Millisecond
+	defaultRetryMaxDelay     = 2 * time.Second
+)
+
+// delay returns how long to wait after an attempt fails.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	if p.Backoff != nil {
+		return p.Backoff(attempt)
+	}
+
+	initialDelay, maxDelay := p.InitialDelay, p.MaxDelay
+	if initialDelay <= 0 {
+		initialDelay = defaultRetryInitialDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	d := initialDelay
+	for i := 1; i < attempt && d < maxDelay; i++ {
+		d *= 2
+	}
+	d = min(d, maxDelay)
+
+	return d - rand.N(d/2+1)
+}
+
+// IsTransient returns true if an error is a transient error that may succeed if the
+// query or transaction is retried: ErrBusy, ErrDeadlock, or ErrLockTimeout. See
+// Classify().
+func IsTransient(err error) bool {
+	err = Classify(err)
+	return errors.Is(err, ErrBusy) || errors.Is(err, ErrDeadlock) || errors.Is(err, ErrLockTimeout)
+}
+
+// RetryTx runs fn in a transaction and commits the transaction. If fn, beginning the
+// transaction, or committing the transaction returns a transient error (see
+// IsTransient()) the transaction is rolled back and fn is run again in a new
+// transaction, per the config's RetryPolicy. Any other error is returned immediately
+// after rolling back the transaction.
+//
+// attempt is the number of the current attempt, starting at 1, and is provided for
+// logging. fn may be run more than once so fn should not have side effects outside
+// of the transaction.
+//
+// The number of attempts made is returned. The returned error is classified, see
+// Classify(), so errors.Is can be used to check if the last error was transient.
+//
+// If ctx is canceled while waiting between attempts, ctx's error is returned.
+func (c *Config) RetryTx(ctx context.Context, fn func(tx *sqlx.Tx, attempt int) error) (attempts int, err error) {
+	policy := RetryPolicy{}
+	if c.RetryPolicy != nil {
+		policy = *c.RetryPolicy
+	}
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRetryMaxAttempts
+	}
+
+	for attempts = 1; ; attempts++ {
+		err = c.runTx(ctx, fn, attempts)
+		if err == nil {
+			return
+		}
+
+		err = Classify(err)
+		if !IsTransient(err) || attempts >= maxAttempts {
+			return
+		}
+
+		d := policy.delay(attempts)
+		c.infoLn("sqldb.RetryTx", "Transient error on attempt", attempts, "of", maxAttempts, "retrying in", d.String()+".", "Error:", err)
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			err = ctx.Err()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RetryTx runs fn in a transaction, retrying transient errors, using the connection
+// pool stored in the package level config. See (*Config).RetryTx().
+func RetryTx(ctx context.Context, fn func(tx *sqlx.Tx, attempt int) error) (attempts int, err error) {
+	return cfg.RetryTx(ctx, fn)
+}
+
+// runTx runs one attempt of RetryTx. The transaction is rolled back if fn returns an
+// error or panics.
+func (c *Config) runTx(ctx context.Context, fn func(tx *sqlx.Tx, attempt int) error, attempt int) (err error) {
+	if !c.Connected() {
+		return ErrNotConnected
+	}
+
+	tx, err := c.connection.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	err = fn(tx, attempt)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	committed = err == nil
+	return
+}
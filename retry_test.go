@@ -0,0 +1,129 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{InitialDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	tt := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 300 * time.Millisecond},
+		{10, 300 * time.Millisecond},
+	}
+
+	for _, tc := range tt {
+		d := p.delay(tc.attempt)
+		if d > tc.max || d < tc.max/2 {
+			t.Fatal("Bad delay.", tc.attempt, d)
+			return
+		}
+	}
+}
+
+func TestRetryTx(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueries = []string{"CREATE TABLE IF NOT EXISTS users (ID INTEGER PRIMARY KEY, Name TEXT)"}
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     func(attempt int) time.Duration { return time.Millisecond },
+	}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	ctx := context.Background()
+
+	//Transient error, succeeds on the last attempt. Earlier attempts must be rolled
+	//back.
+	attempts, err := c.RetryTx(ctx, func(tx *sqlx.Tx, attempt int) error {
+		_, err := tx.Exec("INSERT INTO users (Name) VALUES (?)", "a")
+		if err != nil {
+			return err
+		}
+
+		if attempt < 3 {
+			return deadlock
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if attempts != 3 {
+		t.Fatal("Bad attempts.", attempts)
+		return
+	}
+
+	var count int
+	err = c.Connection().Get(&count, "SELECT COUNT(*) FROM users")
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if count != 1 {
+		t.Fatal("Failed attempts not rolled back.", count)
+		return
+	}
+
+	//Transient error on every attempt.
+	attempts, err = c.RetryTx(ctx, func(tx *sqlx.Tx, attempt int) error {
+		return deadlock
+	})
+	if !errors.Is(err, ErrDeadlock) || attempts != 3 {
+		t.Fatal("Deadlock should have been returned after 3 attempts.", attempts, err)
+		return
+	}
+
+	//Non-transient errors are not retried.
+	attempts, err = c.RetryTx(ctx, func(tx *sqlx.Tx, attempt int) error {
+		_, err := tx.Exec("INSERT INTO not_a_table (Name) VALUES (?)", "a")
+		return err
+	})
+	if !errors.Is(err, ErrNoSuchTable) || attempts != 1 {
+		t.Fatal("Error should have been returned without retrying.", attempts, err)
+		return
+	}
+
+	//Context canceled while waiting.
+	c.RetryPolicy.Backoff = func(attempt int) time.Duration { return time.Hour }
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = c.RetryTx(ctx, func(tx *sqlx.Tx, attempt int) error {
+		return deadlock
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("Context error should have been returned.", err)
+		return
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	if !IsTransient(&mysql.MySQLError{Number: 1205}) {
+		t.Fatal("Lock timeout should be transient.")
+		return
+	}
+	if IsTransient(&mysql.MySQLError{Number: 1062}) {
+		t.Fatal("Duplicate key should not be transient.")
+		return
+	}
+	if IsTransient(nil) {
+		t.Fatal("Nil should not be transient.")
+		return
+	}
+}
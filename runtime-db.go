@@ -0,0 +1,485 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+This file handles translating queries that are run at runtime, after a database has
+been deployed, versus DeployQueries and UpdateQueries that are translated when
+DeploySchema() or UpdateSchema() is called.
+
+Runtime queries are typically written with ? placeholders, which work with MariaDB,
+MySQL, and SQLite, but not with MSSQL which uses @p1, @p2, etc. TranslatedDB rebinds
+the placeholders for the database type and runs any RuntimeQueryTranslators and
+RuntimeQueryCheckedTranslators so that the same query can be used regardless of the
+database type.
+*/
+
+// runtimeQueryCacheSize is the maximum number of translated queries that are cached.
+// Once the cache is full, new queries are still translated but are not cached. This
+// prevents unbounded memory usage if queries are built with values inlined instead
+// of using placeholders.
+const runtimeQueryCacheSize = 1000
+
+// runtimeQueryCache stores translated runtime queries, keyed by the original query,
+// so that each query is only translated once.
+type runtimeQueryCache struct {
+	mu      sync.RWMutex
+	queries map[string]string
+}
+
+// get returns a translated query from the cache.
+func (r *runtimeQueryCache) get(query string) (translated string, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	translated, ok = r.queries[query]
+	return
+}
+
+// set saves a translated query to the cache, if the cache isn't full.
+func (r *runtimeQueryCache) set(query, translated string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.queries) >= runtimeQueryCacheSize {
+		return
+	}
+
+	r.queries[query] = translated
+}
+
+// TranslatedDB wraps the connection pool returned by Connection() and translates each
+// query before it is run. Queries are translated by running RuntimeQueryTranslators,
+// in order, and then rebinding ? placeholders to the format used by the database
+// type (ex.: @p1 for MSSQL). Translated queries are cached so that the same query is
+// only translated once.
+//
+// Only the methods defined on TranslatedDB translate queries. The methods of the
+// embedded *sqlx.DB that aren't overridden (ex.: Beginx(), NamedExec()) run queries
+// as-is; use Translate() to translate a query before using one of these methods.
+//
+// If a query cannot be translated, the error is returned and the query is not run.
+// For QueryRow() and QueryRowx(), the error is returned by the Row's Scan() and Err().
+//
+// If the config isn't connected to a database (Connect() wasn't called),
+// ErrNotConnected is returned. Translate() can still be used.
+//
+// Errors returned when running a query are wrapped in a *QueryError, except for
+// sql.ErrNoRows which is returned as-is.
+type TranslatedDB struct {
+	*sqlx.DB
+
+	config *Config
+}
+
+// DB returns the connection pool stored in a config wrapped so that queries are
+// translated for the database type before being run. Use this instead of Connection()
+// when you want to write queries once, with ? placeholders, and run them against any
+// supported database type. See TranslatedDB.
+//
+// If Connect() hasn't been called, running a query returns ErrNotConnected.
+func (c *Config) DB() *TranslatedDB {
+	return &TranslatedDB{
+		DB:     c.connection,
+		config: c,
+	}
+}
+
+// DB returns the connection pool stored in the package level config wrapped so that
+// queries are translated for the database type before being run.
+func DB() *TranslatedDB {
+	return cfg.DB()
+}
+
+// Translate returns the query after running RuntimeQueryTranslators and rebinding
+// placeholders for the database type. An error is returned if a translator reports
+// that the query cannot be translated (see FunctionTranslator).
+func (t *TranslatedDB) Translate(query string) (string, error) {
+	return t.config.translateRuntimeQuery(query)
+}
+
+// Exec runs a query, after translating it, that doesn't return rows.
+func (t *TranslatedDB) Exec(query string, args ...any) (sql.Result, error) {
+	q, err := t.translate(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.DB.Exec(q, args...)
+	return result, t.queryError(q, len(args), err)
+}
+
+// ExecContext runs a query, after translating it, that doesn't return rows.
+func (t *TranslatedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	q, err := t.translate(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.DB.ExecContext(ctx, q, args...)
+	return result, t.queryError(q, len(args), err)
+}
+
+// Query runs a query, after translating it, that returns rows.
+func (t *TranslatedDB) Query(query string, args ...any) (*sql.Rows, error) {
+	q, err := t.translate(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.DB.Query(q, args...)
+	return result, t.queryError(q, len(args), err)
+}
+
+// QueryContext runs a query, after translating it, that returns rows.
+func (t *TranslatedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	q, err := t.translate(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.DB.QueryContext(ctx, q, args...)
+	return result, t.queryError(q, len(args), err)
+}
+
+// Queryx runs a query, after translating it, that returns rows.
+func (t *TranslatedDB) Queryx(query string, args ...any) (*sqlx.Rows, error) {
+	q, err := t.translate(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.DB.Queryx(q, args...)
+	return result, t.queryError(q, len(args), err)
+}
+
+// QueryxContext runs a query, after translating it, that returns rows.
+func (t *TranslatedDB) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	q, err := t.translate(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.DB.QueryxContext(ctx, q, args...)
+	return result, t.queryError(q, len(args), err)
+}
+
+// QueryRow runs a query, after translating it, that returns at most one row. If the
+// query cannot be translated, it is not run and the error is returned by Scan().
+func (t *TranslatedDB) QueryRow(query string, args ...any) *Row {
+	q, err := t.translate(query)
+	if err != nil {
+		return &Row{err: err}
+	}
+
+	return &Row{Row: t.DB.QueryRow(q, args...), db: t, query: q, args: len(args)}
+}
+
+// QueryRowContext runs a query, after translating it, that returns at most one row.
+// If the query cannot be translated, it is not run and the error is returned by
+// Scan().
+func (t *TranslatedDB) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	q, err := t.translate(query)
+	if err != nil {
+		return &Row{err: err}
+	}
+
+	return &Row{Row: t.DB.QueryRowContext(ctx, q, args...), db: t, query: q, args: len(args)}
+}
+
+// QueryRowx runs a query, after translating it, that returns at most one row. If the
+// query cannot be translated, it is not run and the error is returned by Scan(),
+// StructScan(), etc.
+func (t *TranslatedDB) QueryRowx(query string, args ...any) *Rowx {
+	q, err := t.translate(query)
+	if err != nil {
+		return &Rowx{err: err}
+	}
+
+	return &Rowx{Row: t.DB.QueryRowx(q, args...), db: t, query: q, args: len(args)}
+}
+
+// QueryRowxContext runs a query, after translating it, that returns at most one row.
+// If the query cannot be translated, it is not run and the error is returned by
+// Scan(), StructScan(), etc.
+func (t *TranslatedDB) QueryRowxContext(ctx context.Context, query string, args ...any) *Rowx {
+	q, err := t.translate(query)
+	if err != nil {
+		return &Rowx{err: err}
+	}
+
+	return &Rowx{Row: t.DB.QueryRowxContext(ctx, q, args...), db: t, query: q, args: len(args)}
+}
+
+// Get runs a query, after translating it, and scans the resulting row into dest.
+func (t *TranslatedDB) Get(dest any, query string, args ...any) error {
+	q, err := t.translate(query)
+	if err != nil {
+		return err
+	}
+
+	return t.queryError(q, len(args), t.DB.Get(dest, q, args...))
+}
+
+// GetContext runs a query, after translating it, and scans the resulting row into
+// dest.
+func (t *TranslatedDB) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	q, err := t.translate(query)
+	if err != nil {
+		return err
+	}
+
+	return t.queryError(q, len(args), t.DB.GetContext(ctx, dest, q, args...))
+}
+
+// Select runs a query, after translating it, and scans the resulting rows into dest.
+func (t *TranslatedDB) Select(dest any, query string, args ...any) error {
+	q, err := t.translate(query)
+	if err != nil {
+		return err
+	}
+
+	return t.queryError(q, len(args), t.DB.Select(dest, q, args...))
+}
+
+// SelectContext runs a query, after translating it, and scans the resulting rows into
+// dest.
+func (t *TranslatedDB) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	q, err := t.translate(query)
+	if err != nil {
+		return err
+	}
+
+	return t.queryError(q, len(args), t.DB.SelectContext(ctx, dest, q, args...))
+}
+
+// Preparex prepares a statement, after translating the query.
+func (t *TranslatedDB) Preparex(query string) (*sqlx.Stmt, error) {
+	q, err := t.translate(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.DB.Preparex(q)
+	return result, t.queryError(q, 0, err)
+}
+
+// PreparexContext prepares a statement, after translating the query.
+func (t *TranslatedDB) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	q, err := t.translate(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.DB.PreparexContext(ctx, q)
+	return result, t.queryError(q, 0, err)
+}
+
+// translate translates a query that is about to be run. ErrNotConnected is returned
+// if there isn't a connection to run the query with.
+func (t *TranslatedDB) translate(query string) (string, error) {
+	if t.DB == nil {
+		return "", ErrNotConnected
+	}
+
+	return t.Translate(query)
+}
+
+// Row is the result of QueryRow(). Row is a *sql.Row that also returns the error
+// from translating the query, in which case the query was not run. Errors from
+// running the query are wrapped in a *QueryError, except for sql.ErrNoRows.
+type Row struct {
+	*sql.Row
+
+	err error
+
+	//db, query, and args are used to wrap errors from running the query, see
+	//TranslatedDB.queryError().
+	db    *TranslatedDB
+	query string
+	args  int
+}
+
+// Scan copies the columns of the row into dest. See sql.Row.Scan().
+func (r *Row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.db.queryError(r.query, r.args, r.Row.Scan(dest...))
+}
+
+// Err returns the error from translating or running the query. See sql.Row.Err().
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.db.queryError(r.query, r.args, r.Row.Err())
+}
+
+// Rowx is the result of QueryRowx(). Rowx is a *sqlx.Row that also returns the error
+// from translating the query, in which case the query was not run. Errors from
+// running the query are wrapped in a *QueryError, except for sql.ErrNoRows.
+type Rowx struct {
+	*sqlx.Row
+
+	err error
+
+	//db, query, and args are used to wrap errors from running the query, see
+	//TranslatedDB.queryError().
+	db    *TranslatedDB
+	query string
+	args  int
+}
+
+// Scan copies the columns of the row into dest. See sqlx.Row.Scan().
+func (r *Rowx) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.db.queryError(r.query, r.args, r.Row.Scan(dest...))
+}
+
+// StructScan copies the columns of the row into the fields of dest. See
+// sqlx.Row.StructScan().
+func (r *Rowx) StructScan(dest any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.db.queryError(r.query, r.args, r.Row.StructScan(dest))
+}
+
+// MapScan copies the columns of the row into dest. See sqlx.Row.MapScan().
+func (r *Rowx) MapScan(dest map[string]any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.db.queryError(r.query, r.args, r.Row.MapScan(dest))
+}
+
+// SliceScan returns the columns of the row. See sqlx.Row.SliceScan().
+func (r *Rowx) SliceScan() ([]any, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	values, err := r.Row.SliceScan()
+	return values, r.db.queryError(r.query, r.args, err)
+}
+
+// Columns returns the column names of the row. See sqlx.Row.Columns().
+func (r *Rowx) Columns() ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	columns, err := r.Row.Columns()
+	return columns, r.db.queryError(r.query, r.args, err)
+}
+
+// ColumnTypes returns the column types of the row. See sqlx.Row.ColumnTypes().
+func (r *Rowx) ColumnTypes() ([]*sql.ColumnType, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	types, err := r.Row.ColumnTypes()
+	return types, r.db.queryError(r.query, r.args, err)
+}
+
+// Err returns the error from translating or running the query. See sqlx.Row.Err().
+func (r *Rowx) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.db.queryError(r.query, r.args, r.Row.Err())
+}
+
+// queryError wraps an error returned from running a translated query in a
+// QueryError. Only the number of bind values is kept, not the values.
+func (t *TranslatedDB) queryError(query string, args int, err error) error {
+	return t.config.queryError(StageRuntimeQuery, 0, query, "", args, err)
+}
+
+// translateRuntimeQuery runs the RuntimeQueryTranslators on a query and rebinds the
+// placeholders for the database type. The translated query is cached.
+func (c *Config) translateRuntimeQuery(query string) (translated string, err error) {
+	bindType := c.bindType()
+	if len(c.RuntimeQueryTranslators) == 0 && len(c.RuntimeQueryCheckedTranslators) == 0 && bindType == sqlx.QUESTION {
+		return query, nil
+	}
+
+	if c.runtimeQueries != nil {
+		if translated, ok := c.runtimeQueries.get(query); ok {
+			return translated, nil
+		}
+	}
+
+	translated, err = RunTranslators(query, queryTranslators(c.RuntimeQueryTranslators, c.RuntimeQueryCheckedTranslators))
+	if err != nil {
+		return
+	}
+	translated = rebind(translated, c.Type, bindType)
+
+	if translated != query {
+		c.debugLn("sqldb.translateRuntimeQuery", "Translated runtime query.", "Original:", query, "Translated:", translated)
+	}
+
+	if c.runtimeQueries != nil {
+		c.runtimeQueries.set(query, translated)
+	}
+
+	return
+}
+
+// bindType returns the placeholder format used by the database type.
+func (c *Config) bindType() int {
+	switch c.Type {
+	case DBTypeMSSQL:
+		return sqlx.AT
+	default:
+		return sqlx.QUESTION
+	}
+}
+
+// rebind changes ? placeholders in a query, written for the database type t, to the
+// format used by bindType. Unlike sqlx.Rebind(), a ? in a string literal, quoted
+// identifier, or comment is not changed.
+func rebind(query string, t dbType, bindType int) string {
+	if bindType == sqlx.QUESTION || !strings.Contains(query, "?") {
+		return query
+	}
+
+	tokens := TokenizeDialect(t, query)
+	n := 0
+	for i, t := range tokens {
+		if t.Kind != TokenPlaceholder || t.Text != "?" {
+			continue
+		}
+
+		n++
+		switch bindType {
+		case sqlx.AT:
+			tokens[i].Text = "@p" + strconv.Itoa(n)
+		case sqlx.DOLLAR:
+			tokens[i].Text = "$" + strconv.Itoa(n)
+		case sqlx.NAMED:
+			tokens[i].Text = ":arg" + strconv.Itoa(n)
+		}
+	}
+
+	return JoinTokens(tokens)
+}
@@ -0,0 +1,230 @@
+package sqldb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestRebind(t *testing.T) {
+	tt := []struct {
+		name     string
+		query    string
+		bindType int
+		expected string
+	}{
+		{
+			name:     "question",
+			query:    "SELECT * FROM users WHERE ID = ?",
+			bindType: sqlx.QUESTION,
+			expected: "SELECT * FROM users WHERE ID = ?",
+		},
+		{
+			name:     "at",
+			query:    "INSERT INTO users (Name, Email) VALUES (?,?)",
+			bindType: sqlx.AT,
+			expected: "INSERT INTO users (Name, Email) VALUES (@p1,@p2)",
+		},
+		{
+			name:     "dollar",
+			query:    "UPDATE users SET Name=? WHERE ID=?",
+			bindType: sqlx.DOLLAR,
+			expected: "UPDATE users SET Name=$1 WHERE ID=$2",
+		},
+		{
+			name:     "question in string and comment",
+			query:    "SELECT * FROM users WHERE Name = 'who?' AND ID = ? -- why?",
+			bindType: sqlx.AT,
+			expected: "SELECT * FROM users WHERE Name = 'who?' AND ID = @p1 -- why?",
+		},
+		{
+			name:     "temp table is not a comment",
+			query:    "SELECT * FROM #users WHERE ID = ?",
+			bindType: sqlx.AT,
+			expected: "SELECT * FROM #users WHERE ID = @p1",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := rebind(tc.query, DBTypeMSSQL, tc.bindType)
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad rebind.")
+				return
+			}
+		})
+	}
+}
+
+func TestTranslatedDB(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+
+	//Translator to check that queries are cached.
+	calls := 0
+	c.RuntimeQueryTranslators = []Translator{
+		func(in string) string {
+			calls++
+			return strings.ReplaceAll(in, "UTC_TIMESTAMP()", "CURRENT_TIMESTAMP")
+		},
+	}
+
+	err := c.Connect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	db := c.DB()
+
+	_, err = db.Exec("CREATE TABLE users (ID INTEGER PRIMARY KEY, Name TEXT, Created TEXT)")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	insert := "INSERT INTO users (Name, Created) VALUES (?, UTC_TIMESTAMP())"
+	for _, name := range []string{"a", "b"} {
+		_, err = db.Exec(insert, name)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+	}
+
+	//CREATE TABLE and INSERT.
+	if calls != 2 {
+		t.Fatal("Translated queries not cached.", calls)
+		return
+	}
+
+	var count int
+	err = db.Get(&count, "SELECT COUNT(*) FROM users WHERE Created <= UTC_TIMESTAMP()")
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if count != 2 {
+		t.Fatal("Bad count.", count)
+		return
+	}
+
+	var names []string
+	err = db.Select(&names, "SELECT Name FROM users WHERE Name <> ? ORDER BY Name", "b")
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if len(names) != 1 || names[0] != "a" {
+		t.Fatal("Bad select.", names)
+		return
+	}
+}
+
+func TestTranslatedDBMSSQL(t *testing.T) {
+	c := NewMSSQL("10.0.0.1", "db_name", "user", "password")
+	c.RuntimeQueryTranslators = []Translator{TranslateMariaDBToMSSQL}
+
+	q := "SELECT `Name` FROM users WHERE Created < UTC_TIMESTAMP() AND ID = ?"
+	got, err := c.DB().Translate(q)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	expected := "SELECT [Name] FROM users WHERE Created < SYSUTCDATETIME() AND ID = @p1"
+	if got != expected {
+		t.Log("Got:", got)
+		t.Log("Exp:", expected)
+		t.Fatal("Bad translation.")
+		return
+	}
+}
+
+func TestTranslatedDBFunctions(t *testing.T) {
+	tr, err := FunctionTranslator(DBTypeMariaDB, DBTypeSQLite)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.RuntimeQueryCheckedTranslators = []CheckedTranslator{tr}
+
+	err = c.Connect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	var s string
+	err = c.DB().Get(&s, "SELECT CONCAT('a', IFNULL(NULL, 'b'), ?)", "c")
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if s != "abc" {
+		t.Fatal("Bad result.", s)
+		return
+	}
+
+	//Untranslatable query.
+	untranslatable := "SELECT GROUP_CONCAT(Name ORDER BY Name) FROM users"
+	err = c.DB().Get(&s, untranslatable)
+	var te *TranslationError
+	if !errors.As(err, &te) {
+		t.Fatal("TranslationError should have occured.", err)
+		return
+	}
+
+	//The error is returned by the row, the query is not run.
+	err = c.DB().QueryRow(untranslatable).Scan(&s)
+	if !errors.As(err, &te) {
+		t.Fatal("TranslationError should have occured.", err)
+		return
+	}
+
+	m := make(map[string]any)
+	err = c.DB().QueryRowx(untranslatable).MapScan(m)
+	if !errors.As(err, &te) {
+		t.Fatal("TranslationError should have occured.", err)
+		return
+	}
+
+	err = c.DB().QueryRowx("SELECT CONCAT('a', ?)", "b").Scan(&s)
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if s != "ab" {
+		t.Fatal("Bad result.", s)
+		return
+	}
+}
+
+func TestTranslatedDBNotConnected(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.RuntimeQueryTranslators = []Translator{TranslateMariaDBToSQLite}
+
+	db := c.DB()
+	if _, err := db.Exec("SELECT 1"); err != ErrNotConnected {
+		t.Fatal("ErrNotConnected should have occured.", err)
+		return
+	}
+
+	var i int
+	if err := db.QueryRow("SELECT 1").Scan(&i); err != ErrNotConnected {
+		t.Fatal("ErrNotConnected should have occured.", err)
+		return
+	}
+	if err := db.QueryRowx("SELECT 1").Err(); err != ErrNotConnected {
+		t.Fatal("ErrNotConnected should have occured.", err)
+		return
+	}
+
+	//Translating doesn't require a connection.
+	if _, err := db.Translate("SELECT 1"); err != nil {
+		t.Fatal(err)
+		return
+	}
+}
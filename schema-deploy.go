@@ -4,9 +4,6 @@ import (
 	"path"
 	"reflect"
 	"runtime"
-	"strings"
-
-	"github.com/jmoiron/sqlx"
 )
 
 // DeploySchemaOptions provides options when deploying a schema.
@@ -18,6 +15,10 @@ type DeploySchemaOptions struct {
 	//Each connection to an in-memory database references a new database, so to run
 	//queries against an in-memory database that was just deployed, we need to keep
 	//the connection open.
+	//
+	//This is not needed for shared-cache in-memory databases, such as those using
+	//SQLiteInMemoryFilepathNamed(), since these databases are kept alive until
+	//Close() is called.
 	CloseConnection bool //default true
 }
 
@@ -27,7 +28,8 @@ type DeploySchemaOptions struct {
 //
 // DeployQueries will be translated via DeployQueryTranslators and any DeployQuery
 // errors will be processed by DeployQueryErrorHandlers. Neither of these steps apply
-// to DeployFuncs.
+// to DeployFuncs. Each statement in a translated DeployQuery is run separately, see
+// SplitStatements().
 //
 // DeploySchemaOptions is a pointer so that in cases where you do not want to provide
 // any options, using the defaults, you can simply provide nil.
@@ -53,6 +55,12 @@ func (c *Config) DeploySchema(opts *DeploySchemaOptions) (err error) {
 		return
 	}
 
+	//For SQLite, create the directories for, and check, the database file.
+	err = c.prepareSQLiteFile()
+	if err != nil {
+		return
+	}
+
 	//Build the connection string used to connect to the database.
 	//
 	//The returned string will not included the database name (for non-SQLite) since
@@ -60,16 +68,14 @@ func (c *Config) DeploySchema(opts *DeploySchemaOptions) (err error) {
 	c.debugLn("sqldb.DeploySchema", "Getting connection string before deploying...")
 	connString := c.buildConnectionString(true)
 
-	//Get the correct driver based on the database type.
-	//
-	//If using SQLite, the correct driver is chosen based on build tags.
-	driver := getDriver(c.Type)
-
 	//Create the database, if it doesn't already exist.
 	//
 	//For MariaDB/MySQL, we need to create the actual database on the server.
 	//For SQLite, we need to Ping() the connection so the file is created on disk.
-	conn, err := sqlx.Open(driver, connString)
+	//
+	//The correct driver is chosen based on the database type, and if using SQLite,
+	//build tags. See open().
+	conn, err := c.open(connString)
 	if err != nil {
 		return
 	}
@@ -112,7 +118,7 @@ func (c *Config) DeploySchema(opts *DeploySchemaOptions) (err error) {
 	//This is only effective when an error does not occur in the below code. When an
 	//error occurs, Close() is always called.
 	if opts.CloseConnection {
-		defer c.Close()
+		defer c.close()
 	}
 
 	//Get connection to use for deploying.
@@ -120,38 +126,59 @@ func (c *Config) DeploySchema(opts *DeploySchemaOptions) (err error) {
 
 	//Run each DeployQuery.
 	c.infoLn("sqldb.DeploySchema", "Running DeployQueries...")
-	for _, q := range c.DeployQueries {
-		//Translate.
-		q := c.RunDeployQueryTranslators(q)
-
-		//Log for diagnostics. Seeing queries is sometimes nice to see what is
-		//happening.
-		//
-		//Trim logging length just to prevent super long queries from causing long
-		//logging entries.
-		ql, _, found := strings.Cut(strings.TrimSpace(q), "\n")
-		if found {
-			c.infoLn("DeployQuery:", ql)
-		} else if maxLen := 70; len(q) > maxLen {
-			c.infoLn("DeployQuery:", q[:maxLen]+"...")
-		} else {
-			c.infoLn("DeployQuery:", q)
+	for i, q := range c.dialectQueries("sqldb.DeploySchema", c.DeployQueries, c.DeployDialectQueries) {
+		//Render the query as a template, if enabled. The query is rendered into a
+		//separate variable so that the template is logged if rendering fails.
+		rendered, innerErr := c.renderQueryTemplate(q)
+		if innerErr != nil {
+			err = c.queryError(StageDeployQuery, i, q, "", 0, innerErr)
+			c.errorLn("sqldb.DeploySchema", "Error rendering query template.", q, err)
+			c.Close()
+			return
 		}
+		q = rendered
 
-		//Execute the query. If an error occurs, check if it should be ignored.
-		_, innerErr := connection.Exec(q)
-		if innerErr != nil && !c.runDeployQueryErrorHandlers(q, innerErr) {
-			err = innerErr
-			c.errorLn("sqldb.DeploySchema", "Error with query.", q, err)
+		//Translate. The query is translated into a separate variable so that the
+		//untranslated query is provided in the error if translating fails.
+		translated, innerErr := c.runTranslators(q, c.DeployQueryTranslators, c.DeployQueryCheckedTranslators)
+		if innerErr != nil {
+			err = c.queryError(StageDeployQuery, i, q, "", 0, innerErr)
+			c.errorLn("sqldb.DeploySchema", "Error translating query.", q, err)
 			c.Close()
 			return
 		}
+		q = translated
+
+		//Warn about reserved words used as identifiers since these will most likely
+		//cause the query to fail.
+		c.warnReservedIdentifiers("sqldb.DeploySchema", q)
+
+		//Execute each statement separately since a query may be more than one
+		//statement (ex.: a translated CREATE TABLE followed by CREATE INDEX) and
+		//not every driver supports running multiple statements at once.
+		for _, stmt := range SplitStatementsDialect(c.Type, q) {
+			//Log for diagnostics. Seeing queries is sometimes nice to see what is
+			//happening.
+			//
+			//Trim logging length just to prevent super long queries from causing long
+			//logging entries.
+			c.infoLn("DeployQuery:", shortQuery(stmt))
+
+			//Execute the query. If an error occurs, check if it should be ignored.
+			_, innerErr = connection.Exec(stmt)
+			if innerErr != nil && !c.runQueryErrorHandlers(c.DeployQueryErrorHandlers, c.DeployQueryNamedErrorHandlers, StageDeployQuery, i, stmt, innerErr) {
+				err = c.queryError(StageDeployQuery, i, stmt, "", 0, innerErr)
+				c.errorLn("sqldb.DeploySchema", "Error with query.", stmt, err)
+				c.Close()
+				return
+			}
+		}
 	}
 	c.infoLn("sqldb.DeploySchema", "Running DeployQueries...done")
 
 	//Run each DeployFunc.
 	c.infoLn("sqldb.DeploySchema", "Running DeployFuncs...")
-	for _, f := range c.DeployFuncs {
+	for i, f := range c.DeployFuncs {
 		//Get function name for diagnostic logging, since for DeployQueries above we
 		//log out some or all of each query.
 		rawNameWithPath := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
@@ -161,7 +188,7 @@ func (c *Config) DeploySchema(opts *DeploySchemaOptions) (err error) {
 		//Execute the func.
 		innerErr := f(connection)
 		if innerErr != nil {
-			err = innerErr
+			err = c.queryError(StageDeployFunc, i, "", funcName, 0, innerErr)
 			c.errorLn("sqldb.DeploySchema", "Error with DeployFunc.", funcName, err)
 			c.Close()
 			return
@@ -171,7 +198,7 @@ func (c *Config) DeploySchema(opts *DeploySchemaOptions) (err error) {
 
 	//Close the connection to the database, if needed.
 	if opts.CloseConnection {
-		c.Close()
+		c.close()
 		c.debugLn("sqldb.DeploySchema", "Connection closed after successful deploy.")
 	} else {
 		c.debugLn("sqldb.DeploySchema", "Connection left open after successful deploy.")
@@ -186,7 +213,8 @@ func (c *Config) DeploySchema(opts *DeploySchemaOptions) (err error) {
 //
 // DeployQueries will be translated via DeployQueryTranslators and any DeployQuery
 // errors will be processed by DeployQueryErrorHandlers. Neither of these steps apply
-// to DeployFuncs.
+// to DeployFuncs. Each statement in a translated DeployQuery is run separately, see
+// SplitStatements().
 //
 // DeploySchemaOptions is a pointer so that in cases where you do not want to provide
 // any options, using the defaults, you can simply provide nil.
@@ -199,13 +227,18 @@ func DeploySchema(opts *DeploySchemaOptions) (err error) {
 // RunDeployQueryTranslators runs the list of DeployQueryTranslators on the provided
 // query.
 //
-// This func is called in DeploySchema() but can also be called manually when you want
-// to translate a DeployQuery (for example, running a specific DeployQuery as part of
-// UpdateSchema).
+// This performs the same translation as DeploySchema() and can be called manually
+// when you want to translate a DeployQuery (for example, running a specific
+// DeployQuery as part of UpdateSchema). Note that DeploySchema() chooses the query
+// for the database type from DeployDialectQueries, and renders query templates if
+// QueryTemplates is enabled, before translating; neither is done here.
+//
+// If a translator reports that the query cannot be translated, the error is logged
+// and the query is returned as-is. Use RunTranslators() to get the error.
 func (c *Config) RunDeployQueryTranslators(in string) (out string) {
-	out = in
-	for _, t := range c.DeployQueryTranslators {
-		out = t(out)
+	out, err := c.runTranslators(in, c.DeployQueryTranslators, c.DeployQueryCheckedTranslators)
+	if err != nil {
+		c.errorLn("sqldb.RunDeployQueryTranslators", "Error translating query.", in, err)
 	}
 
 	return out
@@ -214,33 +247,14 @@ func (c *Config) RunDeployQueryTranslators(in string) (out string) {
 // RunDeployQueryTranslators runs the list of DeployQueryTranslators on the provided
 // query.
 //
-// This func is called in DeploySchema() but can also be called manually when you want
-// to translate a DeployQuery (for example, running a specific DeployQuery as part of
-// UpdateSchema).
+// This performs the same translation as DeploySchema() and can be called manually
+// when you want to translate a DeployQuery (for example, running a specific
+// DeployQuery as part of UpdateSchema). Note that DeploySchema() chooses the query
+// for the database type from DeployDialectQueries, and renders query templates if
+// QueryTemplates is enabled, before translating; neither is done here.
+//
+// If a translator reports that the query cannot be translated, the error is logged
+// and the query is returned as-is. Use RunTranslators() to get the error.
 func RunDeployQueryTranslators(in string) (out string) {
-	out = in
-	for _, t := range cfg.DeployQueryTranslators {
-		out = t(out)
-	}
-
-	return out
-}
-
-// runDeployQueryErrorHandlers runs the list of DeployQueryErrorHandlers when an error
-// occured from running a DeployQuery. This is run in Deploy().
-func (c *Config) runDeployQueryErrorHandlers(query string, err error) (ignoreError bool) {
-	//Make sure an error occured.
-	if err == nil {
-		return true
-	}
-
-	//Run each DeployQueryErrorHandler and see if any return true to ignore this error.
-	for _, eh := range c.DeployQueryErrorHandlers {
-		ignoreError = eh(query, err)
-		if ignoreError {
-			return
-		}
-	}
-
-	return false
+	return cfg.RunDeployQueryTranslators(in)
 }
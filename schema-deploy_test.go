@@ -120,3 +120,66 @@ func TestDeploySchemaAndClose(t *testing.T) {
 	//Close connection
 	c.Close()
 }
+
+func TestDeploySchemaMultipleStatements(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueryTranslators = []Translator{TranslateMariaDBToSQLite}
+
+	//Translated into a CREATE TABLE, a CREATE INDEX, and a CREATE TRIGGER.
+	createTable := `
+		CREATE TABLE IF NOT EXISTS users (
+			ID INT UNSIGNED NOT NULL AUTO_INCREMENT,
+			Username VARCHAR(255) NOT NULL,
+			Status ENUM('active','disabled') NOT NULL DEFAULT 'active',
+			Updated DATETIME NOT NULL DEFAULT '2000-01-01 00:00:00' ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (ID),
+			UNIQUE KEY Username_idx (Username)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4
+	`
+	c.DeployQueries = []string{createTable}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	db := c.Connection()
+	_, err = db.Exec("INSERT INTO users (Username) VALUES (?)", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//Unique index.
+	_, err = db.Exec("INSERT INTO users (Username) VALUES (?)", "a@example.com")
+	if err == nil {
+		t.Fatal("Unique index error should have occured.")
+		return
+	}
+
+	//ENUM check constraint.
+	_, err = db.Exec("INSERT INTO users (Username, Status) VALUES (?, ?)", "b@example.com", "unknown")
+	if err == nil {
+		t.Fatal("Check constraint error should have occured.")
+		return
+	}
+
+	//ON UPDATE trigger.
+	_, err = db.Exec("UPDATE users SET Status = ? WHERE Username = ?", "disabled", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	var updated string
+	err = db.Get(&updated, "SELECT Updated FROM users WHERE Username = ?", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if updated == "2000-01-01 00:00:00" {
+		t.Fatal("Trigger did not set column.", updated)
+		return
+	}
+}
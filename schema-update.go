@@ -4,7 +4,6 @@ import (
 	"path"
 	"reflect"
 	"runtime"
-	"strings"
 )
 
 // UpdateSchemaOptions provides options when updating a schema.
@@ -16,6 +15,10 @@ type UpdateSchemaOptions struct {
 	//Each connection to an in-memory database references a new database, so to run
 	//queries against an in-memory database that was just deployed, we need to keep
 	//the connection open.
+	//
+	//This is not needed for shared-cache in-memory databases, such as those using
+	//SQLiteInMemoryFilepathNamed(), since these databases are kept alive until
+	//Close() is called.
 	CloseConnection bool //default true
 }
 
@@ -25,7 +28,8 @@ type UpdateSchemaOptions struct {
 //
 // UpdateQueries will be translated via UpdateQueryTranslators and any UpdateQuery
 // errors will be processed by UpdateQueryErrorHandlers. Neither of these steps apply
-// to UpdateFuncs.
+// to UpdateFuncs. Each statement in a translated UpdateQuery is run separately, see
+// SplitStatements().
 //
 // UpdateSchemaOptions is a pointer so that in cases where you do not want to provide
 // any options, using the defaults, you can simply provide nil.
@@ -66,7 +70,7 @@ func (c *Config) UpdateSchema(opts *UpdateSchemaOptions) (err error) {
 	//This is only effective when an error does not occur in the below code. When an
 	//error occurs, Close() is always called.
 	if opts.CloseConnection {
-		defer c.Close()
+		defer c.close()
 	}
 
 	//Get connection to use for deploying.
@@ -74,38 +78,59 @@ func (c *Config) UpdateSchema(opts *UpdateSchemaOptions) (err error) {
 
 	//Run each UpdateQuery.
 	c.infoLn("sqldb.UpdateSchema", "Running UpdateQueries...")
-	for _, q := range c.UpdateQueries {
-		//Translate.
-		q = c.RunUpdateQueryTranslators(q)
-
-		//Log for diagnostics. Seeing queries is sometimes nice to see what is
-		//happening.
-		//
-		//Trim logging length just to prevent super long queries from causing long
-		//logging entries.
-		ql, _, found := strings.Cut(strings.TrimSpace(q), "\n")
-		if found {
-			c.infoLn("UpdateQuery:", ql)
-		} else if maxLen := 70; len(q) > maxLen {
-			c.infoLn("UpdateQuery:", q[:maxLen]+"...")
-		} else {
-			c.infoLn("UpdateQuery:", q)
+	for i, q := range c.dialectQueries("sqldb.UpdateSchema", c.UpdateQueries, c.UpdateDialectQueries) {
+		//Render the query as a template, if enabled. The query is rendered into a
+		//separate variable so that the template is logged if rendering fails.
+		rendered, innerErr := c.renderQueryTemplate(q)
+		if innerErr != nil {
+			err = c.queryError(StageUpdateQuery, i, q, "", 0, innerErr)
+			c.errorLn("sqldb.UpdateSchema", "Error rendering query template.", q, err)
+			c.Close()
+			return
 		}
+		q = rendered
 
-		//Execute the query. If an error occurs, check if it should be ignored.
-		_, innerErr := connection.Exec(q)
-		if innerErr != nil && !c.runUpdateQueryErrorHandlers(q, innerErr) {
-			err = innerErr
-			c.errorLn("sqldb.UpdateSchema", "Error with query.", q, err)
+		//Translate. The query is translated into a separate variable so that the
+		//untranslated query is provided in the error if translating fails.
+		translated, innerErr := c.runTranslators(q, c.UpdateQueryTranslators, c.UpdateQueryCheckedTranslators)
+		if innerErr != nil {
+			err = c.queryError(StageUpdateQuery, i, q, "", 0, innerErr)
+			c.errorLn("sqldb.UpdateSchema", "Error translating query.", q, err)
 			c.Close()
 			return
 		}
+		q = translated
+
+		//Warn about reserved words used as identifiers since these will most likely
+		//cause the query to fail.
+		c.warnReservedIdentifiers("sqldb.UpdateSchema", q)
+
+		//Execute each statement separately since a query may be more than one
+		//statement (ex.: a translated CREATE TABLE followed by CREATE INDEX) and
+		//not every driver supports running multiple statements at once.
+		for _, stmt := range SplitStatementsDialect(c.Type, q) {
+			//Log for diagnostics. Seeing queries is sometimes nice to see what is
+			//happening.
+			//
+			//Trim logging length just to prevent super long queries from causing long
+			//logging entries.
+			c.infoLn("UpdateQuery:", shortQuery(stmt))
+
+			//Execute the query. If an error occurs, check if it should be ignored.
+			_, innerErr = connection.Exec(stmt)
+			if innerErr != nil && !c.runQueryErrorHandlers(c.UpdateQueryErrorHandlers, c.UpdateQueryNamedErrorHandlers, StageUpdateQuery, i, stmt, innerErr) {
+				err = c.queryError(StageUpdateQuery, i, stmt, "", 0, innerErr)
+				c.errorLn("sqldb.UpdateSchema", "Error with query.", stmt, err)
+				c.Close()
+				return
+			}
+		}
 	}
 	c.infoLn("sqldb.UpdateSchema", "Running UpdateQueries...done")
 
 	//Run each UpdateFunc.
 	c.infoLn("sqldb.UpdateSchema", "Running UpdateFuncs...")
-	for _, f := range c.UpdateFuncs {
+	for i, f := range c.UpdateFuncs {
 		//Get function name for diagnostic logging, since for UpdateQueries above we
 		//log out some or all of each query.
 		rawNameWithPath := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
@@ -115,17 +140,17 @@ func (c *Config) UpdateSchema(opts *UpdateSchemaOptions) (err error) {
 		//Execute the func.
 		innerErr := f(connection)
 		if innerErr != nil {
-			err = innerErr
+			err = c.queryError(StageUpdateFunc, i, "", funcName, 0, innerErr)
 			c.errorLn("sqldb.UpdateSchema", "Error with UpdateFunc.", funcName, err)
 			c.Close()
-			return innerErr
+			return
 		}
 	}
 	c.infoLn("sqldb.UpdateSchema", "Running UpdateFuncs...done")
 
 	//Close the connection to the database, if needed.
 	if opts.CloseConnection {
-		c.Close()
+		c.close()
 		c.debugLn("sqldb.UpdateSchama", "Connection closed after success.")
 	} else {
 		c.debugLn("sqldb.UpdateSchama", "Connection left open after success.")
@@ -140,7 +165,8 @@ func (c *Config) UpdateSchema(opts *UpdateSchemaOptions) (err error) {
 //
 // UpdateQueries will be translated via UpdateQueryTranslators and any UpdateQuery
 // errors will be processed by UpdateQueryErrorHandlers. Neither of these steps apply
-// to UpdateFuncs.
+// to UpdateFuncs. Each statement in a translated UpdateQuery is run separately, see
+// SplitStatements().
 //
 // UpdateSchemaOptions is a pointer so that in cases where you do not want to provide
 // any options, using the defaults, you can simply provide nil.
@@ -153,11 +179,17 @@ func UpdateSchema(opts *UpdateSchemaOptions) (err error) {
 // RunUpdateQueryTranslators runs the list of UpdateQueryTranslators on the provided
 // query.
 //
-// This func is called in UpdateSchema().
+// This performs the same translation as UpdateSchema(). Note that UpdateSchema()
+// chooses the query for the database type from UpdateDialectQueries, and renders
+// query templates if QueryTemplates is enabled, before translating; neither is done
+// here.
+//
+// If a translator reports that the query cannot be translated, the error is logged
+// and the query is returned as-is. Use RunTranslators() to get the error.
 func (c *Config) RunUpdateQueryTranslators(in string) (out string) {
-	out = in
-	for _, t := range c.UpdateQueryTranslators {
-		out = t(out)
+	out, err := c.runTranslators(in, c.UpdateQueryTranslators, c.UpdateQueryCheckedTranslators)
+	if err != nil {
+		c.errorLn("sqldb.RunUpdateQueryTranslators", "Error translating query.", in, err)
 	}
 
 	return out
@@ -166,31 +198,13 @@ func (c *Config) RunUpdateQueryTranslators(in string) (out string) {
 // RunUpdateQueryTranslators runs the list of UpdateQueryTranslators on the provided
 // query.
 //
-// This func is called in UpdateSchema().
+// This performs the same translation as UpdateSchema(). Note that UpdateSchema()
+// chooses the query for the database type from UpdateDialectQueries, and renders
+// query templates if QueryTemplates is enabled, before translating; neither is done
+// here.
+//
+// If a translator reports that the query cannot be translated, the error is logged
+// and the query is returned as-is. Use RunTranslators() to get the error.
 func RunUpdateQueryTranslators(in string) (out string) {
-	out = in
-	for _, t := range cfg.UpdateQueryTranslators {
-		out = t(out)
-	}
-
-	return out
-}
-
-// runUpdateQueryErrorHandlers runs the list of UpdateQueryErrorHandlers when an error
-// occured from running a UpdateQuery. This is run in Update().
-func (c *Config) runUpdateQueryErrorHandlers(query string, err error) (ignoreError bool) {
-	//Make sure an error occured.
-	if err == nil {
-		return true
-	}
-
-	//Run each UpdateQueryErrorHandler and see if any return true to ignore this error.
-	for _, eh := range c.UpdateQueryErrorHandlers {
-		ignoreError = eh(query, err)
-		if ignoreError {
-			return
-		}
-	}
-
-	return false
+	return cfg.RunUpdateQueryTranslators(in)
 }
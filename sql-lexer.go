@@ -0,0 +1,338 @@
+package sqldb
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+This file handles splitting a SQL query into tokens. Tokenizing a query, versus
+using strings.Replace(), allows Translators to only modify the parts of a query that
+should be modified. For example, a column named "DateCreated" won't be modified when
+translating DATE columns, and text within a string literal or comment won't ever be
+modified.
+
+Tokenizing is lossless; joining the text of each token results in the original
+query, including all whitespace and comments.
+*/
+
+// TokenKind is the type of a Token.
+type TokenKind int
+
+const (
+	TokenWhitespace       TokenKind = iota //spaces, tabs, newlines.
+	TokenComment                           //-- comment, # comment (MariaDB/MySQL), or /* comment */.
+	TokenKeyword                           //a bare word that is a SQL keyword (ex.: CREATE, INT).
+	TokenIdentifier                        //a bare word that is not a SQL keyword (ex.: a column name).
+	TokenQuotedIdentifier                  //`name` or [name].
+	TokenString                            //'text'.
+	TokenDoubleQuoted                      //"text", a string in MariaDB/MySQL, an identifier otherwise.
+	TokenNumber                            //1, 1.5, 1e10, 0xFF.
+	TokenPunctuation                       //( ) , ; . and operators.
+	TokenPlaceholder                       //?, ?1, $1, :name, @name.
+)
+
+// String returns the name of the TokenKind, for debugging.
+func (k TokenKind) String() string {
+	switch k {
+	case TokenWhitespace:
+		return "whitespace"
+	case TokenComment:
+		return "comment"
+	case TokenKeyword:
+		return "keyword"
+	case TokenIdentifier:
+		return "identifier"
+	case TokenQuotedIdentifier:
+		return "quoted identifier"
+	case TokenString:
+		return "string"
+	case TokenDoubleQuoted:
+		return "double quoted"
+	case TokenNumber:
+		return "number"
+	case TokenPunctuation:
+		return "punctuation"
+	case TokenPlaceholder:
+		return "placeholder"
+	default:
+		return "unknown"
+	}
+}
+
+// Token is a piece of a SQL query.
+type Token struct {
+	Kind TokenKind
+
+	//Text is the token exactly as it appears in the query, including quotes for
+	//strings and quoted identifiers.
+	Text string
+}
+
+// Tokenize splits a SQL query into tokens.
+//
+// Whether a bare word is a TokenKeyword or a TokenIdentifier is determined by a list
+// of common SQL keywords, not the word's position in the query. Therefore, a column
+// named "Date" is a TokenKeyword. Translators should use the position of a token
+// (ex.: the first word of a column definition is the column's name) when deciding
+// what a word is.
+//
+// The query is tokenized as MariaDB/MySQL does; backslash escapes within strings are
+// handled (ex.: 'it\'s') and # starts a comment. Use TokenizeDialect() for queries
+// written for another database type.
+func Tokenize(query string) (tokens []Token) {
+	return TokenizeDialect(DBTypeMariaDB, query)
+}
+
+// TokenizeDialect splits a SQL query, written for the database type t, into tokens.
+//
+// Backslash escapes within strings and # comments are only handled for MariaDB and
+// MySQL. Other databases treat a backslash as a normal character (ex.: 'C:\' is a
+// complete string in SQLite) and # as part of a name or an operator (ex.: #temp
+// tables in SQL Server).
+func TokenizeDialect(t dbType, query string) (tokens []Token) {
+	mysql := t == DBTypeMariaDB || t == DBTypeMySQL
+	for i := 0; i < len(query); {
+		kind, n := nextToken(query[i:], mysql)
+		tokens = append(tokens, Token{Kind: kind, Text: query[i : i+n]})
+		i += n
+	}
+
+	return
+}
+
+// JoinTokens joins tokens back into a query.
+func JoinTokens(tokens []Token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.Text)
+	}
+
+	return b.String()
+}
+
+// IsWord returns true if the token is a bare word, a keyword or an identifier.
+func (t Token) IsWord() bool {
+	return t.Kind == TokenKeyword || t.Kind == TokenIdentifier
+}
+
+// Is returns true if the token is a bare word matching one of the given words, case
+// insensitively.
+func (t Token) Is(words ...string) bool {
+	if !t.IsWord() {
+		return false
+	}
+
+	for _, w := range words {
+		if strings.EqualFold(t.Text, w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsPunctuation returns true if the token is the given punctuation.
+func (t Token) IsPunctuation(p string) bool {
+	return t.Kind == TokenPunctuation && t.Text == p
+}
+
+// Significant returns true if the token is not whitespace or a comment.
+func (t Token) Significant() bool {
+	return t.Kind != TokenWhitespace && t.Kind != TokenComment
+}
+
+// IsName returns true if the token can be the name of a table or column, a bare
+// word or a quoted identifier.
+func (t Token) IsName() bool {
+	return t.IsWord() || t.Kind == TokenQuotedIdentifier || t.Kind == TokenDoubleQuoted
+}
+
+// Unquoted returns the text of the token with any surrounding quotes removed. This
+// is used to compare names regardless of how they are quoted.
+func (t Token) Unquoted() string {
+	switch t.Kind {
+	case TokenQuotedIdentifier, TokenDoubleQuoted, TokenString:
+		if len(t.Text) >= 2 {
+			return t.Text[1 : len(t.Text)-1]
+		}
+	}
+
+	return t.Text
+}
+
+// nextToken returns the kind and length of the token at the start of s. mysql is
+// true when tokenizing a MariaDB/MySQL query.
+func nextToken(s string, mysql bool) (kind TokenKind, n int) {
+	r, size := utf8.DecodeRuneInString(s)
+
+	switch {
+	case unicode.IsSpace(r):
+		return TokenWhitespace, scanWhile(s, unicode.IsSpace)
+
+	case strings.HasPrefix(s, "--"), mysql && r == '#':
+		end := strings.IndexByte(s, '\n')
+		if end == -1 {
+			return TokenComment, len(s)
+		}
+		return TokenComment, end
+
+	case strings.HasPrefix(s, "/*"):
+		end := strings.Index(s[2:], "*/")
+		if end == -1 {
+			return TokenComment, len(s)
+		}
+		return TokenComment, end + 4
+
+	case r == '\'':
+		return TokenString, scanQuoted(s, '\'', mysql)
+
+	case r == '"':
+		return TokenDoubleQuoted, scanQuoted(s, '"', mysql)
+
+	case r == '`':
+		return TokenQuotedIdentifier, scanQuoted(s, '`', false)
+
+	case r == '[':
+		return TokenQuotedIdentifier, scanQuoted(s, ']', false)
+
+	case isDigit(r), r == '.' && len(s) > 1 && isDigit(rune(s[1])):
+		return TokenNumber, scanNumber(s)
+
+	case isWordStart(r):
+		n = scanWhile(s, isWordPart)
+		if isKeyword(s[:n]) {
+			return TokenKeyword, n
+		}
+		return TokenIdentifier, n
+
+	case r == '?':
+		return TokenPlaceholder, 1 + scanWhile(s[1:], isDigit)
+
+	case r == '$' && len(s) > 1 && isDigit(rune(s[1])):
+		return TokenPlaceholder, 1 + scanWhile(s[1:], isDigit)
+
+	case (r == ':' || r == '@') && len(s) > 1 && isWordStart(rune(s[1])):
+		return TokenPlaceholder, 1 + scanWhile(s[1:], isWordPart)
+	}
+
+	for _, op := range multiCharOperators {
+		if strings.HasPrefix(s, op) {
+			return TokenPunctuation, len(op)
+		}
+	}
+
+	return TokenPunctuation, size
+}
+
+// multiCharOperators are operators made of more than one character that are kept
+// together as a single token.
+var multiCharOperators = []string{"<=>", "<=", ">=", "<>", "!=", "==", "||", "::", "<<", ">>"}
+
+// scanWhile returns the number of bytes at the start of s where f is true.
+func scanWhile(s string, f func(rune) bool) int {
+	for i, r := range s {
+		if !f(r) {
+			return i
+		}
+	}
+
+	return len(s)
+}
+
+// scanQuoted returns the length of the quoted text at the start of s, including the
+// quotes. A doubled closing quote is an escaped quote. If backslash is true, a
+// backslash escapes the next character. An unterminated quote runs to the end of s.
+func scanQuoted(s string, closing byte, backslash bool) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case backslash && s[i] == '\\':
+			i++
+		case s[i] == closing:
+			if i+1 < len(s) && s[i+1] == closing {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+
+	return len(s)
+}
+
+// scanNumber returns the length of the number at the start of s.
+func scanNumber(s string) int {
+	if len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		return 2 + scanWhile(s[2:], isHexDigit)
+	}
+
+	i := scanWhile(s, isDigit)
+	if i < len(s) && s[i] == '.' {
+		i++
+		i += scanWhile(s[i:], isDigit)
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if d := scanWhile(s[j:], isDigit); d > 0 {
+			i = j + d
+		}
+	}
+
+	return i
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isHexDigit(r rune) bool {
+	return isDigit(r) || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
+
+func isWordStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isWordPart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isKeyword returns true if the word is a SQL keyword.
+func isKeyword(word string) bool {
+	_, ok := sqlKeywords[strings.ToUpper(word)]
+	return ok
+}
+
+// sqlKeywords is a list of common SQL keywords and data types across the supported
+// databases. This is used to classify bare words as a TokenKeyword.
+var sqlKeywords = makeSet(
+	"ADD", "AFTER", "ALL", "ALTER", "AND", "AS", "ASC", "AUTO_INCREMENT", "AUTOINCREMENT",
+	"BEFORE", "BEGIN", "BETWEEN", "BIGINT", "BINARY", "BIT", "BLOB", "BOOL", "BOOLEAN", "BY",
+	"CASCADE", "CASE", "CHANGE", "CHAR", "CHARACTER", "CHARSET", "CHECK", "COLLATE", "COLUMN",
+	"COMMENT", "CONFLICT", "CONSTRAINT", "CREATE", "CROSS", "CURRENT_DATE", "CURRENT_TIME",
+	"CURRENT_TIMESTAMP", "DATABASE", "DATE", "DATETIME", "DECIMAL", "DEFAULT", "DELETE", "DESC",
+	"DISTINCT", "DOUBLE", "DROP", "DUPLICATE", "EACH", "ELSE", "END", "ENGINE", "ENUM", "EXISTS",
+	"FLOAT", "FOR", "FOREIGN", "FROM", "FULL", "FULLTEXT", "GROUP", "HAVING", "IF", "IGNORE",
+	"IN", "INDEX", "INNER", "INSERT", "INT", "INTEGER", "INTO", "IS", "JOIN", "JSON", "KEY",
+	"LEFT", "LIKE", "LIMIT", "LONGBLOB", "LONGTEXT", "MEDIUMBLOB", "MEDIUMINT", "MEDIUMTEXT",
+	"MODIFY", "NOT", "NULL", "NUMERIC", "OFFSET", "ON", "OR", "ORDER", "OUTER", "PRIMARY",
+	"REAL", "REFERENCES", "RENAME", "REPLACE", "RESTRICT", "RIGHT", "ROW", "SELECT", "SET",
+	"SIGNED", "SMALLINT", "SPATIAL", "TABLE", "TEMPORARY", "TEXT", "THEN", "TIME", "TIMESTAMP",
+	"TINYBLOB", "TINYINT", "TINYTEXT", "TO", "TRIGGER", "UNION", "UNIQUE", "UNSIGNED",
+	"UPDATE", "USING", "UTC_TIMESTAMP", "VALUES", "VARBINARY", "VARCHAR", "VIEW", "WHEN",
+	"WHERE", "WITH", "ZEROFILL",
+)
+
+// makeSet returns a map used to check if a word is in a list.
+func makeSet(words ...string) map[string]struct{} {
+	m := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		m[w] = struct{}{}
+	}
+
+	return m
+}
@@ -0,0 +1,196 @@
+package sqldb
+
+import (
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	q := "SELECT `Date`, [Time] FROM users WHERE Name = 'it''s \\'x\\'' AND ID > ?1 -- comment\n/* block */ AND Amount >= 1.5e3 AND Other = @p1 AND Col::TEXT <> \"dq\""
+	tokens := Tokenize(q)
+
+	//Tokenizing must be lossless.
+	if JoinTokens(tokens) != q {
+		t.Fatal("Joined tokens do not match query.", JoinTokens(tokens))
+		return
+	}
+
+	//Check kinds, skipping whitespace.
+	expected := []Token{
+		{TokenKeyword, "SELECT"},
+		{TokenQuotedIdentifier, "`Date`"},
+		{TokenPunctuation, ","},
+		{TokenQuotedIdentifier, "[Time]"},
+		{TokenKeyword, "FROM"},
+		{TokenIdentifier, "users"},
+		{TokenKeyword, "WHERE"},
+		{TokenIdentifier, "Name"},
+		{TokenPunctuation, "="},
+		{TokenString, "'it''s \\'x\\''"},
+		{TokenKeyword, "AND"},
+		{TokenIdentifier, "ID"},
+		{TokenPunctuation, ">"},
+		{TokenPlaceholder, "?1"},
+		{TokenComment, "-- comment"},
+		{TokenComment, "/* block */"},
+		{TokenKeyword, "AND"},
+		{TokenIdentifier, "Amount"},
+		{TokenPunctuation, ">="},
+		{TokenNumber, "1.5e3"},
+		{TokenKeyword, "AND"},
+		{TokenIdentifier, "Other"},
+		{TokenPunctuation, "="},
+		{TokenPlaceholder, "@p1"},
+		{TokenKeyword, "AND"},
+		{TokenIdentifier, "Col"},
+		{TokenPunctuation, "::"},
+		{TokenKeyword, "TEXT"},
+		{TokenPunctuation, "<>"},
+		{TokenDoubleQuoted, "\"dq\""},
+	}
+
+	var got []Token
+	for _, tok := range tokens {
+		if tok.Kind != TokenWhitespace {
+			got = append(got, tok)
+		}
+	}
+	if len(got) != len(expected) {
+		t.Fatal("Token count mismatch.", len(got), len(expected), got)
+		return
+	}
+	for i, tok := range got {
+		if tok != expected[i] {
+			t.Fatal("Token mismatch.", i, tok.Kind, tok.Text, "expected", expected[i].Kind, expected[i].Text)
+			return
+		}
+	}
+}
+
+func TestTokenizeUnterminated(t *testing.T) {
+	//Unterminated strings and comments run to the end of the query but are still
+	//lossless.
+	for _, q := range []string{"SELECT 'abc", "SELECT /* abc", "SELECT `abc", "SELECT \"abc\\"} {
+		if JoinTokens(Tokenize(q)) != q {
+			t.Fatal("Joined tokens do not match query.", q)
+			return
+		}
+	}
+}
+
+func TestTokenizeDialect(t *testing.T) {
+	tt := []struct {
+		name     string
+		dbType   dbType
+		query    string
+		expected []Token
+	}{
+		{
+			name:   "sqlite backslash is not an escape",
+			dbType: DBTypeSQLite,
+			query:  "SELECT 'C:\\', 'x'",
+			expected: []Token{
+				{TokenKeyword, "SELECT"},
+				{TokenString, "'C:\\'"},
+				{TokenPunctuation, ","},
+				{TokenString, "'x'"},
+			},
+		},
+		{
+			name:   "mariadb backslash is an escape",
+			dbType: DBTypeMariaDB,
+			query:  "SELECT 'C:\\', 'x'",
+			expected: []Token{
+				{TokenKeyword, "SELECT"},
+				{TokenString, "'C:\\', '"},
+				{TokenIdentifier, "x"},
+				{TokenString, "'"},
+			},
+		},
+		{
+			name:   "mariadb hash comment",
+			dbType: DBTypeMariaDB,
+			query:  "SELECT 1 # it's a comment\nFROM users",
+			expected: []Token{
+				{TokenKeyword, "SELECT"},
+				{TokenNumber, "1"},
+				{TokenComment, "# it's a comment"},
+				{TokenKeyword, "FROM"},
+				{TokenIdentifier, "users"},
+			},
+		},
+		{
+			name:   "mysql hash comment",
+			dbType: DBTypeMySQL,
+			query:  "DROP TABLE users #old",
+			expected: []Token{
+				{TokenKeyword, "DROP"},
+				{TokenKeyword, "TABLE"},
+				{TokenIdentifier, "users"},
+				{TokenComment, "#old"},
+			},
+		},
+		{
+			name:   "mssql hash is not a comment",
+			dbType: DBTypeMSSQL,
+			query:  "SELECT * FROM #users",
+			expected: []Token{
+				{TokenKeyword, "SELECT"},
+				{TokenPunctuation, "*"},
+				{TokenKeyword, "FROM"},
+				{TokenPunctuation, "#"},
+				{TokenIdentifier, "users"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens := TokenizeDialect(tc.dbType, tc.query)
+			if JoinTokens(tokens) != tc.query {
+				t.Fatal("Joined tokens do not match query.", JoinTokens(tokens))
+				return
+			}
+
+			var got []Token
+			for _, tok := range tokens {
+				if tok.Kind != TokenWhitespace {
+					got = append(got, tok)
+				}
+			}
+			if len(got) != len(tc.expected) {
+				t.Fatal("Token count mismatch.", len(got), len(tc.expected), got)
+				return
+			}
+			for i, tok := range got {
+				if tok != tc.expected[i] {
+					t.Fatal("Token mismatch.", i, tok.Kind, tok.Text, "expected", tc.expected[i].Kind, tc.expected[i].Text)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestTokenMethods(t *testing.T) {
+	tok := Token{Kind: TokenKeyword, Text: "varchar"}
+	if !tok.Is("INT", "VARCHAR") {
+		t.Fatal("Is should match case insensitively.")
+		return
+	}
+
+	tok = Token{Kind: TokenString, Text: "'VARCHAR'"}
+	if tok.Is("VARCHAR") {
+		t.Fatal("Is should not match a string.")
+		return
+	}
+	if tok.Unquoted() != "VARCHAR" {
+		t.Fatal("Unquoted not correct.", tok.Unquoted())
+		return
+	}
+
+	tok = Token{Kind: TokenQuotedIdentifier, Text: "`Users`"}
+	if !tok.IsName() || tok.Unquoted() != "Users" {
+		t.Fatal("Quoted identifier should be a name.", tok.Unquoted())
+		return
+	}
+}
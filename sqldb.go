@@ -58,6 +58,28 @@ at once.
 		return
 	  }
 
+# Runtime Queries
+
+Queries run with Connection() are run as-is. To write runtime queries once, with ?
+placeholders, and run them against any supported database type, use DB() instead.
+DB() returns the connection pool wrapped so that each query is translated with
+RuntimeQueryTranslators and placeholders are rebound for the database type (ex.: @p1
+for MSSQL). Translated queries are cached so each query is only translated once.
+
+	c := cfg.DB()
+	err := c.Get(&name, "SELECT Name FROM users WHERE ID = ?", id)
+
+SQL functions and clauses that differ between database types (ex.: NOW(), IFNULL(),
+GROUP_CONCAT(), LIMIT) can be translated with FunctionTranslator(). Queries that
+contain something that cannot be translated return a *TranslationError instead of
+being run as-is. Upserts, which use a different syntax for each database type, can
+be built with Upsert.
+
+Table and column names that are reserved words (ex.: Order, Key) must be quoted and
+each database type uses different quotes. Use QuoteIdent() and QuoteColumns() to
+quote names for the database type. A warning is logged when a DeployQuery or
+UpdateQuery creates a table or column named with an unquoted reserved word.
+
 # Deploying a Database
 
 Deployment of a schema is done via DeployQueries and DeployFuncs, along with the
@@ -73,9 +95,37 @@ to the dialect required for another database type. This is extremely useful if y
 application supports multiple database types. Note that DeployQueryTranslators do not
 apply to DeployFuncs since DeployFuncs are typically more than just a SQL query.
 
+Predefined translators are provided for common dialect translations, see
+TranslateMariaDBToSQLite, TranslateSQLiteToMariaDB, TranslateMariaDBToMSSQL, and
+TranslateMariaDBToPostgres. Custom translators can be written using TokenTranslator
+so that only the needed parts of a query are modified, not string literals or
+column names. A Translator returns a query as-is when it cannot be translated, while
+a CheckedTranslator returns an error (ex.: TryTranslateMariaDBToSQLite or
+FunctionTranslator); CheckedTranslators are set in the DeployQueryCheckedTranslators,
+UpdateQueryCheckedTranslators, and RuntimeQueryCheckedTranslators fields and are run
+after the Translators. A translator may return more than one statement separated by
+semicolons (ex.: a CREATE TABLE followed by CREATE INDEX statements), each statement
+is run separately, see SplitStatements.
+
+Instead of translating queries, DeployQueries and UpdateQueries can be written as
+text/template templates with helper funcs that output SQL for the database type, ex.:
+{{autoIncrementPK "ID"}} or {{datetime}}. Set QueryTemplates to render each query
+before it is translated, see RenderQueryTemplate.
+
+To debug a query that is translated incorrectly, set TranslatorTracer to receive the
+change made by each translator, or call TraceTranslators directly. The sqldbtest
+package provides Golden() for testing a list of translators against a directory of
+.sql files and expected outputs.
+
 DeployQueryErrorHandlers is a list of functions that are run when any DeployQuery
 results in an error (as returned by [sql.Exec]). These funcs are used to evaluate,
-and if appropriate, ignore the error.
+and if appropriate, ignore the error. Error handlers built from a set of conditions
+with Handle() are set in DeployQueryNamedErrorHandlers instead.
+
+Queries that cannot be translated and must be written differently for each database
+type, or only run for some database types, can be provided in DeployDialectQueries
+instead of branching on the database type when building DeployQueries. See
+DialectQuery.
 
 DeployQueries and DeployFuncs should be safe to be rerun multiple times, particularly
 without INSERTing duplicate data. Use IF NOT EXISTS or check if something exists
@@ -93,11 +143,13 @@ available (see SQLite for ALTER TABLE...DROP COLUMN).
 
 # SQLite Library
 
-This package support two SQLite libraries, [github.com/mattn/go-sqlite3] and
-[gitlab.com/cznic/sqlite]. The mattn library requires CGO which can be troublesome
-for cross-compiling. The modernc library is pure golang, however, it is a translation,
-not the original SQLite code, and therefore does not have the same level of
-trustworthiness or extent of testing.
+This package support three SQLite libraries, [github.com/mattn/go-sqlite3],
+[gitlab.com/cznic/sqlite], and [github.com/ncruces/go-sqlite3]. The mattn library
+requires CGO which can be troublesome for cross-compiling. The modernc library is
+pure golang, however, it is a translation, not the original SQLite code, and
+therefore does not have the same level of trustworthiness or extent of testing. The
+ncruces library does not require CGO and runs the original SQLite code compiled to
+WASM, however, it is newer and less widely used.
 
 As of now, mattn is the default if no build tags are provided. This is simply due to
 the longer history of this library being available and the fact that this uses the
@@ -107,8 +159,21 @@ Use either library with build tags:
 
 	go build -tags mattn ...
 	go build -tags modernc ...
+	go build -tags ncruces ...
 	go run -tags mattn ...
 	go run -tags modernc ...
+	go run -tags ncruces ...
+
+More than one library can be built into the same binary by providing more than one
+build tag. The library is then chosen at runtime with the config SQLiteLibrary field,
+which is useful when a binary must run without CGO on some systems. If SQLiteLibrary
+is not set, the first library built in is used in the order mattn, ncruces, modernc
+(see SQLiteLibraries()).
+
+	go build -tags mattn,modernc ...
+
+The mattn and ncruces libraries cannot be built into the same binary since both
+register a driver named "sqlite3".
 
 The mattn library sets some default PRAGMA values, as noted in the source code at
 https://github.com/mattn/go-sqlite3/blob/ae2a61f847e10e6dd771ecd4e1c55e0421cdc7f9/sqlite3.go#L1086.
@@ -118,6 +183,19 @@ set when using the modernc library. This is done to make using both libraries ac
 the same manner, make them more interchangable with the same result. See
 DefaultSQLitePragmas.
 
+PRAGMAs can also be provided as typed, validated, values using SQLitePragmaConfig.
+These are translated to the format required by the SQLite library in use. PRAGMAs
+that a library cannot set via the filepath are run on each new connection instead.
+
+Additional SQLite database files can be attached to each connection with
+SQLiteAttachments. This allows joining across database files since ATTACH DATABASE
+only applies to a single connection in the connection pool otherwise.
+
+Custom SQL functions and collations implemented in Go can be registered with
+RegisterSQLiteFunc() and RegisterSQLiteCollation() before calling Connect(). These
+are registered using whichever SQLite library is in use, so the same code works
+with each library.
+
 # Notes
 
 This package uses [github.com/jmoiron/sqlx] instead of the Go standard library
@@ -129,13 +207,13 @@ package sqldb
 import (
 	"errors"
 	"fmt"
-	"log"
 	"net"
 	"net/url"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/jmoiron/sqlx"
 
@@ -171,6 +249,35 @@ type Config struct {
 	//SQLitePath is the path where the SQLite database file is located.
 	SQLitePath string
 
+	//SQLiteExpandPath expands a leading ~ to the user's home directory and any
+	//environment variables (ex.: $HOME or ${XDG_DATA_HOME}) in SQLitePath when
+	//connecting. SQLitePath itself is not modified. The path of a file: URI is
+	//percent-decoded before being expanded.
+	SQLiteExpandPath bool
+
+	//SQLiteCreateDirs creates any missing parent directories of SQLitePath when
+	//DeploySchema() is called.
+	SQLiteCreateDirs bool
+
+	//SQLiteFileMode sets the permissions of the SQLite database file when
+	//DeploySchema() is called (ex.: 0600 for databases storing secrets). If 0, the
+	//file is created by the SQLite library with permissions based on the umask.
+	//SQLite creates the WAL and journal files with the same permissions as the
+	//database file.
+	SQLiteFileMode os.FileMode
+
+	//SQLiteCheckHeader prevents DeploySchema() from deploying to a path where a file
+	//already exists but isn't a SQLite database, based on the file's header. This
+	//prevents accidentally writing to the wrong file. Don't use this with encrypted
+	//databases since the header is encrypted.
+	SQLiteCheckHeader bool
+
+	//SQLiteLibrary is the SQLite library to use when more than one SQLite library is
+	//built into the binary (ex.: go build -tags mattn,modernc). If blank, the
+	//default library is used; the first library returned by SQLiteLibraries() (mattn,
+	//then ncruces, then modernc). The chosen library must be built into the binary.
+	SQLiteLibrary library
+
 	//SQLitePragmas is a list of PRAGMAs to apply when connecting to a SQLite
 	//database. Typically this is used to set the journal mode or busy timeout.
 	//PRAGMAs provided here are in SQLite query format with an equals sign
@@ -185,6 +292,22 @@ type Config struct {
 	//https://pkg.go.dev/modernc.org/sqlite#Driver.Open
 	SQLitePragmas []string
 
+	//SQLitePragmaConfig is a typed alternative to SQLitePragmas for the more common
+	//PRAGMAs. Values are validated before connecting. Any PRAGMA set here overrides
+	//the PRAGMA of the same name in SQLitePragmas.
+	SQLitePragmaConfig SQLitePragmaConfig
+
+	//SQLiteAttachments is a list of additional SQLite database files to attach to
+	//each connection, keyed by the alias (schema name) used to reference the
+	//database in queries (ex.: SELECT * FROM archive.Users). Databases are attached
+	//to every connection in the connection pool when the connection is opened, so
+	//queries can join across databases regardless of which connection is used.
+	//Database files that do not exist are created when attached.
+	//
+	//Attached databases are available in DeploySchema() and UpdateSchema(), so
+	//tables can be deployed to an attached database using alias.table syntax.
+	SQLiteAttachments map[string]string
+
 	//MapperFunc is used to override the mapping of database column names to struct
 	//field names or struct tags. Mapping of column names is used during queries
 	//where sqlx's StructScan(), Get(), or Select() is used.
@@ -209,13 +332,20 @@ type Config struct {
 	//Each query should be safe to be rerun multiple times!
 	DeployQueries []string
 
+	//DeployDialectQueries is a list of SQL queries used to deploy a database schema
+	//that are written differently for some database types, or are only run for some
+	//database types. These are run after DeployQueries, are translated with
+	//DeployQueryTranslators, and errors are handled with DeployQueryErrorHandlers,
+	//the same as DeployQueries. See DialectQuery.
+	DeployDialectQueries []DialectQuery
+
 	//DeployFuncs is a list of functions, each containing at least one SQL query,
 	//that is used to deploy a database schema. Use these for more complicated schema
 	//deployment or initialization steps, such as INSERTing initial data. DeployFuncs
 	//should be used more sparingly than DeployQueries. These functions will be run
 	//when DeploySchema() is called.
 	//
-	//These functions are executed after DeployQueries.
+	//These functions are executed after DeployQueries and DeployDialectQueries.
 	//
 	//Each function should be safe to be rerun multiple times!
 	DeployFuncs []QueryFunc
@@ -228,9 +358,15 @@ type Config struct {
 	//A DeployQueryTranslator function takes a DeployQuery as an input and returns a
 	//rewritten query.
 	//
-	//See predefined translator functions starting with TF.
+	//See predefined translator functions starting with Translate.
 	DeployQueryTranslators []Translator
 
+	//DeployQueryCheckedTranslators is a list of translators that can report that a
+	//DeployQuery cannot be translated, ex.: FunctionTranslator(). These are run
+	//after DeployQueryTranslators. If a translator returns an error, DeploySchema()
+	//stops and returns the error.
+	DeployQueryCheckedTranslators []CheckedTranslator
+
 	//DeployQueryErrorHandlers is a list of functions that are run when an error
 	//results from running a DeployQuery and is used to determine if the error can be
 	//ignored. Use this for ignoring expected errors from SQL queries, typically when
@@ -242,6 +378,10 @@ type Config struct {
 	//ignored.
 	DeployQueryErrorHandlers []ErrorHandler
 
+	//DeployQueryNamedErrorHandlers is a list of error handlers built with Handle()
+	//from a set of conditions. These are run after DeployQueryErrorHandlers.
+	DeployQueryNamedErrorHandlers []*NamedErrorHandler
+
 	//UpdateQueries is a list of SQL queries used to update a database schema. These
 	//are typically used ot add new columns, ALTER a column, or DROP a column. These
 	//queries will be run when UpdateSchema() is called.
@@ -252,13 +392,20 @@ type Config struct {
 	//Each query should be safe to be rerun multiple times!
 	UpdateQueries []string
 
+	//UpdateDialectQueries is a list of SQL queries used to update a database schema
+	//that are written differently for some database types, or are only run for some
+	//database types. These are run after UpdateQueries, are translated with
+	//UpdateQueryTranslators, and errors are handled with UpdateQueryErrorHandlers,
+	//the same as UpdateQueries. See DialectQuery.
+	UpdateDialectQueries []DialectQuery
+
 	//UpdateFuncs is a list of functions, each containing at least one SQL query,
 	//that is used to update a database schema. Use these for more complicated schema
 	//updates, such as reading values before updating. UpdateFuncs should be used
 	//more sparingly than UpdateQueries. These functions will be run when
 	//UpdateSchema() is called.
 	//
-	//These functions are executed after UpdateQueries.
+	//These functions are executed after UpdateQueries and UpdateDialectQueries.
 	//
 	//Each function should be safe to be rerun multiple times!
 	UpdateFuncs []QueryFunc
@@ -270,6 +417,12 @@ type Config struct {
 	//a rewritten query.
 	UpdateQueryTranslators []Translator
 
+	//UpdateQueryCheckedTranslators is a list of translators that can report that an
+	//UpdateQuery cannot be translated, ex.: FunctionTranslator(). These are run
+	//after UpdateQueryTranslators. If a translator returns an error, UpdateSchema()
+	//stops and returns the error.
+	UpdateQueryCheckedTranslators []CheckedTranslator
+
 	//UpdateQueryErrorHandlers is a list of functions that are run when an error
 	//results from running an UpdateQuery and is used to determine if the error can
 	//be ignored.
@@ -277,6 +430,59 @@ type Config struct {
 	//from Exec as an input and returns true if the error should be ignored.
 	UpdateQueryErrorHandlers []ErrorHandler
 
+	//UpdateQueryNamedErrorHandlers is a list of error handlers built with Handle()
+	//from a set of conditions. These are run after UpdateQueryErrorHandlers.
+	UpdateQueryNamedErrorHandlers []*NamedErrorHandler
+
+	//RuntimeQueryTranslators is a list of functions that translate queries run with
+	//the connection pool returned by DB(). This functionality is provided so that
+	//you can write runtime queries once, typically in MariaDB format with ?
+	//placeholders, and run them against any supported database type. Placeholders
+	//are rebound for the database type after these translators are run.
+	//
+	//Translated queries are cached, therefore translators must return the same
+	//output for the same input.
+	RuntimeQueryTranslators []Translator
+
+	//RuntimeQueryCheckedTranslators is a list of translators that can report that a
+	//runtime query cannot be translated, ex.: FunctionTranslator(). These are run
+	//after RuntimeQueryTranslators. If a translator returns an error, the error is
+	//returned and the query is not run.
+	RuntimeQueryCheckedTranslators []CheckedTranslator
+
+	//QueryTemplates enables rendering each DeployQuery and UpdateQuery as a
+	//text/template template, using helper funcs that output SQL for the database
+	//type, before the query is translated. See RenderQueryTemplate().
+	QueryTemplates bool
+
+	//QueryTemplateFuncs are additional funcs available when rendering a query
+	//template. A func with the same name as a predefined helper func replaces it.
+	QueryTemplateFuncs template.FuncMap
+
+	//TranslatorTracer is called with a trace of the changes made by each translator
+	//when DeployQueries and UpdateQueries are translated, by DeploySchema(),
+	//UpdateSchema(), RunDeployQueryTranslators(), and RunUpdateQueryTranslators().
+	//This is used to debug a query that is translated incorrectly, for example by
+	//logging the trace with log.Println(trace). See TraceTranslators().
+	TranslatorTracer func(trace TranslatorTrace)
+
+	//IgnoredErrorReporter is called when an error from running a DeployQuery or
+	//UpdateQuery is ignored by an error handler, with the name of the handler that
+	//ignored the error. This is used to report which handler suppressed what, for
+	//example to show the ignored errors after deploying or updating a schema.
+	IgnoredErrorReporter func(ie IgnoredError)
+
+	//SQLiteMaintenance is a list of maintenance tasks, such as checkpointing the WAL
+	//file, that are run periodically in the background while connected to a SQLite
+	//database. The tasks are started in Connect() and stopped in Close(). This can
+	//only be used with SQLite databases.
+	SQLiteMaintenance *SQLiteMaintenanceOptions
+
+	//RetryPolicy defines how many times, and how long to wait between each attempt,
+	//a transaction is retried by RetryTx() when a transient error occurs. If nil,
+	//the defaults listed in RetryPolicy are used.
+	RetryPolicy *RetryPolicy
+
 	//LoggingLevel enables logging at ERROR, INFO, or DEBUG levels.
 	LoggingLevel logLevel
 
@@ -288,6 +494,32 @@ type Config struct {
 	//connectionString is the connection string used to establish the connection to
 	//the database. This is set upon Connect() being called and is used for debugging.
 	connectionString string
+
+	//sqliteExpandedPath is the SQLitePath after expanding ~ and environment
+	//variables, set in validate() if SQLiteExpandPath is true. SQLitePath is not
+	//modified so that the config still has the path as it was provided. See
+	//sqlitePath().
+	sqliteExpandedPath string
+
+	//sqliteFuncs and sqliteCollations are the custom SQL functions and collations
+	//to register when connecting to a SQLite database. See RegisterSQLiteFunc() and
+	//RegisterSQLiteCollation().
+	sqliteFuncs      []sqliteFunc
+	sqliteCollations []sqliteCollation
+
+	//sqliteKeepAlive is a connection kept open to a shared-cache in-memory SQLite
+	//database so that the database isn't destroyed when the connection pool closes
+	//idle connections. See openSQLiteKeepAlive().
+	sqliteKeepAlive *sqlx.DB
+
+	//runtimeQueries caches queries translated by DB() so that each query is only
+	//translated once. This is reset upon Connect() being called.
+	runtimeQueries *runtimeQueryCache
+
+	//maintenanceStop and maintenanceDone are used to stop the SQLiteMaintenance
+	//tasks running in the background and wait for them to complete.
+	maintenanceStop chan struct{}
+	maintenanceDone chan struct{}
 }
 
 // QueryFunc is a function used to perform a deployment or Update task that is more
@@ -298,6 +530,11 @@ type QueryFunc func(*sqlx.DB) error
 // Supported databases.
 type dbType string
 
+// DBTypeName is the type of the supported database constants (ex.: DBTypeSQLite).
+// Use this when you need to name the type outside of this package, for example for
+// the keys of DialectQuery.Variants.
+type DBTypeName = dbType
+
 const (
 	DBTypeMySQL   = dbType("mysql")
 	DBTypeMariaDB = dbType("mariadb")
@@ -305,6 +542,11 @@ const (
 	DBTypeMSSQL   = dbType("mssql")
 )
 
+// dbTypePostgres is used for the TranslationErrors returned by
+// TryTranslateMariaDBToPostgres. It isn't a supported database since this package
+// doesn't support connecting to PostgreSQL.
+const dbTypePostgres = dbType("postgres")
+
 var validDBTypes = []dbType{
 	DBTypeMySQL,
 	DBTypeMariaDB,
@@ -324,6 +566,10 @@ var (
 	//connected-to database.
 	ErrConnected = errors.New("sqldb: connection already established")
 
+	//ErrNotConnected is returned when trying to use a database before a connection
+	//has been established.
+	ErrNotConnected = errors.New("sqldb: connection not established")
+
 	//ErrSQLitePathNotProvided is returned SQLitePath is empty.
 	ErrSQLitePathNotProvided = errors.New("sqldb: SQLite path not provided")
 
@@ -391,11 +637,6 @@ func (c *Config) Connect() (err error) {
 	connString := c.buildConnectionString(false)
 	c.connectionString = connString
 
-	//Get the correct driver based on the database type.
-	//
-	//If using SQLite, the correct driver is chosen based on build tags.
-	driver := getDriver(c.Type)
-
 	//Connect to the database.
 	//
 	//For SQLite, check if the database file exists. This func will not create the
@@ -403,19 +644,48 @@ func (c *Config) Connect() (err error) {
 	//If the database is in-memory, we can ignore this error though, since, the
 	//database will never exist yet an is in fact created when Open() and Ping() are
 	//called below.
-	if c.IsSQLite() && c.SQLitePath != SQLiteInMemoryFilepathRacy && c.SQLitePath != SQLiteInMemoryFilepathRaceSafe {
-		_, err = os.Stat(c.SQLitePath)
+	inMemory, _ := isSQLiteInMemory(c.SQLitePath)
+	if c.IsSQLite() && !inMemory {
+		_, err = os.Stat(sqliteDiskPath(c.sqlitePath()))
 		if os.IsNotExist(err) {
 			return err
 		}
 	}
 
+	//For shared-cache in-memory SQLite databases, keep a connection open so the
+	//database isn't destroyed when the connection pool closes connections.
+	hadKeepAlive := c.sqliteKeepAlive != nil
+	err = c.openSQLiteKeepAlive(connString)
+	if err != nil {
+		return
+	}
+
+	//Close anything opened below if connecting fails so connections aren't leaked.
+	//A keep alive connection opened before this func was called, such as by
+	//DeploySchema(), is left open since it is keeping the database alive.
+	var conn *sqlx.DB
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if conn != nil {
+			conn.Close()
+		}
+		if !hadKeepAlive {
+			c.closeSQLiteKeepAlive()
+		}
+	}()
+
 	//Connect to the database.
 	//
 	//Note no "defer conn.Close()" since we want to keep the connection alive for
 	//future use in running queries. It is the job of whatever func called Connect()
 	//to call Close().
-	conn, err := sqlx.Open(driver, connString)
+	//
+	//The correct driver is chosen based on the database type, and if using SQLite,
+	//build tags. See open().
+	conn, err = c.open(connString)
 	if err != nil {
 		return
 	}
@@ -431,14 +701,18 @@ func (c *Config) Connect() (err error) {
 	}
 
 	//Save the connection for running future queries.
+	c.runtimeQueries = &runtimeQueryCache{queries: make(map[string]string)}
 	c.connection = conn
 
+	//Start any background maintenance tasks.
+	c.startSQLiteMaintenance()
+
 	//Diagnostic logging, useful for logging out which database you are connected to.
 	switch c.Type {
 	case DBTypeMySQL, DBTypeMariaDB, DBTypeMSSQL:
 		c.infoLn("sqldb.Connect", "Connecting to database "+c.Name+" on "+c.Host+" with user "+c.User+".")
 	case DBTypeSQLite:
-		c.infoLn("sqldb.Connect", "Connecting to database: "+c.SQLitePath+".")
+		c.infoLn("sqldb.Connect", "Connecting to database: "+c.sqlitePath()+".")
 	default:
 		//This can never occur because we called validate() above to verify that a
 		//valid database type was provided.
@@ -482,8 +756,39 @@ func (c *Config) validate() (err error) {
 			return ErrSQLitePathNotProvided
 		}
 
-		//We don't check PRAGMAs since they are just strings. We will return any
-		//errors when the database is connected to via Open().
+		//Expand the path into a separate field so that SQLitePath is left as
+		//provided. The path is expanded each time since SQLitePath may change.
+		c.sqliteExpandedPath = ""
+		if c.SQLiteExpandPath {
+			c.sqliteExpandedPath, err = expandSQLitePath(c.SQLitePath)
+			if err != nil {
+				return
+			}
+		}
+
+		if c.SQLiteLibrary != "" && !isOneOf(c.SQLiteLibrary, SQLiteLibraries()) {
+			return fmt.Errorf("sqldb: SQLite library not built into binary, should be one of '%s', got '%s'", SQLiteLibraries(), c.SQLiteLibrary)
+		}
+
+		//We don't check SQLitePragmas since they are just strings. We will return
+		//any errors when the database is connected to via Open(). The typed PRAGMAs
+		//can be checked though.
+		err = c.SQLitePragmaConfig.validate()
+		if err != nil {
+			return
+		}
+
+		if c.SQLiteMaintenance != nil {
+			err = c.SQLiteMaintenance.validate()
+			if err != nil {
+				return
+			}
+		}
+
+		err = validateSQLiteAttachments(c.SQLiteAttachments)
+		if err != nil {
+			return
+		}
 
 	case DBTypeMySQL, DBTypeMariaDB, DBTypeMSSQL:
 		if c.Host == "" {
@@ -501,6 +806,12 @@ func (c *Config) validate() (err error) {
 		if c.Password == "" {
 			return ErrPasswordNotProvided
 		}
+		if c.SQLiteMaintenance != nil {
+			return fmt.Errorf("sqldb: SQLiteMaintenance provided, %w", ErrNotSQLite)
+		}
+		if len(c.SQLiteAttachments) > 0 {
+			return fmt.Errorf("sqldb: SQLiteAttachments provided, %w", ErrNotSQLite)
+		}
 
 	default:
 		return fmt.Errorf("sqldb: invalid database type, should be one of '%s', got '%s'", validDBTypes, c.Type)
@@ -548,38 +859,41 @@ func (c *Config) buildConnectionString(deployingDB bool) (connString string) {
 		connString = dbConnectionConfig.FormatDSN()
 
 	case DBTypeSQLite:
-		connString = c.SQLitePath
+		connString = sqliteInMemoryConnString(c.sqlitePath(), c.GetSQLiteLibrary())
 
 		//For SQLite, the connection string is simply a path to a file. However, we
 		//may need to append PRAGMAs as needed. PRAGMAs are appended to end of
 		//filepath as query parameters.
-		if len(c.SQLitePragmas) != 0 {
-			//An error here should rarely occur, and if it does, the path to the
-			//database file was not provided correctly so the only thing we can do
-			//is error out.
-			u, err := url.Parse(c.SQLitePath)
-			if err != nil {
-				log.Fatalln("Could not parse SQLite path.", c.SQLitePath, err)
-				os.Exit(1)
-				return
+		pragmas := c.sqlitePragmas()
+		if len(pragmas) != 0 {
+			lib := c.GetSQLiteLibrary()
+
+			//The ncruces library only reads query parameters from the filepath
+			//when the filepath is a URI filename. The path to a file is encoded
+			//since a ?, #, or % in a URI isn't part of the path.
+			if lib == SQLiteLibraryNcruces && !strings.HasPrefix(connString, "file:") {
+				if inMemory, _ := isSQLiteInMemory(connString); inMemory {
+					connString = "file:" + connString
+				} else {
+					connString = sqliteFileURI(connString)
+				}
 			}
 
-			lib := GetSQLiteLibrary()
-			pragmasToAdd := pragmasToURLValues(c.SQLitePragmas, lib)
+			//The path is not parsed as a URL since a path to a file, or ":memory:",
+			//is not a valid URL. Any existing query parameters are kept.
+			pragmasToAdd := pragmasToURLValues(pragmas, lib)
 
-			if len(u.Query()) > 0 {
-				u.RawQuery = u.RawQuery + "&" + pragmasToAdd.Encode()
+			if strings.Contains(connString, "?") {
+				connString = connString + "&" + pragmasToAdd.Encode()
 			} else {
-				u.RawQuery = pragmasToAdd.Encode()
+				connString = connString + "?" + pragmasToAdd.Encode()
 			}
 
-			connString = u.String()
-
 			//Sort the PRAGMAs since Encode() does this and this makes looking at the
 			//two logging lines easier since the order matches.
-			sort.Strings(c.SQLitePragmas)
+			sort.Strings(pragmas)
 
-			c.debugLn("sqldb.buildConnectionString", "PRAGMAs provided: ", strings.Join(c.SQLitePragmas, "; "))
+			c.debugLn("sqldb.buildConnectionString", "PRAGMAs provided: ", strings.Join(pragmas, "; "))
 			c.debugLn("sqldb.buildConnectionString", "PRAGMA String:    ", pragmasToAdd.Encode())
 			c.debugLn("sqldb.buildConnectionString", "Path With PRAGMAs:", connString)
 			c.debugLn("sqldb.buildConnectionString", "SQLite Library:   ", lib)
@@ -618,12 +932,14 @@ func (c *Config) buildConnectionString(deployingDB bool) (connString string) {
 
 // getDriver returns the Go sql driver used for the chosen database type. This is
 // used in Connect() to get the name of the driver as needed by [database/sql.Open].
-func getDriver(t dbType) (driver string) {
+//
+// The SQLite library is only used when the database type is SQLite.
+func getDriver(t dbType, lib library) (driver string) {
 	switch t {
 	case DBTypeSQLite:
-		//See sqlite- subfiles based on library used. Correct driver is chosen based
+		//See sqlite- subfiles based on library used. Libraries are built in based
 		//on build tags.
-		driver = sqliteDriverName
+		driver = sqliteDrivers[lib].driverName
 
 	case DBTypeMySQL, DBTypeMariaDB:
 		driver = "mysql"
@@ -641,7 +957,20 @@ func getDriver(t dbType) (driver string) {
 }
 
 // Close handles closing the underlying database connection stored in the config.
+// Any SQLiteMaintenance tasks are stopped first.
 func (c *Config) Close() (err error) {
+	err = c.close()
+	c.closeSQLiteKeepAlive()
+	return
+}
+
+// close closes the connection pool but, unlike Close(), leaves a shared-cache
+// in-memory SQLite database alive. This is used after successfully running
+// DeploySchema() or UpdateSchema() so that the deployed database can be connected to
+// again with Connect().
+func (c *Config) close() (err error) {
+	c.stopSQLiteMaintenance()
+
 	if c.Connected() {
 		return c.connection.Close()
 	}
@@ -277,6 +277,9 @@ func TestBuildConnectionString(t *testing.T) {
 		c := NewSQLite("/path/to/sqlite.db")
 		got := c.buildConnectionString(true)
 		expected := c.SQLitePath //plus some pragmas appended to path.
+		if c.GetSQLiteLibrary() == SQLiteLibraryNcruces {
+			expected = "file:" + expected
+		}
 
 		if !strings.HasPrefix(got, expected) {
 			t.Log("Got:", got)
@@ -291,6 +294,9 @@ func TestBuildConnectionString(t *testing.T) {
 		c := NewSQLite("/path/to/sqlite.db")
 		got := c.buildConnectionString(false)
 		expected := c.SQLitePath //plus some pragmas appended to path.
+		if c.GetSQLiteLibrary() == SQLiteLibraryNcruces {
+			expected = "file:" + expected
+		}
 
 		if !strings.HasPrefix(got, expected) {
 			t.Log("Got:", got)
@@ -310,12 +316,18 @@ func TestBuildConnectionString(t *testing.T) {
 		got := c.buildConnectionString(false)
 
 		expected := ""
-		switch GetSQLiteLibrary() {
-		case sqliteLibraryMattn:
+		switch c.GetSQLiteLibrary() {
+		case SQLiteLibraryMattn:
 			expected = c.SQLitePath + "?_busy_timeout=5000"
-		case sqliteLibraryModernc:
+		case SQLiteLibraryModernc:
 			expected = c.SQLitePath + "?_pragma=busy_timeout=5000"
 
+			//have to handle second equals sign being replaced by %3D.
+			got, _ = url.QueryUnescape(got)
+			expected, _ = url.QueryUnescape(expected)
+		case SQLiteLibraryNcruces:
+			expected = "file:" + c.SQLitePath + "?_pragma=busy_timeout=5000"
+
 			//have to handle second equals sign being replaced by %3D.
 			got, _ = url.QueryUnescape(got)
 			expected, _ = url.QueryUnescape(expected)
@@ -339,11 +351,11 @@ func TestBuildConnectionString(t *testing.T) {
 		got := c.buildConnectionString(false)
 
 		expected := ""
-		switch GetSQLiteLibrary() {
-		case sqliteLibraryMattn:
+		switch c.GetSQLiteLibrary() {
+		case SQLiteLibraryMattn:
 			expected = c.SQLitePath + "&_busy_timeout=5000"
-		case sqliteLibraryModernc:
-			expected = c.SQLitePath + "&_pragma=busy_timeout=5000"
+		case SQLiteLibraryModernc, SQLiteLibraryNcruces:
+			expected = sqliteInMemoryConnString(c.SQLitePath, c.GetSQLiteLibrary()) + "&_pragma=busy_timeout=5000"
 
 			//have to handle second equals sign being replaced by %3D.
 			got, _ = url.QueryUnescape(got)
@@ -400,19 +412,21 @@ func TestBuildConnectionString(t *testing.T) {
 }
 
 func TestGetDriver(t *testing.T) {
-	d := getDriver(DBTypeMariaDB)
+	d := getDriver(DBTypeMariaDB, "")
 	if d != "mysql" {
 		t.FailNow()
 		return
 	}
 
-	d = getDriver(DBTypeSQLite)
-	if d != sqliteDriverName {
-		t.FailNow()
-		return
+	for _, lib := range SQLiteLibraries() {
+		d = getDriver(DBTypeSQLite, lib)
+		if d != sqliteDrivers[lib].driverName {
+			t.FailNow()
+			return
+		}
 	}
 
-	d = getDriver(DBTypeMSSQL)
+	d = getDriver(DBTypeMSSQL, "")
 	if d != "mssql" {
 		t.FailNow()
 		return
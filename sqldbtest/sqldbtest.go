@@ -0,0 +1,99 @@
+/*
+Package sqldbtest provides helpers for testing code that uses the sqldb package.
+
+Golden() runs a directory of .sql files through a list of translators and compares
+each translated query to the expected output saved in a matching .golden file. Run
+tests with the -sqldbtest.update flag to write the .golden files from the current
+output:
+
+	go test ./... -sqldbtest.update
+
+The flag is namespaced, like the testing package's -test.* flags, so that it
+doesn't conflict with an -update flag defined by your own tests.
+
+Review the changes to the .golden files before committing them.
+*/
+package sqldbtest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/c9845/sqldb/v3"
+)
+
+// update is set when .golden files should be written instead of compared against.
+var update = flag.Bool("sqldbtest.update", false, "update sqldbtest .golden files with the current output")
+
+// goldenExt is the extension of files storing the expected output for a .sql file.
+const goldenExt = ".golden"
+
+// Golden runs each .sql file in dir through the translators, in order, and compares
+// the translated query to the file with the same name and a .golden extension (ex.:
+// users.sql and users.golden). Each file is run as a subtest.
+//
+// If a translator reports that a query cannot be translated, the error message,
+// prefixed with "error: ", is compared instead. This allows testing that a query
+// fails to translate.
+//
+// When the -sqldbtest.update flag is provided, the .golden files are written instead of
+// compared.
+//
+// When a query does not match, the trace of each translator's changes is logged to
+// help find which translator is at fault. See sqldb.TraceTranslators().
+func Golden(t *testing.T, dir string, translators ...sqldb.QueryTranslator) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if len(files) == 0 {
+		t.Fatal("No .sql files found in", dir)
+		return
+	}
+
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".sql")
+		t.Run(name, func(t *testing.T) {
+			in, err := os.ReadFile(f)
+			if err != nil {
+				t.Fatal(err)
+				return
+			}
+
+			trace, err := sqldb.TraceTranslators(string(in), translators)
+			got := trace.Translated
+			if err != nil {
+				got = "error: " + err.Error() + "\n"
+			}
+
+			goldenPath := strings.TrimSuffix(f, ".sql") + goldenExt
+			if *update {
+				err := os.WriteFile(goldenPath, []byte(got), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			expected, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatal("Could not read golden file, run with -sqldbtest.update to create it.", err)
+				return
+			}
+
+			if got != string(expected) {
+				t.Log("Got:", got)
+				t.Log("Exp:", string(expected))
+				t.Log(trace)
+				t.Fatal("Translated query does not match", goldenPath)
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package sqldbtest
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/c9845/sqldb/v3"
+)
+
+func TestGolden(t *testing.T) {
+	functions, err := sqldb.FunctionTranslator(sqldb.DBTypeMariaDB, sqldb.DBTypeSQLite)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	Golden(t, "testdata/mariadb-to-sqlite", sqldb.Translator(sqldb.TranslateMariaDBToSQLite), functions)
+}
+
+func TestUpdateFlag(t *testing.T) {
+	//The flag must be namespaced so that tests importing this package can define
+	//their own -update flag without a "flag redefined" panic.
+	if flag.Lookup("update") != nil {
+		t.Fatal("-update flag should not be defined.")
+		return
+	}
+	if flag.Lookup("sqldbtest.update") == nil {
+		t.Fatal("-sqldbtest.update flag should be defined.")
+		return
+	}
+}
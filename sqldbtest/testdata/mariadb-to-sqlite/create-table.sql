@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS users (
+	ID INT UNSIGNED NOT NULL AUTO_INCREMENT,
+	Email VARCHAR(255) NOT NULL,
+	Status ENUM('active','disabled') NOT NULL DEFAULT 'active',
+	DatetimeCreated DATETIME NOT NULL DEFAULT UTC_TIMESTAMP,
+	DatetimeModified DATETIME NOT NULL DEFAULT UTC_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (ID),
+	UNIQUE KEY Email_idx (Email)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
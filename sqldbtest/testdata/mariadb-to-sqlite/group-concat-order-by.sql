@@ -0,0 +1 @@
+SELECT GROUP_CONCAT(Email ORDER BY Email SEPARATOR ', ') FROM users;
@@ -0,0 +1,2 @@
+INSERT INTO users (Email, Status) VALUES (?, ?)
+ON DUPLICATE KEY UPDATE Status = VALUES(Status), DatetimeModified = NOW();
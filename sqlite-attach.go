@@ -0,0 +1,65 @@
+package sqldb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/*
+This file handles attaching additional SQLite database files to each connection in
+the connection pool. See the config SQLiteAttachments field.
+
+ATTACH DATABASE only applies to the connection it is run on. Since connections are
+pooled, running ATTACH via Connection() would only attach the database to one
+connection. Instead, attachments are run on each new connection when the connection
+is opened.
+*/
+
+// sqliteAliasRegex is used to validate the alias, schema name, an attached database
+// is referenced by. The alias cannot be provided as a query parameter so it must be
+// validated to prevent SQL injection.
+var sqliteAliasRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateSQLiteAttachments checks that each attached database has a valid alias and
+// path. This is called in Config.validate().
+func validateSQLiteAttachments(attachments map[string]string) (err error) {
+	for alias, path := range attachments {
+		if !sqliteAliasRegex.MatchString(alias) {
+			return fmt.Errorf("sqldb: invalid SQLite attachment alias, must be letters, numbers, and underscores, got '%s'", alias)
+		}
+
+		//main and temp are the names SQLite uses for the main and temporary
+		//databases on every connection.
+		if l := strings.ToLower(alias); l == "main" || l == "temp" {
+			return fmt.Errorf("sqldb: invalid SQLite attachment alias, '%s' is reserved", alias)
+		}
+
+		if strings.TrimSpace(path) == "" {
+			return fmt.Errorf("sqldb: SQLite attachment path not provided for alias '%s'", alias)
+		}
+	}
+
+	return
+}
+
+// sqliteAttachHooks returns a connectHook for each database to attach. Hooks are
+// sorted by alias so that databases are attached in the same order on every
+// connection.
+func (c *Config) sqliteAttachHooks() (hooks []connectHook) {
+	aliases := make([]string, 0, len(c.SQLiteAttachments))
+	for alias := range c.SQLiteAttachments {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		path := strings.TrimSpace(c.SQLiteAttachments[alias])
+		q := "ATTACH DATABASE ? AS " + alias
+		hooks = append(hooks, execHook(q, driver.NamedValue{Ordinal: 1, Value: path}))
+	}
+
+	return
+}
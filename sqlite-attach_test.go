@@ -0,0 +1,136 @@
+package sqldb
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteAttachments(t *testing.T) {
+	dir := t.TempDir()
+
+	c := NewSQLite(filepath.Join(dir, "main.db"))
+	c.SQLiteAttachments = map[string]string{
+		"archive": filepath.Join(dir, "archive.db"),
+	}
+	c.DeployQueries = []string{
+		`CREATE TABLE IF NOT EXISTS users (ID INTEGER PRIMARY KEY NOT NULL, Name TEXT NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS archive.logins (ID INTEGER PRIMARY KEY NOT NULL, UserID INTEGER NOT NULL)`,
+		`INSERT OR IGNORE INTO users (ID, Name) VALUES (1, 'a')`,
+		`INSERT OR IGNORE INTO archive.logins (ID, UserID) VALUES (1, 1)`,
+	}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	//Check on more than one connection in the pool since databases are attached
+	//per-connection.
+	c.Connection().SetMaxIdleConns(0)
+	for i := 0; i < 2; i++ {
+		var name string
+		q := `SELECT users.Name FROM archive.logins JOIN users ON users.ID = logins.UserID`
+		err = c.Connection().Get(&name, q)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if name != "a" {
+			t.Fatal("Join across attached database returned wrong value.", name)
+			return
+		}
+	}
+}
+
+func TestSQLiteAttachmentsTranslated(t *testing.T) {
+	dir := t.TempDir()
+
+	//Inline indexes and ON UPDATE triggers must be created in the attached
+	//database, not in main.
+	c := NewSQLite(filepath.Join(dir, "main.db"))
+	c.SQLiteAttachments = map[string]string{
+		"archive": filepath.Join(dir, "archive.db"),
+	}
+	c.DeployQueryTranslators = []Translator{TranslateMariaDBToSQLite}
+	c.DeployQueries = []string{
+		`CREATE TABLE IF NOT EXISTS archive.users (
+			ID INT NOT NULL AUTO_INCREMENT,
+			Name VARCHAR(255) NOT NULL,
+			Updated DATETIME DEFAULT UTC_TIMESTAMP ON UPDATE UTC_TIMESTAMP,
+			PRIMARY KEY (ID),
+			INDEX idx_name (Name)
+		)`,
+		`INSERT INTO archive.users (Name, Updated) VALUES ('a', '2000-01-01 00:00:00')`,
+	}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	var n int
+	err = c.Connection().Get(&n, `SELECT COUNT(*) FROM archive.sqlite_master WHERE name IN ('users_idx_name', 'users_Updated_on_update')`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if n != 2 {
+		t.Fatal("Index and trigger not created in attached database.", n)
+		return
+	}
+
+	_, err = c.Connection().Exec(`UPDATE archive.users SET Name = 'b'`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	var updated string
+	err = c.Connection().Get(&updated, `SELECT Updated FROM archive.users`)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if updated == "2000-01-01 00:00:00" {
+		t.Fatal("Trigger did not update column.")
+		return
+	}
+}
+
+func TestValidateSQLiteAttachments(t *testing.T) {
+	good := map[string]string{"archive_2": "/path/to/archive.db"}
+	err := validateSQLiteAttachments(good)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	bad := []map[string]string{
+		{"archive; DROP TABLE users": "/path/to/archive.db"},
+		{"2archive": "/path/to/archive.db"},
+		{"main": "/path/to/archive.db"},
+		{"TEMP": "/path/to/archive.db"},
+		{"archive": " "},
+	}
+	for _, b := range bad {
+		err = validateSQLiteAttachments(b)
+		if err == nil {
+			t.Fatal("Error about invalid attachment should have occured.", b)
+			return
+		}
+	}
+
+	//Attachments are only for SQLite.
+	c := NewMariaDB("10.0.0.1", "db_name", "user", "password")
+	c.SQLiteAttachments = good
+	err = c.validate()
+	if !errors.Is(err, ErrNotSQLite) {
+		t.Fatal("ErrNotSQLite should have occured.", err)
+		return
+	}
+}
@@ -0,0 +1,11 @@
+//go:build mattn && ncruces
+
+/*
+This file prevents building with both the mattn and ncruces SQLite libraries. Both
+libraries register a [database/sql] driver named "sqlite3" which would cause a panic
+when the binary starts. Failing at build time is much clearer.
+*/
+
+package sqldb
+
+var _ = cannot_build_with_both_mattn_and_ncruces_sqlite_libraries
@@ -0,0 +1,182 @@
+package sqldb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+This file handles preparing the file a SQLite database is stored in before the
+database is deployed. See the config SQLiteCreateDirs, SQLiteFileMode,
+SQLiteExpandPath, and SQLiteCheckHeader fields.
+*/
+
+// sqliteHeader is the first 16 bytes of every SQLite database file.
+//
+// Reference: https://www.sqlite.org/fileformat.html#the_database_header
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+var (
+	//ErrNotSQLiteFile is returned when deploying a SQLite database to a path where
+	//a file that isn't a SQLite database already exists. This is only checked if
+	//SQLiteCheckHeader is true.
+	ErrNotSQLiteFile = errors.New("sqldb: file exists but is not a SQLite database")
+)
+
+// expandSQLitePath expands a leading ~ to the user's home directory and any
+// environment variables in a SQLite path. For a file: URI, the path is percent-decoded
+// before being expanded and encoded again after, query parameters are not modified.
+// In-memory database paths are not modified.
+func expandSQLitePath(path string) (expanded string, err error) {
+	if inMemory, _ := isSQLiteInMemory(path); inMemory {
+		return path, nil
+	}
+
+	p, rawQuery, hasQuery := strings.Cut(path, "?")
+	p, isURI := strings.CutPrefix(p, "file:")
+	if !isURI {
+		p, rawQuery, hasQuery = path, "", false
+	} else {
+		p, err = url.PathUnescape(p)
+		if err != nil {
+			return "", fmt.Errorf("sqldb: could not decode SQLite URI path, %w", err)
+		}
+	}
+
+	p = os.ExpandEnv(p)
+
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`) {
+		home, innerErr := os.UserHomeDir()
+		if innerErr != nil {
+			return "", fmt.Errorf("sqldb: could not expand ~ in SQLite path, %w", innerErr)
+		}
+
+		p = filepath.Join(home, p[1:])
+	}
+
+	if !isURI {
+		return p, nil
+	}
+
+	expanded = sqliteFileURI(p)
+	if hasQuery {
+		expanded += "?" + rawQuery
+	}
+
+	return
+}
+
+// sqliteFileURI returns a path to a SQLite database file as a file: URI. The path is
+// percent-encoded so that characters such as ?, #, and % are part of the path instead
+// of starting the URI's query or fragment.
+func sqliteFileURI(p string) string {
+	return "file:" + (&url.URL{Path: filepath.ToSlash(p)}).EscapedPath()
+}
+
+// sqlitePath returns the path to the SQLite database to connect to. This is SQLitePath
+// after being expanded, if SQLiteExpandPath is true (see validate()).
+func (c *Config) sqlitePath() string {
+	if c.sqliteExpandedPath != "" {
+		return c.sqliteExpandedPath
+	}
+
+	return c.SQLitePath
+}
+
+// sqliteDiskPath returns the path to the file on disk for a SQLite path. Paths can be
+// URIs (ex.: file:/path/to/sqlite.db?mode=rwc) which are percent-decoded.
+func sqliteDiskPath(path string) string {
+	p, isURI := strings.CutPrefix(path, "file:")
+	if !isURI {
+		return path
+	}
+
+	p, _, _ = strings.Cut(p, "?")
+	if decoded, err := url.PathUnescape(p); err == nil {
+		p = decoded
+	}
+
+	return p
+}
+
+// prepareSQLiteFile creates the directories for, creates, checks, and sets the
+// permissions of the SQLite database file based on the config's options. This is
+// called in DeploySchema() before connecting to the database, and therefore before
+// the SQLite library creates the database file. In-memory databases are skipped.
+func (c *Config) prepareSQLiteFile() (err error) {
+	if !c.IsSQLite() {
+		return
+	}
+	if inMemory, _ := isSQLiteInMemory(c.SQLitePath); inMemory {
+		return
+	}
+
+	path := sqliteDiskPath(c.sqlitePath())
+
+	if c.SQLiteCreateDirs {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return
+		}
+	}
+
+	if c.SQLiteCheckHeader {
+		err = checkSQLiteHeader(path)
+		if err != nil {
+			return
+		}
+	}
+
+	if c.SQLiteFileMode != 0 {
+		//Create the file, if needed, so that the database file is never readable
+		//with more permissive permissions. An empty file is a valid, empty, SQLite
+		//database. Chmod is always called since the umask applies when creating a
+		//file and the file may already exist with other permissions.
+		f, innerErr := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, c.SQLiteFileMode)
+		if innerErr != nil {
+			return innerErr
+		}
+		f.Close()
+
+		err = os.Chmod(path, c.SQLiteFileMode)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// checkSQLiteHeader returns ErrNotSQLiteFile if a file exists at the path and the
+// file is not a SQLite database. A missing or empty file is not an error since
+// SQLite will initialize the file as a new database.
+func checkSQLiteHeader(path string) (err error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return
+	}
+	defer f.Close()
+
+	b := make([]byte, len(sqliteHeader))
+	n, err := io.ReadFull(f, b)
+	if n == 0 && err == io.EOF {
+		return nil
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return
+	}
+
+	if !bytes.Equal(b[:n], sqliteHeader) {
+		return fmt.Errorf("%w: %s", ErrNotSQLiteFile, path)
+	}
+
+	return nil
+}
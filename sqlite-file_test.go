@@ -0,0 +1,180 @@
+package sqldb
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPrepareSQLiteFile(t *testing.T) {
+	dir := t.TempDir()
+
+	c := NewSQLite(filepath.Join(dir, "a", "b", "sqlite.db"))
+	c.SQLiteCreateDirs = true
+	c.SQLiteFileMode = 0600
+	c.SQLiteCheckHeader = true
+	c.DeployQueries = []string{
+		`CREATE TABLE IF NOT EXISTS users (ID INTEGER PRIMARY KEY NOT NULL)`,
+	}
+
+	err := c.DeploySchema(nil)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	fi, err := os.Stat(c.SQLitePath)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm() != 0600 {
+		t.Fatal("File mode not set.", fi.Mode().Perm())
+		return
+	}
+
+	//Redeploying over an existing SQLite database is fine.
+	err = c.DeploySchema(nil)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//Deploying over a file that isn't a SQLite database must fail.
+	notSQLite := filepath.Join(dir, "notes.txt")
+	err = os.WriteFile(notSQLite, []byte("some important notes"), 0644)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	c.SQLitePath = notSQLite
+	err = c.DeploySchema(nil)
+	if !errors.Is(err, ErrNotSQLiteFile) {
+		t.Fatal("ErrNotSQLiteFile should have occured.", err)
+		return
+	}
+}
+
+func TestCheckSQLiteHeader(t *testing.T) {
+	dir := t.TempDir()
+
+	//Missing file.
+	err := checkSQLiteHeader(filepath.Join(dir, "missing.db"))
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//Empty file.
+	empty := filepath.Join(dir, "empty.db")
+	err = os.WriteFile(empty, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = checkSQLiteHeader(empty)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//File shorter than the header.
+	short := filepath.Join(dir, "short.db")
+	err = os.WriteFile(short, []byte("SQL"), 0644)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = checkSQLiteHeader(short)
+	if !errors.Is(err, ErrNotSQLiteFile) {
+		t.Fatal("ErrNotSQLiteFile should have occured.", err)
+		return
+	}
+}
+
+func TestExpandSQLitePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("No home directory.", err)
+		return
+	}
+	t.Setenv("SQLDB_TEST_DIR", "data")
+
+	c := NewSQLite("~/$SQLDB_TEST_DIR/sqlite.db")
+	c.SQLiteExpandPath = true
+	err = c.validate()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	expected := filepath.Join(home, "data", "sqlite.db")
+	if c.sqlitePath() != expected {
+		t.Fatal("Path not expanded.", c.sqlitePath(), expected)
+		return
+	}
+	if c.SQLitePath != "~/$SQLDB_TEST_DIR/sqlite.db" {
+		t.Fatal("SQLitePath should not be modified.", c.SQLitePath)
+		return
+	}
+
+	//URI paths are percent-decoded before being expanded, query parameters are kept.
+	c = NewSQLite("file:%7E/%24SQLDB_TEST_DIR/my%20db.sqlite?mode=rwc&_pragma=foreign_keys(1)")
+	c.SQLiteExpandPath = true
+	err = c.validate()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	expected = "file:" + (&url.URL{Path: filepath.ToSlash(filepath.Join(home, "data", "my db.sqlite"))}).EscapedPath() + "?mode=rwc&_pragma=foreign_keys(1)"
+	if c.sqlitePath() != expected {
+		t.Log("Got:", c.sqlitePath())
+		t.Log("Exp:", expected)
+		t.Fatal("URI path not expanded.")
+		return
+	}
+	if got := sqliteDiskPath(c.sqlitePath()); got != filepath.ToSlash(filepath.Join(home, "data", "my db.sqlite")) {
+		t.Fatal("Bad path on disk.", got)
+		return
+	}
+
+	//In-memory paths are not modified.
+	c = NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.SQLiteExpandPath = true
+	err = c.validate()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if c.SQLitePath != SQLiteInMemoryFilepathRaceSafe {
+		t.Fatal("In-memory path should not be modified.", c.SQLitePath)
+		return
+	}
+}
+
+func TestSQLiteFileURI(t *testing.T) {
+	got := sqliteFileURI("/path/to/my?db#1%.sqlite")
+	expected := "file:/path/to/my%3Fdb%231%25.sqlite"
+	if got != expected {
+		t.Log("Got:", got)
+		t.Log("Exp:", expected)
+		t.Fatal("Path not encoded.")
+		return
+	}
+
+	//The encoded path decodes back to the original path.
+	u, err := url.Parse(got)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if u.Path != "/path/to/my?db#1%.sqlite" || u.RawQuery != "" || u.Fragment != "" {
+		t.Fatal("Path not decoded as expected.", u.Path, u.RawQuery, u.Fragment)
+		return
+	}
+}
@@ -0,0 +1,185 @@
+package sqldb
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+/*
+This file handles registering custom SQL functions and collations with SQLite. Each
+SQLite library uses a different API for this, so a driver-neutral API is provided
+here and the functions are registered using the library in use when Connect() is
+called. See the registerFuncs field in each sqlite-*.go file.
+*/
+
+// SQLiteFunc is a custom SQL function implemented in Go.
+//
+// Arguments will be one of int64, float64, string, []byte, or nil (for NULL) based
+// on the SQLite type of each value. The returned value should be one of nil, bool,
+// any integer or float type, string, []byte, or time.Time. Any other type results in
+// an error from the query calling the function.
+type SQLiteFunc func(args ...any) (any, error)
+
+// sqliteFunc is a registered SQLiteFunc.
+type sqliteFunc struct {
+	name          string
+	fn            SQLiteFunc
+	deterministic bool
+}
+
+// sqliteCollation is a registered collation.
+type sqliteCollation struct {
+	name string
+	cmp  func(a, b string) int
+}
+
+var (
+	//ErrInvalidSQLiteFunc is returned when registering a custom SQL function or
+	//collation with a missing name or implementation.
+	ErrInvalidSQLiteFunc = errors.New("sqldb: SQLite function or collation name or implementation not provided")
+)
+
+// RegisterSQLiteFunc registers a Go function as a SQL function that can be called in
+// queries. The function is registered on each connection in the connection pool when
+// Connect() is called, therefore this must be called before Connect(). The function
+// can be called with any number of arguments, the function should validate the
+// arguments it receives.
+//
+// Set deterministic to true if the function always returns the same result for the
+// same arguments. This allows SQLite to use the function in indexes and to perform
+// additional optimizations.
+//
+// With the [modernc.org/sqlite] library, functions are registered for the entire
+// process, not per config. The most recently connected config's function is used for
+// a given name.
+//
+// Ex.:
+//
+//	c.RegisterSQLiteFunc("REGEXP", func(args ...any) (any, error) {
+//	  pattern, _ := args[0].(string)
+//	  value, _ := args[1].(string)
+//	  return regexp.MatchString(pattern, value)
+//	}, true)
+func (c *Config) RegisterSQLiteFunc(name string, fn SQLiteFunc, deterministic bool) (err error) {
+	name = strings.TrimSpace(name)
+	if name == "" || fn == nil {
+		return ErrInvalidSQLiteFunc
+	}
+	if c.connection != nil && c.Connected() {
+		return ErrConnected
+	}
+
+	c.sqliteFuncs = append(c.sqliteFuncs, sqliteFunc{
+		name:          name,
+		fn:            fn,
+		deterministic: deterministic,
+	})
+	return
+}
+
+// RegisterSQLiteFunc registers a Go function as a SQL function with the package
+// level config.
+func RegisterSQLiteFunc(name string, fn SQLiteFunc, deterministic bool) (err error) {
+	return cfg.RegisterSQLiteFunc(name, fn, deterministic)
+}
+
+// RegisterSQLiteCollation registers a Go function as a collation that can be used in
+// queries and column definitions (ex.: ORDER BY Name COLLATE my_collation). The
+// function should return a negative number, zero, or a positive number if a is less
+// than, equal to, or greater than b. The collation is registered on each connection
+// in the connection pool when Connect() is called, therefore this must be called
+// before Connect().
+//
+// With the [modernc.org/sqlite] library, collations are registered for the entire
+// process, not per config. The most recently connected config's collation is used
+// for a given name.
+func (c *Config) RegisterSQLiteCollation(name string, cmp func(a, b string) int) (err error) {
+	name = strings.TrimSpace(name)
+	if name == "" || cmp == nil {
+		return ErrInvalidSQLiteFunc
+	}
+	if c.connection != nil && c.Connected() {
+		return ErrConnected
+	}
+
+	c.sqliteCollations = append(c.sqliteCollations, sqliteCollation{
+		name: name,
+		cmp:  cmp,
+	})
+	return
+}
+
+// RegisterSQLiteCollation registers a Go function as a collation with the package
+// level config.
+func RegisterSQLiteCollation(name string, cmp func(a, b string) int) (err error) {
+	return cfg.RegisterSQLiteCollation(name, cmp)
+}
+
+// call runs the function and converts the returned value to a type all of the
+// SQLite libraries can handle.
+func (f sqliteFunc) call(args ...any) (driver.Value, error) {
+	v, err := f.fn(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqliteFuncResult(v)
+}
+
+// sqliteFuncResult converts a value returned from a SQLiteFunc to one of nil, int64,
+// float64, string, or []byte. A time.Time is converted to a UTC datetime string in
+// the format SQLite's date and time functions use.
+func sqliteFuncResult(v any) (driver.Value, error) {
+	switch t := v.(type) {
+	case nil, int64, float64, string, []byte:
+		return t, nil
+	case time.Time:
+		return t.UTC().Format(sqliteDatetimeFormat), nil
+	case bool:
+		if t {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case int:
+		return int64(t), nil
+	case int8:
+		return int64(t), nil
+	case int16:
+		return int64(t), nil
+	case int32:
+		return int64(t), nil
+	case uint:
+		return uintResult(uint64(t))
+	case uint8:
+		return int64(t), nil
+	case uint16:
+		return int64(t), nil
+	case uint32:
+		return int64(t), nil
+	case uint64:
+		return uintResult(t)
+	case float32:
+		return float64(t), nil
+	default:
+		return nil, fmt.Errorf("sqldb: unsupported SQLite function result type %T", v)
+	}
+}
+
+// sqliteDatetimeFormat is the format a time.Time returned from a SQLiteFunc is
+// converted to. This is the format returned by SQLite's datetime() function, with
+// fractional seconds if needed.
+const sqliteDatetimeFormat = "2006-01-02 15:04:05.999999999"
+
+// uintResult converts an unsigned integer to an int64, erroring if the value would
+// overflow since SQLite integers are signed.
+func uintResult(u uint64) (driver.Value, error) {
+	if u > math.MaxInt64 {
+		return nil, fmt.Errorf("sqldb: SQLite function result %d overflows int64", u)
+	}
+
+	return int64(u), nil
+}
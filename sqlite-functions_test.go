@@ -0,0 +1,138 @@
+package sqldb
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterSQLiteFunc(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+
+	err := c.RegisterSQLiteFunc("REGEXP", func(args ...any) (any, error) {
+		if len(args) != 2 {
+			return nil, errors.New("REGEXP requires 2 arguments")
+		}
+		pattern, _ := args[0].(string)
+		value, _ := args[1].(string)
+		return regexp.MatchString(pattern, value)
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	err = c.RegisterSQLiteCollation("nocase_reverse", func(a, b string) int {
+		return strings.Compare(strings.ToLower(b), strings.ToLower(a))
+	})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	err = c.Connect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	//Registering after connecting isn't allowed.
+	err = c.RegisterSQLiteFunc("too_late", func(args ...any) (any, error) { return nil, nil }, false)
+	if err != ErrConnected {
+		t.Fatal("ErrConnected should have occured.", err)
+		return
+	}
+
+	//Check on more than one connection in the pool since functions are registered
+	//per-connection with some libraries.
+	c.Connection().SetMaxIdleConns(0)
+	for i := 0; i < 2; i++ {
+		var match bool
+		err = c.Connection().Get(&match, "SELECT 'sqldb' REGEXP '^sql'")
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if !match {
+			t.Fatal("REGEXP should have matched.")
+			return
+		}
+
+		var names []string
+		err = c.Connection().Select(&names, "SELECT column1 FROM (VALUES ('a'), ('C'), ('b')) ORDER BY column1 COLLATE nocase_reverse")
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if strings.Join(names, "") != "Cba" {
+			t.Fatal("Collation not applied.", names)
+			return
+		}
+	}
+
+	//Errors from the function are returned from the query.
+	var match bool
+	err = c.Connection().Get(&match, "SELECT REGEXP('a')")
+	if err == nil {
+		t.Fatal("Error from function should have been returned.")
+		return
+	}
+}
+
+func TestRegisterSQLiteFuncInvalid(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+
+	err := c.RegisterSQLiteFunc(" ", func(args ...any) (any, error) { return nil, nil }, false)
+	if err != ErrInvalidSQLiteFunc {
+		t.Fatal("ErrInvalidSQLiteFunc should have occured.", err)
+		return
+	}
+	err = c.RegisterSQLiteFunc("my_func", nil, false)
+	if err != ErrInvalidSQLiteFunc {
+		t.Fatal("ErrInvalidSQLiteFunc should have occured.", err)
+		return
+	}
+	err = c.RegisterSQLiteCollation("my_collation", nil)
+	if err != ErrInvalidSQLiteFunc {
+		t.Fatal("ErrInvalidSQLiteFunc should have occured.", err)
+		return
+	}
+}
+
+func TestSQLiteFuncResult(t *testing.T) {
+	good := map[any]any{
+		true:        int64(1),
+		false:       int64(0),
+		int(5):      int64(5),
+		uint8(5):    int64(5),
+		float32(.5): float64(.5),
+		"abc":       "abc",
+
+		time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600)): "2024-01-02 02:04:05",
+	}
+	for in, expected := range good {
+		got, err := sqliteFuncResult(in)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if got != expected {
+			t.Fatal("Result not converted as expected.", in, got)
+			return
+		}
+	}
+
+	_, err := sqliteFuncResult(uint64(1 << 63))
+	if err == nil {
+		t.Fatal("Error about overflow should have occured.")
+		return
+	}
+	_, err = sqliteFuncResult(struct{}{})
+	if err == nil {
+		t.Fatal("Error about unsupported type should have occured.")
+		return
+	}
+}
@@ -0,0 +1,356 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+/*
+This file handles SQLite maintenance tasks, such as checkpointing the WAL file,
+running ANALYZE via PRAGMA optimize, and vacuuming. These tasks can be run manually
+or periodically in the background via the config SQLiteMaintenance field.
+*/
+
+// sqliteCheckpointMode is the mode used when checkpointing the WAL file.
+type sqliteCheckpointMode string
+
+const (
+	SQLiteCheckpointPassive  = sqliteCheckpointMode("PASSIVE")
+	SQLiteCheckpointFull     = sqliteCheckpointMode("FULL")
+	SQLiteCheckpointRestart  = sqliteCheckpointMode("RESTART")
+	SQLiteCheckpointTruncate = sqliteCheckpointMode("TRUNCATE")
+)
+
+var validSQLiteCheckpointModes = []sqliteCheckpointMode{
+	SQLiteCheckpointPassive,
+	SQLiteCheckpointFull,
+	SQLiteCheckpointRestart,
+	SQLiteCheckpointTruncate,
+}
+
+var (
+	//ErrNotSQLite is returned when a SQLite specific task is run against a config
+	//that is not for a SQLite database.
+	ErrNotSQLite = errors.New("sqldb: database type is not SQLite")
+)
+
+// SQLiteCheckpointResult is the result of checkpointing the WAL file.
+//
+// Reference: https://www.sqlite.org/pragma.html#pragma_wal_checkpoint
+type SQLiteCheckpointResult struct {
+	//Busy is true if the checkpoint could not run to completion because of
+	//concurrent readers or writers.
+	Busy bool
+
+	//LogFrames is the number of modified pages written to the WAL file. This is -1
+	//if the database is not in WAL mode.
+	LogFrames int
+
+	//CheckpointedFrames is the number of pages in the WAL file that were moved back
+	//into the database file. This is -1 if the database is not in WAL mode.
+	CheckpointedFrames int
+}
+
+// SQLiteIntegrityCheckResult is the result of checking the integrity of a database.
+//
+// Reference: https://www.sqlite.org/pragma.html#pragma_integrity_check
+type SQLiteIntegrityCheckResult struct {
+	//OK is true if no problems were found.
+	OK bool
+
+	//Problems is the list of problems found, as described by SQLite.
+	Problems []string
+}
+
+// SQLiteMaintenanceOptions defines the tasks run periodically in the background
+// while connected to a SQLite database. An interval of 0 disables the task.
+type SQLiteMaintenanceOptions struct {
+	//CheckpointInterval is how often to checkpoint the WAL file. This prevents the
+	//WAL file from growing large when there are always readers on a database.
+	CheckpointInterval time.Duration
+
+	//CheckpointMode is the mode used for checkpointing. Defaults to PASSIVE.
+	CheckpointMode sqliteCheckpointMode
+
+	//OptimizeInterval is how often to run PRAGMA optimize, which runs ANALYZE on
+	//tables when SQLite thinks it will help query planning.
+	OptimizeInterval time.Duration
+
+	//IncrementalVacuumInterval is how often to run PRAGMA incremental_vacuum. This
+	//only has an effect if PRAGMA auto_vacuum is INCREMENTAL.
+	IncrementalVacuumInterval time.Duration
+
+	//IncrementalVacuumPages is the maximum number of pages to free each time an
+	//incremental vacuum is run. 0 frees all free pages.
+	IncrementalVacuumPages int
+}
+
+// validate checks the provided maintenance options. This is called in
+// Config.validate().
+func (o *SQLiteMaintenanceOptions) validate() (err error) {
+	if o.CheckpointMode != "" && !isOneOf(o.CheckpointMode, validSQLiteCheckpointModes) {
+		return fmt.Errorf("sqldb: invalid SQLite checkpoint mode, should be one of '%s', got '%s'", validSQLiteCheckpointModes, o.CheckpointMode)
+	}
+	if o.CheckpointInterval < 0 || o.OptimizeInterval < 0 || o.IncrementalVacuumInterval < 0 {
+		return errors.New("sqldb: SQLite maintenance intervals must not be negative")
+	}
+	if o.IncrementalVacuumPages < 0 {
+		return errors.New("sqldb: SQLite incremental vacuum pages must not be negative")
+	}
+
+	return
+}
+
+// checkSQLiteConnected checks that a config is for a SQLite database and that a
+// connection has been established. This is used before running a maintenance task.
+func (c *Config) checkSQLiteConnected() error {
+	if !c.IsSQLite() {
+		return ErrNotSQLite
+	}
+	if c.connection == nil {
+		return ErrNotConnected
+	}
+
+	return nil
+}
+
+// Checkpoint checkpoints the WAL file, moving changes into the database file. The
+// WAL file is truncated if the mode is TRUNCATE.
+func (c *Config) Checkpoint(mode sqliteCheckpointMode) (result SQLiteCheckpointResult, err error) {
+	err = c.checkSQLiteConnected()
+	if err != nil {
+		return
+	}
+
+	if mode == "" {
+		mode = SQLiteCheckpointPassive
+	}
+	if !isOneOf(mode, validSQLiteCheckpointModes) {
+		err = fmt.Errorf("sqldb: invalid SQLite checkpoint mode, should be one of '%s', got '%s'", validSQLiteCheckpointModes, mode)
+		return
+	}
+
+	var busy int
+	q := "PRAGMA wal_checkpoint(" + string(mode) + ")"
+	err = c.connection.QueryRow(q).Scan(&busy, &result.LogFrames, &result.CheckpointedFrames)
+	if err != nil {
+		return
+	}
+
+	result.Busy = busy != 0
+	return
+}
+
+// Checkpoint checkpoints the WAL file of the database noted in the package level
+// config.
+func Checkpoint(mode sqliteCheckpointMode) (result SQLiteCheckpointResult, err error) {
+	return cfg.Checkpoint(mode)
+}
+
+// Optimize runs PRAGMA optimize which runs ANALYZE on tables when SQLite thinks doing
+// so will improve query planning. This is cheap to run and should be run
+// periodically on long-running connections.
+func (c *Config) Optimize() (err error) {
+	err = c.checkSQLiteConnected()
+	if err != nil {
+		return
+	}
+
+	_, err = c.connection.Exec("PRAGMA optimize")
+	return
+}
+
+// Optimize runs PRAGMA optimize on the database noted in the package level config.
+func Optimize() (err error) {
+	return cfg.Optimize()
+}
+
+// Vacuum rebuilds the database file, reclaiming free space. This requires free disk
+// space of up to twice the size of the database and blocks other writers.
+func (c *Config) Vacuum() (err error) {
+	err = c.checkSQLiteConnected()
+	if err != nil {
+		return
+	}
+
+	_, err = c.connection.Exec("VACUUM")
+	return
+}
+
+// Vacuum rebuilds the database file of the database noted in the package level
+// config.
+func Vacuum() (err error) {
+	return cfg.Vacuum()
+}
+
+// IncrementalVacuum frees up to the given number of pages from the database file,
+// or all free pages if pages is 0, and returns the number of pages freed. This only
+// has an effect if PRAGMA auto_vacuum is INCREMENTAL.
+func (c *Config) IncrementalVacuum(pages int) (freed int, err error) {
+	err = c.checkSQLiteConnected()
+	if err != nil {
+		return
+	}
+
+	if pages < 0 {
+		err = errors.New("sqldb: SQLite incremental vacuum pages must not be negative")
+		return
+	}
+
+	//Count free pages before and after so we know how many pages were freed.
+	var before, after int
+	err = c.connection.Get(&before, "PRAGMA freelist_count")
+	if err != nil {
+		return
+	}
+
+	//Iterate through the returned rows, if any, since some libraries only free one
+	//page each time a row is stepped through.
+	q := "PRAGMA incremental_vacuum(" + strconv.Itoa(pages) + ")"
+	rows, err := c.connection.Query(q)
+	if err != nil {
+		return
+	}
+	for rows.Next() {
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return
+	}
+
+	err = c.connection.Get(&after, "PRAGMA freelist_count")
+	if err != nil {
+		return
+	}
+
+	freed = before - after
+	return
+}
+
+// IncrementalVacuum frees pages from the database noted in the package level config.
+func IncrementalVacuum(pages int) (freed int, err error) {
+	return cfg.IncrementalVacuum(pages)
+}
+
+// IntegrityCheck checks the database for corruption and returns any problems found.
+func (c *Config) IntegrityCheck() (result SQLiteIntegrityCheckResult, err error) {
+	err = c.checkSQLiteConnected()
+	if err != nil {
+		return
+	}
+
+	var rows []string
+	err = c.connection.Select(&rows, "PRAGMA integrity_check")
+	if err != nil {
+		return
+	}
+
+	//A single row of "ok" is returned if no problems were found.
+	if len(rows) == 1 && rows[0] == "ok" {
+		result.OK = true
+		return
+	}
+
+	result.Problems = rows
+	return
+}
+
+// IntegrityCheck checks the database noted in the package level config for
+// corruption.
+func IntegrityCheck() (result SQLiteIntegrityCheckResult, err error) {
+	return cfg.IntegrityCheck()
+}
+
+// startSQLiteMaintenance starts running the SQLiteMaintenance tasks in the
+// background. This is called in Connect() and the tasks are stopped in Close().
+func (c *Config) startSQLiteMaintenance() {
+	o := c.SQLiteMaintenance
+	if o == nil || !c.IsSQLite() {
+		return
+	}
+
+	//Don't start anything if no tasks are enabled.
+	if o.CheckpointInterval == 0 && o.OptimizeInterval == 0 && o.IncrementalVacuumInterval == 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	c.maintenanceStop = stop
+	c.maintenanceDone = done
+
+	go func() {
+		defer close(done)
+
+		//A nil channel is never ready, so tasks with a 0 interval never run.
+		checkpoint, stopCheckpoint := maintenanceTicker(o.CheckpointInterval)
+		optimize, stopOptimize := maintenanceTicker(o.OptimizeInterval)
+		vacuum, stopVacuum := maintenanceTicker(o.IncrementalVacuumInterval)
+		defer stopCheckpoint()
+		defer stopOptimize()
+		defer stopVacuum()
+
+		for {
+			select {
+			case <-stop:
+				return
+
+			case <-checkpoint:
+				r, err := c.Checkpoint(o.CheckpointMode)
+				if err != nil {
+					c.errorLn("sqldb.SQLiteMaintenance", "Error with checkpoint.", err)
+					continue
+				}
+				c.debugLn("sqldb.SQLiteMaintenance", "Checkpoint.", "busy:", r.Busy, "log:", r.LogFrames, "checkpointed:", r.CheckpointedFrames)
+
+			case <-optimize:
+				err := c.Optimize()
+				if err != nil {
+					c.errorLn("sqldb.SQLiteMaintenance", "Error with optimize.", err)
+					continue
+				}
+				c.debugLn("sqldb.SQLiteMaintenance", "Optimize.")
+
+			case <-vacuum:
+				freed, err := c.IncrementalVacuum(o.IncrementalVacuumPages)
+				if err != nil {
+					c.errorLn("sqldb.SQLiteMaintenance", "Error with incremental vacuum.", err)
+					continue
+				}
+				c.debugLn("sqldb.SQLiteMaintenance", "Incremental vacuum.", "freed:", freed)
+			}
+		}
+	}()
+
+	c.debugLn("sqldb.SQLiteMaintenance", "Started.")
+}
+
+// stopSQLiteMaintenance stops the SQLiteMaintenance tasks, if running, and waits for
+// any in-progress task to complete. This is called in Close().
+func (c *Config) stopSQLiteMaintenance() {
+	if c.maintenanceStop == nil {
+		return
+	}
+
+	close(c.maintenanceStop)
+	<-c.maintenanceDone
+
+	c.maintenanceStop = nil
+	c.maintenanceDone = nil
+
+	c.debugLn("sqldb.SQLiteMaintenance", "Stopped.")
+}
+
+// maintenanceTicker returns a channel that receives every interval and a func to stop
+// the ticker. If the interval is 0, the returned channel is nil which never receives.
+func maintenanceTicker(interval time.Duration) (<-chan time.Time, func()) {
+	if interval <= 0 {
+		return nil, func() {}
+	}
+
+	t := time.NewTicker(interval)
+	return t.C, t.Stop
+}
@@ -0,0 +1,201 @@
+package sqldb
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// deployMaintenanceTestDB deploys a SQLite database in a file, since WAL mode and
+// vacuuming don't apply to in-memory databases, and inserts some data.
+func deployMaintenanceTestDB(t *testing.T) *Config {
+	c := NewSQLite(filepath.Join(t.TempDir(), "maintenance.db"))
+	c.SQLitePragmaConfig = SQLitePragmaConfig{
+		JournalMode: SQLiteJournalModeWAL,
+		AutoVacuum:  SQLiteAutoVacuumIncremental,
+	}
+	c.DeployQueries = []string{
+		`CREATE TABLE IF NOT EXISTS blobs (ID INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL, Data BLOB NOT NULL)`,
+	}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return nil
+	}
+
+	for i := 0; i < 50; i++ {
+		_, err = c.Connection().Exec("INSERT INTO blobs (Data) VALUES (zeroblob(8192))")
+		if err != nil {
+			t.Fatal(err)
+			return nil
+		}
+	}
+
+	return c
+}
+
+func TestCheckpoint(t *testing.T) {
+	c := deployMaintenanceTestDB(t)
+	defer c.Close()
+
+	r, err := c.Checkpoint(SQLiteCheckpointTruncate)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if r.Busy {
+		t.Fatal("Checkpoint should not be busy.")
+		return
+	}
+	if r.LogFrames != 0 {
+		t.Fatal("WAL file should be truncated.", r.LogFrames)
+		return
+	}
+
+	//Default mode.
+	_, err = c.Checkpoint("")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//Bad mode.
+	_, err = c.Checkpoint("sometimes")
+	if err == nil {
+		t.Fatal("Error about invalid checkpoint mode should have occured.")
+		return
+	}
+}
+
+func TestOptimizeAndVacuum(t *testing.T) {
+	c := deployMaintenanceTestDB(t)
+	defer c.Close()
+
+	err := c.Optimize()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//Delete data so there are free pages to reclaim.
+	_, err = c.Connection().Exec("DELETE FROM blobs")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	freed, err := c.IncrementalVacuum(5)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if freed != 5 {
+		t.Fatal("Incremental vacuum should have freed 5 pages.", freed)
+		return
+	}
+
+	freed, err = c.IncrementalVacuum(0)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if freed == 0 {
+		t.Fatal("Incremental vacuum should have freed all remaining pages.")
+		return
+	}
+
+	err = c.Vacuum()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+}
+
+func TestIntegrityCheck(t *testing.T) {
+	c := deployMaintenanceTestDB(t)
+	defer c.Close()
+
+	r, err := c.IntegrityCheck()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if !r.OK || len(r.Problems) != 0 {
+		t.Fatal("Integrity check should be ok.", r.Problems)
+		return
+	}
+}
+
+func TestMaintenanceNotSQLite(t *testing.T) {
+	c := NewMariaDB("10.0.0.1", "db_name", "user", "password")
+
+	_, err := c.Checkpoint(SQLiteCheckpointPassive)
+	if !errors.Is(err, ErrNotSQLite) {
+		t.Fatal("ErrNotSQLite should have occured.", err)
+		return
+	}
+	err = c.Optimize()
+	if !errors.Is(err, ErrNotSQLite) {
+		t.Fatal("ErrNotSQLite should have occured.", err)
+		return
+	}
+
+	//Background maintenance is only for SQLite.
+	c.SQLiteMaintenance = &SQLiteMaintenanceOptions{
+		OptimizeInterval: time.Hour,
+	}
+	err = c.validate()
+	if !errors.Is(err, ErrNotSQLite) {
+		t.Fatal("ErrNotSQLite should have occured.", err)
+		return
+	}
+
+	//Not connected.
+	c = NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	err = c.Optimize()
+	if err != ErrNotConnected {
+		t.Fatal("ErrNotConnected should have occured.", err)
+		return
+	}
+}
+
+func TestSQLiteMaintenanceBackground(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.SQLiteMaintenance = &SQLiteMaintenanceOptions{
+		CheckpointInterval: 5 * time.Millisecond,
+		OptimizeInterval:   5 * time.Millisecond,
+	}
+
+	err := c.Connect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if c.maintenanceStop == nil {
+		t.Fatal("Background maintenance should be running.")
+		return
+	}
+
+	//Let tasks run a few times.
+	time.Sleep(25 * time.Millisecond)
+
+	err = c.Close()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if c.maintenanceStop != nil {
+		t.Fatal("Background maintenance should be stopped.")
+		return
+	}
+
+	//Bad options.
+	c.SQLiteMaintenance.CheckpointMode = "sometimes"
+	err = c.Connect()
+	if err == nil {
+		t.Fatal("Error about invalid checkpoint mode should have occured.")
+		return
+	}
+}
@@ -1,10 +1,12 @@
-//go:build !modernc
+//go:build (!modernc && !ncruces) || mattn
 
 /*
 This file handles the [github.com/mattn/go-sqlite3] SQLite library.
 
 This library is the default SQLite library if no build tags are provided. Note the
-"go:build !modernc" line.
+"go:build (!modernc && !ncruces) || mattn" line. Provide both the mattn and modernc
+build tags to build with both libraries and choose the library at runtime with the
+config SQLiteLibrary field.
 
 This library requires CGO, and therefore requires a bit more work to get cross-
 compiling to work properly.
@@ -13,13 +15,62 @@ compiling to work properly.
 package sqldb
 
 import (
-	_ "github.com/mattn/go-sqlite3"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
 )
 
-const (
-	//sqliteLibrary is used in logging.
-	sqliteLibrary = sqliteLibraryMattn
+func init() {
+	registerSQLiteLibrary(SQLiteLibraryMattn, sqliteDriver{
+		//driverName is used in Connect() when calling [database/sql.Open].
+		driverName: "sqlite3",
 
-	//sqliteDriverName is used in Connect() when calling [database/sql.Open].
-	sqliteDriverName = "sqlite3"
-)
+		registerFuncs: mattnRegisterFuncs,
+
+		errorCode: mattnErrorCode,
+	})
+}
+
+// mattnRegisterFuncs returns a connectHook that registers custom SQL functions and
+// collations on each connection. The mattn library registers functions per
+// connection.
+func mattnRegisterFuncs(funcs []sqliteFunc, collations []sqliteCollation) (connectHook, error) {
+	hook := func(ctx context.Context, conn driver.Conn) (err error) {
+		sc, ok := conn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("sqldb: unexpected connection type %T for mattn library", conn)
+		}
+
+		for _, f := range funcs {
+			err = sc.RegisterFunc(f.name, f.call, f.deterministic)
+			if err != nil {
+				return
+			}
+		}
+
+		for _, cl := range collations {
+			err = sc.RegisterCollation(cl.name, cl.cmp)
+			if err != nil {
+				return
+			}
+		}
+
+		return
+	}
+
+	return hook, nil
+}
+
+// mattnErrorCode returns the extended SQLite result code from an error returned by
+// the mattn library.
+func mattnErrorCode(err error) (code int, ok bool) {
+	var se sqlite3.Error
+	if !errors.As(err, &se) {
+		return
+	}
+
+	return int(se.ExtendedCode), true
+}
@@ -0,0 +1,129 @@
+package sqldb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+/*
+This file handles SQLite in-memory databases.
+
+An in-memory database only exists while at least one connection to it is open. With
+connection pooling, connections are opened and closed as needed, so a shared-cache
+in-memory database could be destroyed between queries, or between DeploySchema()
+and Connect(). To prevent this, a separate connection is kept open to shared-cache
+in-memory databases from Connect() until Close() is called.
+*/
+
+// SQLiteInMemoryFilepathNamed returns the path to provide for SQLitePath when you
+// want to use a named, shared-cache, in-memory database. Every connection in the
+// connection pool connects to the same database, but configs using different names
+// get separate databases. If name is blank, a random name is used.
+//
+// This is good for running tests in parallel since each test can use a separate
+// in-memory database.
+func SQLiteInMemoryFilepathNamed(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		b := make([]byte, 8)
+		rand.Read(b)
+		name = "sqldb-" + hex.EncodeToString(b)
+	}
+
+	return "file:" + url.PathEscape(name) + "?mode=memory&cache=shared"
+}
+
+// NewSQLiteInMemory is a shorthand for calling New() and then manually setting the
+// applicable SQLite fields for a uniquely named in-memory database. See
+// SQLiteInMemoryFilepathNamed().
+func NewSQLiteInMemory() *Config {
+	return NewSQLite(SQLiteInMemoryFilepathNamed(""))
+}
+
+// isSQLiteInMemory returns true if the path is for an in-memory database. The
+// returned shared value is true if the path uses a shared cache, meaning each
+// connection in the pool connects to the same database.
+func isSQLiteInMemory(path string) (inMemory, shared bool) {
+	p, rawQuery, _ := strings.Cut(path, "?")
+	q, _ := url.ParseQuery(rawQuery)
+
+	inMemory = p == ":memory:" || p == "file::memory:" || q.Get("mode") == "memory"
+	shared = inMemory && q.Get("cache") == "shared"
+	return
+}
+
+// sqliteSharedInMemoryName is the name used for the unnamed shared-cache in-memory
+// database (ex.: SQLiteInMemoryFilepathRaceSafe) when the library doesn't support a
+// shared cache. Every config using the unnamed database shares the same database,
+// the same as with a shared cache.
+const sqliteSharedInMemoryName = "sqldb-shared-memory"
+
+// sqliteInMemoryConnString returns the path to use in the connection string for a
+// SQLite database. This only changes the path for a shared-cache in-memory database
+// when the library requires a different format (see namedInMemoryPath).
+func sqliteInMemoryConnString(path string, lib library) string {
+	convert := sqliteDrivers[lib].namedInMemoryPath
+	if convert == nil {
+		return path
+	}
+
+	p, rawQuery, _ := strings.Cut(path, "?")
+	q, _ := url.ParseQuery(rawQuery)
+	if q.Get("cache") != "shared" {
+		return path
+	}
+
+	//Unnamed, ex.: file::memory:?cache=shared.
+	if p == ":memory:" || p == "file::memory:" {
+		return convert(sqliteSharedInMemoryName)
+	}
+
+	name, isURI := strings.CutPrefix(p, "file:")
+	if !isURI || name == "" || q.Get("mode") != "memory" {
+		return path
+	}
+
+	return convert(name)
+}
+
+// openSQLiteKeepAlive opens a connection to a shared-cache in-memory database that
+// is kept open until Close() is called so that the database isn't destroyed when the
+// connection pool closes its connections. This is called in Connect().
+func (c *Config) openSQLiteKeepAlive(connString string) (err error) {
+	if !c.IsSQLite() || c.sqliteKeepAlive != nil {
+		return
+	}
+	if _, shared := isSQLiteInMemory(c.SQLitePath); !shared {
+		return
+	}
+
+	conn, err := c.open(connString)
+	if err != nil {
+		return
+	}
+
+	conn.SetMaxOpenConns(1)
+	err = conn.Ping()
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	c.sqliteKeepAlive = conn
+	c.debugLn("sqldb.openSQLiteKeepAlive", "Keeping in-memory database alive.")
+	return
+}
+
+// closeSQLiteKeepAlive closes the connection opened in openSQLiteKeepAlive(), if
+// any, destroying the in-memory database once all other connections are closed.
+// This is called in Close().
+func (c *Config) closeSQLiteKeepAlive() {
+	if c.sqliteKeepAlive == nil {
+		return
+	}
+
+	c.sqliteKeepAlive.Close()
+	c.sqliteKeepAlive = nil
+}
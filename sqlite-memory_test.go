@@ -0,0 +1,238 @@
+package sqldb
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSQLiteInMemoryFilepathNamed(t *testing.T) {
+	got := SQLiteInMemoryFilepathNamed("my db")
+	if got != "file:my%20db?mode=memory&cache=shared" {
+		t.Fatal("Path not built correctly.", got)
+		return
+	}
+
+	//Random names should be unique.
+	a := SQLiteInMemoryFilepathNamed("")
+	b := SQLiteInMemoryFilepathNamed("")
+	if a == b {
+		t.Fatal("Random names should be unique.", a, b)
+		return
+	}
+
+	inMemory, shared := isSQLiteInMemory(a)
+	if !inMemory || !shared {
+		t.Fatal("Path should be for a shared in-memory database.", a)
+		return
+	}
+	inMemory, shared = isSQLiteInMemory(SQLiteInMemoryFilepathRacy)
+	if !inMemory || shared {
+		t.Fatal("Path should be for a non-shared in-memory database.")
+		return
+	}
+	inMemory, _ = isSQLiteInMemory("/path/to/sqlite.db")
+	if inMemory {
+		t.Fatal("Path should not be for an in-memory database.")
+		return
+	}
+}
+
+func TestSQLiteInMemoryNamed(t *testing.T) {
+	t.Parallel()
+
+	deploy := func(t *testing.T, c *Config) {
+		c.SQLitePragmaConfig.ForeignKeys = true
+		c.DeployQueries = []string{
+			`CREATE TABLE IF NOT EXISTS users (ID INTEGER PRIMARY KEY NOT NULL, Name TEXT NOT NULL)`,
+		}
+
+		//Default options close the connection after deploying.
+		err := c.DeploySchema(nil)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		if c.Connected() {
+			t.Fatal("Connection should be closed after deploy.")
+			return
+		}
+	}
+
+	c1 := NewSQLiteInMemory()
+	c2 := NewSQLiteInMemory()
+	deploy(t, c1)
+	deploy(t, c2)
+	defer c1.Close()
+	defer c2.Close()
+
+	//Database should still exist after deploying closed the connection pool.
+	err := c1.Connect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//Check on more than one connection in the pool.
+	c1.Connection().SetMaxIdleConns(0)
+	for i := 0; i < 2; i++ {
+		_, err = c1.Connection().Exec("INSERT INTO users (Name) VALUES (?)", "a")
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+	}
+
+	//Databases should be separate.
+	err = c2.Connect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	var count1, count2 int
+	err = c1.Connection().Get(&count1, "SELECT COUNT(*) FROM users")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	err = c2.Connection().Get(&count2, "SELECT COUNT(*) FROM users")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if count1 != 2 || count2 != 0 {
+		t.Fatal("In-memory databases should be separate.", count1, count2)
+		return
+	}
+
+	//Database is destroyed on Close().
+	c1.Close()
+	err = c1.Connect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	_, err = c1.Connection().Exec("SELECT COUNT(*) FROM users")
+	if err == nil || !strings.Contains(err.Error(), "no such table") {
+		t.Fatal("Database should have been destroyed on Close().", err)
+		return
+	}
+}
+
+func TestSQLiteInMemoryRacyWithPragmas(t *testing.T) {
+	//This used to fail since ":memory:" could not be parsed as a URL.
+	c := NewSQLite(SQLiteInMemoryFilepathRacy)
+	c.SQLitePragmaConfig.ForeignKeys = true
+
+	err := c.Connect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	var foreignKeys int
+	err = c.Connection().Get(&foreignKeys, "PRAGMA foreign_keys")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if foreignKeys != 1 {
+		t.Fatal("PRAGMA foreign_keys not set.", foreignKeys)
+		return
+	}
+}
+
+func TestSQLiteInMemoryConnString(t *testing.T) {
+	//Libraries with a shared cache use the path as-is.
+	for lib, d := range sqliteDrivers {
+		if d.namedInMemoryPath != nil {
+			continue
+		}
+		if got := sqliteInMemoryConnString(SQLiteInMemoryFilepathRaceSafe, lib); got != SQLiteInMemoryFilepathRaceSafe {
+			t.Fatal("Path should not be changed.", lib, got)
+			return
+		}
+	}
+
+	//Libraries without a shared cache use a named database instead.
+	convert := func(name string) string { return "file:/" + name + "?vfs=memdb" }
+	registerSQLiteLibrary("test-no-shared-cache", sqliteDriver{namedInMemoryPath: convert})
+	defer delete(sqliteDrivers, "test-no-shared-cache")
+
+	tt := []struct {
+		path     string
+		expected string
+	}{
+		{SQLiteInMemoryFilepathRaceSafe, "file:/" + sqliteSharedInMemoryName + "?vfs=memdb"},
+		{":memory:?cache=shared", "file:/" + sqliteSharedInMemoryName + "?vfs=memdb"},
+		{SQLiteInMemoryFilepathNamed("a"), "file:/a?vfs=memdb"},
+		{SQLiteInMemoryFilepathRacy, SQLiteInMemoryFilepathRacy},
+		{"/path/to/sqlite.db", "/path/to/sqlite.db"},
+	}
+
+	for _, tc := range tt {
+		got := sqliteInMemoryConnString(tc.path, "test-no-shared-cache")
+		if got != tc.expected {
+			t.Log("Got:", got)
+			t.Log("Exp:", tc.expected)
+			t.Fatal("Bad path.", tc.path)
+			return
+		}
+	}
+}
+
+func TestSQLiteInMemoryRaceSafePooled(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueries = []string{
+		`CREATE TABLE IF NOT EXISTS race_safe_pooled (ID INTEGER PRIMARY KEY NOT NULL)`,
+	}
+
+	//Default options close the connection after deploying.
+	err := c.DeploySchema(nil)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	err = c.Connect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	//Use two connections from the pool at once so that each is a separate
+	//connection to the database.
+	ctx := context.Background()
+	conn1, err := c.Connection().Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer conn1.Close()
+	conn2, err := c.Connection().Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer conn2.Close()
+
+	_, err = conn1.ExecContext(ctx, "INSERT INTO race_safe_pooled (ID) VALUES (1)")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	var count int
+	err = conn2.QueryRowContext(ctx, "SELECT COUNT(*) FROM race_safe_pooled").Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if count != 1 {
+		t.Fatal("Row should be visible from another connection.", count)
+		return
+	}
+}
@@ -4,7 +4,8 @@
 This file handles the [modernc.org/sqlite] SQLite library.
 
 This library is not the default since it doesn't use the SQLite source C code and
-isn't as widely used.
+isn't as widely used. Provide both the mattn and modernc build tags to build with
+both libraries and choose the library at runtime with the config SQLiteLibrary field.
 
 However, this library is straight golang and does not require CGO which makes cross-
 compiling much easier.
@@ -13,13 +14,106 @@ compiling much easier.
 package sqldb
 
 import (
-	_ "modernc.org/sqlite"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+
+	"modernc.org/sqlite"
 )
 
-const (
-	//sqliteLibrary is used in logging.
-	sqliteLibrary = sqliteLibraryModernc
+func init() {
+	registerSQLiteLibrary(SQLiteLibraryModernc, sqliteDriver{
+		//driverName is used in Connect() when calling [database/sql.Open].
+		driverName: "sqlite",
+
+		registerFuncs: moderncRegisterFuncs,
 
-	//sqliteDriverName is used in Connect() when calling [database/sql.Open].
-	sqliteDriverName = "sqlite"
+		errorCode: moderncErrorCode,
+	})
+}
+
+// moderncFuncs and moderncCollations are the current implementations of each custom
+// SQL function and collation, keyed by lowercased name. The modernc library
+// registers functions and collations for the entire process and errors if a name is
+// registered twice, so a function that looks up the current implementation is
+// registered once per name instead.
+var (
+	moderncFuncs      = make(map[string]sqliteFunc)
+	moderncCollations = make(map[string]sqliteCollation)
+	moderncMu         sync.RWMutex
 )
+
+// moderncRegisterFuncs registers custom SQL functions and collations for the entire
+// process. A nil connectHook is returned since registration isn't per connection.
+func moderncRegisterFuncs(funcs []sqliteFunc, collations []sqliteCollation) (hook connectHook, err error) {
+	moderncMu.Lock()
+	defer moderncMu.Unlock()
+
+	for _, f := range funcs {
+		key := strings.ToLower(f.name)
+		_, exists := moderncFuncs[key]
+		moderncFuncs[key] = f
+		if exists {
+			continue
+		}
+
+		//Note that whether or not the function is deterministic is set the first
+		//time a name is registered.
+		register := sqlite.RegisterScalarFunction
+		if f.deterministic {
+			register = sqlite.RegisterDeterministicScalarFunction
+		}
+
+		err = register(f.name, -1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			moderncMu.RLock()
+			current := moderncFuncs[key]
+			moderncMu.RUnlock()
+
+			in := make([]any, len(args))
+			for i, a := range args {
+				in[i] = a
+			}
+			return current.call(in...)
+		})
+		if err != nil {
+			delete(moderncFuncs, key)
+			return
+		}
+	}
+
+	for _, cl := range collations {
+		key := strings.ToLower(cl.name)
+		_, exists := moderncCollations[key]
+		moderncCollations[key] = cl
+		if exists {
+			continue
+		}
+
+		err = sqlite.RegisterCollationUtf8(cl.name, func(a, b string) int {
+			moderncMu.RLock()
+			current := moderncCollations[key]
+			moderncMu.RUnlock()
+
+			return current.cmp(a, b)
+		})
+		if err != nil {
+			delete(moderncCollations, key)
+			return
+		}
+	}
+
+	return
+}
+
+// moderncErrorCode returns the SQLite result code from an error returned by the
+// modernc library. This is the extended result code since the modernc library
+// enables extended result codes.
+func moderncErrorCode(err error) (code int, ok bool) {
+	var se *sqlite.Error
+	if !errors.As(err, &se) {
+		return
+	}
+
+	return se.Code(), true
+}
@@ -0,0 +1,140 @@
+//go:build ncruces
+
+/*
+This file handles the [github.com/ncruces/go-sqlite3] SQLite library.
+
+This library is not the default since it is newer and less widely used. It runs the
+SQLite source C code compiled to WASM via [github.com/tetratelabs/wazero] and
+therefore does not require CGO, which makes cross-compiling much easier, while
+still using the original SQLite code.
+
+This library registers itself with the same driver name as the mattn library,
+"sqlite3", so the two libraries cannot be built into the same binary. This library
+can be built alongside the modernc library (-tags modernc,ncruces).
+*/
+
+package sqldb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/ncruces/go-sqlite3"
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+	_ "github.com/ncruces/go-sqlite3/vfs/memdb"
+)
+
+func init() {
+	registerSQLiteLibrary(SQLiteLibraryNcruces, sqliteDriver{
+		//driverName is used in Connect() when calling [database/sql.Open].
+		driverName: "sqlite3",
+
+		registerFuncs: ncrucesRegisterFuncs,
+
+		errorCode: ncrucesErrorCode,
+
+		//The ncruces library is built without shared-cache support, so the memdb
+		//VFS is used instead. The database name must start with a slash to be
+		//shared between connections.
+		namedInMemoryPath: func(name string) string {
+			return "file:/" + name + "?vfs=memdb"
+		},
+	})
+}
+
+// ncrucesRegisterFuncs returns a connectHook that registers custom SQL functions and
+// collations on each connection. The ncruces library registers functions per
+// connection.
+func ncrucesRegisterFuncs(funcs []sqliteFunc, collations []sqliteCollation) (connectHook, error) {
+	hook := func(ctx context.Context, conn driver.Conn) (err error) {
+		rc, ok := conn.(interface{ Raw() *sqlite3.Conn })
+		if !ok {
+			return fmt.Errorf("sqldb: unexpected connection type %T for ncruces library", conn)
+		}
+		raw := rc.Raw()
+
+		for _, f := range funcs {
+			flag := sqlite3.FunctionFlag(0)
+			if f.deterministic {
+				flag = sqlite3.DETERMINISTIC
+			}
+
+			err = raw.CreateFunction(f.name, -1, flag, ncrucesScalarFunc(f))
+			if err != nil {
+				return
+			}
+		}
+
+		for _, cl := range collations {
+			cmp := cl.cmp
+			err = raw.CreateCollation(cl.name, func(a, b []byte) int {
+				return cmp(string(a), string(b))
+			})
+			if err != nil {
+				return
+			}
+		}
+
+		return
+	}
+
+	return hook, nil
+}
+
+// ncrucesScalarFunc wraps a custom SQL function in the ncruces library's function
+// signature, converting arguments and the result.
+func ncrucesScalarFunc(f sqliteFunc) sqlite3.ScalarFunction {
+	return func(ctx sqlite3.Context, arg ...sqlite3.Value) {
+		in := make([]any, len(arg))
+		for i, a := range arg {
+			switch a.Type() {
+			case sqlite3.INTEGER:
+				in[i] = a.Int64()
+			case sqlite3.FLOAT:
+				in[i] = a.Float()
+			case sqlite3.TEXT:
+				in[i] = a.Text()
+			case sqlite3.BLOB:
+				in[i] = a.Blob(nil)
+			default:
+				in[i] = nil
+			}
+		}
+
+		v, err := f.call(in...)
+		if err != nil {
+			ctx.ResultError(err)
+			return
+		}
+
+		//call() converts the result to one of these types, see sqliteFuncResult().
+		switch t := v.(type) {
+		case nil:
+			ctx.ResultNull()
+		case int64:
+			ctx.ResultInt64(t)
+		case float64:
+			ctx.ResultFloat(t)
+		case string:
+			ctx.ResultText(t)
+		case []byte:
+			ctx.ResultBlob(t)
+		default:
+			ctx.ResultError(fmt.Errorf("sqldb: unsupported SQLite function result type %T", v))
+		}
+	}
+}
+
+// ncrucesErrorCode returns the extended SQLite result code from an error returned by
+// the ncruces library.
+func ncrucesErrorCode(err error) (code int, ok bool) {
+	var se *sqlite3.Error
+	if !errors.As(err, &se) {
+		return
+	}
+
+	return int(se.ExtendedCode()), true
+}
@@ -0,0 +1,244 @@
+package sqldb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+This file handles the typed alternative to providing SQLite PRAGMAs as a list of
+"PRAGMA x = y" strings. See the config SQLitePragmaConfig field.
+*/
+
+// SQLitePragmaConfig is a typed list of the more commonly used SQLite PRAGMAs. This
+// is an alternative to providing PRAGMAs in SQLitePragmas as "PRAGMA x = y" strings
+// and validates each value before connecting to the database.
+//
+// The zero value of each field leaves the PRAGMA unset, using SQLite's default (or
+// the value provided in SQLitePragmas). For each field, the zero value either matches
+// SQLite's default or is not a useful value, so "zero means unset" is safe.
+//
+// Any PRAGMA set here will override a PRAGMA of the same name in SQLitePragmas. Use
+// SQLitePragmas for PRAGMAs not covered by this struct.
+type SQLitePragmaConfig struct {
+	//JournalMode sets PRAGMA journal_mode.
+	JournalMode sqliteJournalMode
+
+	//Synchronous sets PRAGMA synchronous.
+	Synchronous sqliteSynchronous
+
+	//BusyTimeout sets PRAGMA busy_timeout. This is rounded down to the millisecond.
+	BusyTimeout time.Duration
+
+	//ForeignKeys sets PRAGMA foreign_keys = ON. Foreign key enforcement is off by
+	//default in SQLite.
+	ForeignKeys bool
+
+	//CacheSize sets PRAGMA cache_size. A positive value is a number of pages, a
+	//negative value is a number of KiB.
+	CacheSize int
+
+	//TempStore sets PRAGMA temp_store.
+	TempStore sqliteTempStore
+
+	//MmapSize sets PRAGMA mmap_size, in bytes.
+	MmapSize int64
+
+	//AutoVacuum sets PRAGMA auto_vacuum. Note that auto_vacuum can only be changed
+	//before any tables are created, or by running VACUUM afterwards.
+	AutoVacuum sqliteAutoVacuum
+}
+
+// Supported values for SQLitePragmaConfig fields.
+type sqliteJournalMode string
+type sqliteSynchronous string
+type sqliteTempStore string
+type sqliteAutoVacuum string
+
+const (
+	SQLiteJournalModeDelete   = sqliteJournalMode("DELETE")
+	SQLiteJournalModeTruncate = sqliteJournalMode("TRUNCATE")
+	SQLiteJournalModePersist  = sqliteJournalMode("PERSIST")
+	SQLiteJournalModeMemory   = sqliteJournalMode("MEMORY")
+	SQLiteJournalModeWAL      = sqliteJournalMode("WAL")
+	SQLiteJournalModeOff      = sqliteJournalMode("OFF")
+
+	SQLiteSynchronousOff    = sqliteSynchronous("OFF")
+	SQLiteSynchronousNormal = sqliteSynchronous("NORMAL")
+	SQLiteSynchronousFull   = sqliteSynchronous("FULL")
+	SQLiteSynchronousExtra  = sqliteSynchronous("EXTRA")
+
+	SQLiteTempStoreDefault = sqliteTempStore("DEFAULT")
+	SQLiteTempStoreFile    = sqliteTempStore("FILE")
+	SQLiteTempStoreMemory  = sqliteTempStore("MEMORY")
+
+	SQLiteAutoVacuumNone        = sqliteAutoVacuum("NONE")
+	SQLiteAutoVacuumFull        = sqliteAutoVacuum("FULL")
+	SQLiteAutoVacuumIncremental = sqliteAutoVacuum("INCREMENTAL")
+)
+
+var (
+	validSQLiteJournalModes = []sqliteJournalMode{
+		SQLiteJournalModeDelete,
+		SQLiteJournalModeTruncate,
+		SQLiteJournalModePersist,
+		SQLiteJournalModeMemory,
+		SQLiteJournalModeWAL,
+		SQLiteJournalModeOff,
+	}
+
+	validSQLiteSynchronous = []sqliteSynchronous{
+		SQLiteSynchronousOff,
+		SQLiteSynchronousNormal,
+		SQLiteSynchronousFull,
+		SQLiteSynchronousExtra,
+	}
+
+	validSQLiteTempStores = []sqliteTempStore{
+		SQLiteTempStoreDefault,
+		SQLiteTempStoreFile,
+		SQLiteTempStoreMemory,
+	}
+
+	validSQLiteAutoVacuums = []sqliteAutoVacuum{
+		SQLiteAutoVacuumNone,
+		SQLiteAutoVacuumFull,
+		SQLiteAutoVacuumIncremental,
+	}
+)
+
+// validate checks that each set field has a value SQLite will accept. This is called
+// in Config.validate().
+func (p SQLitePragmaConfig) validate() (err error) {
+	if p.JournalMode != "" && !isOneOf(p.JournalMode, validSQLiteJournalModes) {
+		return fmt.Errorf("sqldb: invalid SQLite journal_mode, should be one of '%s', got '%s'", validSQLiteJournalModes, p.JournalMode)
+	}
+	if p.Synchronous != "" && !isOneOf(p.Synchronous, validSQLiteSynchronous) {
+		return fmt.Errorf("sqldb: invalid SQLite synchronous, should be one of '%s', got '%s'", validSQLiteSynchronous, p.Synchronous)
+	}
+	if p.TempStore != "" && !isOneOf(p.TempStore, validSQLiteTempStores) {
+		return fmt.Errorf("sqldb: invalid SQLite temp_store, should be one of '%s', got '%s'", validSQLiteTempStores, p.TempStore)
+	}
+	if p.AutoVacuum != "" && !isOneOf(p.AutoVacuum, validSQLiteAutoVacuums) {
+		return fmt.Errorf("sqldb: invalid SQLite auto_vacuum, should be one of '%s', got '%s'", validSQLiteAutoVacuums, p.AutoVacuum)
+	}
+	if p.BusyTimeout < 0 {
+		return fmt.Errorf("sqldb: invalid SQLite busy_timeout, must not be negative, got '%s'", p.BusyTimeout)
+	}
+	if p.MmapSize < 0 {
+		return fmt.Errorf("sqldb: invalid SQLite mmap_size, must not be negative, got '%d'", p.MmapSize)
+	}
+
+	return
+}
+
+// pragmas returns the set fields as PRAGMA statements in SQLite query format, the
+// same format used in SQLitePragmas, so that the PRAGMAs can be translated to the
+// SQLite library's filepath format with pragmasToURLValues().
+func (p SQLitePragmaConfig) pragmas() (pragmas []string) {
+	add := func(key, value string) {
+		pragmas = append(pragmas, "PRAGMA "+key+" = "+value)
+	}
+
+	//auto_vacuum must be first since it can only be set before the database file
+	//is initialized, which setting some other PRAGMAs (journal_mode) will do.
+	if p.AutoVacuum != "" {
+		add("auto_vacuum", string(p.AutoVacuum))
+	}
+	if p.JournalMode != "" {
+		add("journal_mode", string(p.JournalMode))
+	}
+	if p.Synchronous != "" {
+		add("synchronous", string(p.Synchronous))
+	}
+	if p.BusyTimeout > 0 {
+		add("busy_timeout", strconv.FormatInt(p.BusyTimeout.Milliseconds(), 10))
+	}
+	if p.ForeignKeys {
+		add("foreign_keys", "ON")
+	}
+	if p.CacheSize != 0 {
+		add("cache_size", strconv.Itoa(p.CacheSize))
+	}
+	if p.TempStore != "" {
+		add("temp_store", string(p.TempStore))
+	}
+	if p.MmapSize > 0 {
+		add("mmap_size", strconv.FormatInt(p.MmapSize, 10))
+	}
+
+	return
+}
+
+// sqlitePragmas returns the full list of PRAGMAs to apply when connecting to a
+// SQLite database. This is the SQLitePragmas list with any PRAGMAs set in
+// SQLitePragmaConfig appended. A PRAGMA set in SQLitePragmaConfig replaces a PRAGMA
+// of the same name in SQLitePragmas so that the PRAGMA isn't set twice with
+// differing values.
+func (c *Config) sqlitePragmas() (pragmas []string) {
+	typed := c.SQLitePragmaConfig.pragmas()
+	if len(typed) == 0 {
+		return c.SQLitePragmas
+	}
+
+	overridden := make(map[string]bool, len(typed))
+	for _, p := range typed {
+		overridden[pragmaName(p)] = true
+	}
+
+	for _, p := range c.SQLitePragmas {
+		if overridden[pragmaName(p)] {
+			continue
+		}
+
+		pragmas = append(pragmas, p)
+	}
+
+	pragmas = append(pragmas, typed...)
+	return
+}
+
+// pragmaName returns the name of the PRAGMA from a PRAGMA statement in SQLite query
+// format, lowercased (ex.: "PRAGMA busy_timeout = 5000" returns "busy_timeout").
+func pragmaName(pragma string) string {
+	p := strings.ToLower(strings.TrimSpace(pragma))
+	p = strings.TrimPrefix(p, "pragma")
+
+	name, _, _ := strings.Cut(p, "=")
+	return strings.TrimSpace(name)
+}
+
+// mattnDSNPragmas is the list of PRAGMAs the [github.com/mattn/go-sqlite3] library
+// will read from the filepath's query parameters. Any other PRAGMAs are ignored by
+// the library so we have to run them ourselves upon each connection being opened.
+//
+// Reference: https://github.com/mattn/go-sqlite3#connection-string
+var mattnDSNPragmas = []string{
+	"auto_vacuum",
+	"busy_timeout",
+	"cache_size",
+	"case_sensitive_like",
+	"defer_foreign_keys",
+	"foreign_keys",
+	"ignore_check_constraints",
+	"journal_mode",
+	"locking_mode",
+	"query_only",
+	"recursive_triggers",
+	"secure_delete",
+	"synchronous",
+	"writable_schema",
+}
+
+// isOneOf returns true if v is in the list.
+func isOneOf[T comparable](v T, list []T) bool {
+	for _, l := range list {
+		if v == l {
+			return true
+		}
+	}
+
+	return false
+}
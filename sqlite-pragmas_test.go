@@ -0,0 +1,193 @@
+package sqldb
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQLitePragmaConfigValidate(t *testing.T) {
+	//Good config.
+	p := SQLitePragmaConfig{
+		JournalMode: SQLiteJournalModeWAL,
+		Synchronous: SQLiteSynchronousNormal,
+		BusyTimeout: 5 * time.Second,
+		TempStore:   SQLiteTempStoreMemory,
+		AutoVacuum:  SQLiteAutoVacuumIncremental,
+	}
+	err := p.validate()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//Zero value is valid, nothing is set.
+	err = SQLitePragmaConfig{}.validate()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	//Bad values.
+	bad := []SQLitePragmaConfig{
+		{JournalMode: "wall"},
+		{Synchronous: "sometimes"},
+		{TempStore: "disk"},
+		{AutoVacuum: "partial"},
+		{BusyTimeout: -1},
+		{MmapSize: -1},
+	}
+	for _, b := range bad {
+		err = b.validate()
+		if err == nil {
+			t.Fatal("Error about invalid PRAGMA value should have occured.", b)
+			return
+		}
+	}
+}
+
+func TestSQLitePragmaConfigPragmas(t *testing.T) {
+	p := SQLitePragmaConfig{
+		JournalMode: SQLiteJournalModeWAL,
+		BusyTimeout: 2500 * time.Millisecond,
+		ForeignKeys: true,
+		CacheSize:   -2000,
+		MmapSize:    268435456,
+	}
+
+	got := p.pragmas()
+	expected := []string{
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA busy_timeout = 2500",
+		"PRAGMA foreign_keys = ON",
+		"PRAGMA cache_size = -2000",
+		"PRAGMA mmap_size = 268435456",
+	}
+	if strings.Join(got, ";") != strings.Join(expected, ";") {
+		t.Log("Got:", got)
+		t.Log("Exp:", expected)
+		t.Fatal("PRAGMAs not built correctly.")
+		return
+	}
+}
+
+func TestSQLitePragmasOverride(t *testing.T) {
+	c := NewSQLite("/path/to/sqlite.db")
+	c.SQLitePragmas = []string{
+		"PRAGMA busy_timeout = 5000",
+		"PRAGMA synchronous = NORMAL",
+	}
+	c.SQLitePragmaConfig.BusyTimeout = time.Second
+
+	got := c.sqlitePragmas()
+	expected := []string{
+		"PRAGMA synchronous = NORMAL",
+		"PRAGMA busy_timeout = 1000",
+	}
+	if strings.Join(got, ";") != strings.Join(expected, ";") {
+		t.Log("Got:", got)
+		t.Log("Exp:", expected)
+		t.Fatal("Typed PRAGMA did not override SQLitePragmas.")
+		return
+	}
+}
+
+func TestSQLitePragmaConfigURLValues(t *testing.T) {
+	p := SQLitePragmaConfig{
+		JournalMode: SQLiteJournalModeWAL,
+		TempStore:   SQLiteTempStoreMemory,
+	}
+
+	t.Run("mattn", func(t *testing.T) {
+		got := pragmasToURLValues(p.pragmas(), SQLiteLibraryMattn)
+
+		//temp_store isn't supported in the filepath by mattn.
+		expected := url.Values{}
+		expected.Add("_journal_mode", "wal")
+
+		if got.Encode() != expected.Encode() {
+			t.Log("Got:", got)
+			t.Log("Exp:", expected)
+			t.Fatal("mismatch for mattn library")
+			return
+		}
+
+		notInURL := pragmasNotInURL(p.pragmas(), SQLiteLibraryMattn)
+		if len(notInURL) != 1 || notInURL[0] != "PRAGMA temp_store = MEMORY" {
+			t.Fatal("temp_store should be run upon connecting for mattn library.", notInURL)
+			return
+		}
+	})
+
+	t.Run("modernc", func(t *testing.T) {
+		got := pragmasToURLValues(p.pragmas(), SQLiteLibraryModernc)
+
+		expected := url.Values{}
+		expected.Add("_pragma", "journal_mode=wal")
+		expected.Add("_pragma", "temp_store=memory")
+
+		if got.Encode() != expected.Encode() {
+			t.Log("Got:", got)
+			t.Log("Exp:", expected)
+			t.Fatal("mismatch for modernc library")
+			return
+		}
+
+		notInURL := pragmasNotInURL(p.pragmas(), SQLiteLibraryModernc)
+		if len(notInURL) != 0 {
+			t.Fatal("All PRAGMAs can be set via the filepath for modernc library.", notInURL)
+			return
+		}
+	})
+}
+
+func TestConnectSQLitePragmaConfig(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.SQLitePragmaConfig = SQLitePragmaConfig{
+		ForeignKeys: true,
+		TempStore:   SQLiteTempStoreMemory,
+	}
+
+	err := c.Connect()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	//Check on more than one connection in the pool since PRAGMAs are set
+	//per-connection.
+	c.Connection().SetMaxIdleConns(0)
+	for i := 0; i < 2; i++ {
+		var foreignKeys, tempStore int
+		err = c.Connection().Get(&foreignKeys, "PRAGMA foreign_keys")
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+		err = c.Connection().Get(&tempStore, "PRAGMA temp_store")
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+
+		if foreignKeys != 1 {
+			t.Fatal("PRAGMA foreign_keys not set.", foreignKeys)
+			return
+		}
+		if tempStore != 2 {
+			t.Fatal("PRAGMA temp_store not set.", tempStore)
+			return
+		}
+	}
+
+	//Test with an invalid value.
+	c.Close()
+	c.SQLitePragmaConfig.JournalMode = "bad"
+	err = c.Connect()
+	if err == nil {
+		t.Fatal("Error about invalid journal_mode should have occured.")
+		return
+	}
+}
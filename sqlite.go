@@ -12,11 +12,60 @@ type library string
 
 const (
 	//Possible SQLite libraries. These are used in comparisons, such as when building
-	//the connection string PRAGMAs.
-	sqliteLibraryMattn   library = "github.com/mattn/go-sqlite3"
-	sqliteLibraryModernc library = "modernc.org/sqlite"
+	//the connection string PRAGMAs, and to choose a library at runtime when more
+	//than one library is built into the binary. See the config SQLiteLibrary field.
+	SQLiteLibraryMattn   library = "github.com/mattn/go-sqlite3"
+	SQLiteLibraryModernc library = "modernc.org/sqlite"
+	SQLiteLibraryNcruces library = "github.com/ncruces/go-sqlite3"
 )
 
+// sqliteLibraryPriority is the order in which SQLite libraries are chosen as the
+// default library when more than one library is built into the binary and a config
+// does not choose a library.
+var sqliteLibraryPriority = []library{
+	SQLiteLibraryMattn,
+	SQLiteLibraryNcruces,
+	SQLiteLibraryModernc,
+}
+
+// sqliteDriver is the details about a SQLite library needed to use it.
+type sqliteDriver struct {
+	//driverName is the name the library registers itself as with [database/sql].
+	driverName string
+
+	//registerFuncs registers custom SQL functions and collations using the
+	//library's API. If the library registers functions per-connection, a
+	//connectHook is returned to run on each new connection.
+	registerFuncs func([]sqliteFunc, []sqliteCollation) (connectHook, error)
+
+	//namedInMemoryPath returns the path to a named, shared, in-memory database for
+	//libraries that do not support "file:name?mode=memory&cache=shared". Nil if the
+	//library supports shared-cache in-memory databases.
+	namedInMemoryPath func(name string) string
+
+	//errorCode returns the SQLite result code, the extended result code if
+	//available, from an error returned by the library. False is returned if the
+	//error isn't from the library. See Classify().
+	errorCode func(err error) (code int, ok bool)
+}
+
+// sqliteDrivers is the list of SQLite libraries built into the binary. This is
+// populated by the init() func in each sqlite-*.go file based upon build tags.
+var sqliteDrivers = make(map[library]sqliteDriver)
+
+// registerSQLiteLibrary saves a SQLite library as being built into the binary. This
+// is called in the init() func in each sqlite-*.go file.
+func registerSQLiteLibrary(lib library, d sqliteDriver) {
+	sqliteDrivers[lib] = d
+}
+
+// SQLiteLibrary returns a library. This is used when parsing a user-provided SQLite
+// library (such as from an external configuration file) to convert to a library
+// defined in this package.
+func SQLiteLibrary(s string) library {
+	return library(s)
+}
+
 const (
 	//SQLiteInMemoryFilepathRacy is the path to provide for SQLitePath when you want
 	//to use an in-memory database instead of a file on disk. This is racy because
@@ -24,13 +73,20 @@ const (
 	//Connect() once then this is safe to use.
 	//
 	//This is good for running tests since then each test runs with a separate
-	//in-memory db.
+	//in-memory db. However, each connection in the connection pool is also a
+	//separate in-memory db, so queries may not see data created with a different
+	//connection. Use SQLiteInMemoryFilepathNamed() instead.
 	SQLiteInMemoryFilepathRacy = ":memory:"
 
 	//SQLiteInMemoryFilepathRaceSafe is the path to provide for SQLitePath when you
 	//want to use an in-memory database instead of a file on disk. This is race safe
 	//since multiple calls of Connect() will connect to the same in-memory database,
 	//although connecting more than once to the same database would be very odd.
+	//
+	//Every config using this path shares the same database, so this is not safe for
+	//running tests in parallel. Use SQLiteInMemoryFilepathNamed() instead. For
+	//libraries without a shared cache (ncruces), this is connected to as a named
+	//memdb database so that each connection in the pool still sees the same data.
 	SQLiteInMemoryFilepathRaceSafe = "file::memory:?cache=shared"
 )
 
@@ -79,7 +135,7 @@ func IsSQLite() bool {
 // from your own connection.
 func GetSQLiteVersion() (version string, err error) {
 	//Get driver name based on SQLite library in use.
-	driver := getDriver(DBTypeSQLite)
+	driver := getDriver(DBTypeSQLite, defaultSQLiteLibrary())
 
 	//Connect.
 	conn, err := sqlx.Open(driver, SQLiteInMemoryFilepathRacy)
@@ -97,10 +153,50 @@ func GetSQLiteVersion() (version string, err error) {
 	return
 }
 
-// GetSQLiteLibrary returns the SQLite library that was used to build the binary. The
-// library is set at build/run with go build tags.
+// GetSQLiteLibrary returns the SQLite library used for a config. This is the
+// library chosen with the config's SQLiteLibrary field, or if no library was chosen,
+// the default library built into the binary. The libraries built into the binary are
+// set at build/run with go build tags.
+func (c *Config) GetSQLiteLibrary() library {
+	if c == nil || c.SQLiteLibrary == "" {
+		return defaultSQLiteLibrary()
+	}
+
+	return c.SQLiteLibrary
+}
+
+// GetSQLiteLibrary returns the SQLite library used for the package level config, or
+// if the package level config has not been set via Use() or does not choose a
+// library, the default library built into the binary.
 func GetSQLiteLibrary() library {
-	return sqliteLibrary
+	return cfg.GetSQLiteLibrary()
+}
+
+// SQLiteLibraries returns the list of SQLite libraries built into the binary, in the
+// order the default library is chosen. The libraries built into the binary are set
+// at build/run with go build tags.
+func SQLiteLibraries() (libs []library) {
+	for _, lib := range sqliteLibraryPriority {
+		if _, ok := sqliteDrivers[lib]; ok {
+			libs = append(libs, lib)
+		}
+	}
+
+	return
+}
+
+// defaultSQLiteLibrary returns the SQLite library used when a config does not choose
+// a library. This is the first library built into the binary, based upon
+// sqliteLibraryPriority.
+func defaultSQLiteLibrary() library {
+	libs := SQLiteLibraries()
+	if len(libs) == 0 {
+		//This can never happen since at least one sqlite-*.go file is always
+		//built based upon build tags.
+		return ""
+	}
+
+	return libs[0]
 }
 
 // pragmasToURLValues takes SQLite PRAGMAs in SQLite query format and retuns them in
@@ -109,14 +205,15 @@ func GetSQLiteLibrary() library {
 // SQLite PRAGMAs need to be set upon initially connecting to the database. The
 // PRAGMAs are added to the database's filepath as query parameters (?...&...).
 // However, the format of these appended query parameters differs between SQLite
-// libraries (mattn vs modernc). This func translates PRAGMA statements, written in
-// the SQLite query format, into the filepath format required by the SQLite driver
-// the binary is built with.
+// libraries (mattn vs modernc/ncruces). This func translates PRAGMA statements,
+// written in the SQLite query format, into the filepath format required by the
+// SQLite driver in use.
 //
 // Example:
 // - SQLite Query Format: "PRAGMA busy_timeout = 5000".
 // - Mattn Format:        "_busy_timeout=5000".
 // - Modernc: Format:     "_pragma=busy_timeout=5000".
+// - Ncruces: Format:     "_pragma=busy_timeout=5000".
 func pragmasToURLValues(pragmas []string, lib library) (v url.Values) {
 	v = url.Values{}
 
@@ -129,7 +226,7 @@ func pragmasToURLValues(pragmas []string, lib library) (v url.Values) {
 
 		//Build pragma key-value pairs as expected by driver/library in use.
 		switch lib {
-		case sqliteLibraryMattn:
+		case SQLiteLibraryMattn:
 			key, value, found := strings.Cut(p, "=")
 			if !found {
 				continue
@@ -138,10 +235,16 @@ func pragmasToURLValues(pragmas []string, lib library) (v url.Values) {
 			key = strings.TrimSpace(key)
 			value = strings.TrimSpace(value)
 
+			//Skip PRAGMAs the library doesn't read from the filepath. These are
+			//run upon connecting instead, see pragmasNotInURL().
+			if !isOneOf(key, mattnDSNPragmas) {
+				continue
+			}
+
 			key = "_" + key
 			v.Add(key, value)
 
-		case sqliteLibraryModernc:
+		case SQLiteLibraryModernc, SQLiteLibraryNcruces:
 			key := "_pragma"
 			value := p
 
@@ -157,3 +260,28 @@ func pragmasToURLValues(pragmas []string, lib library) (v url.Values) {
 
 	return
 }
+
+// pragmasNotInURL returns the PRAGMAs, in SQLite query format, that cannot be set
+// via the SQLite filepath for the given library. These PRAGMAs must be run on each
+// connection after it is opened instead.
+//
+// The [github.com/mattn/go-sqlite3] library only reads a specific list of PRAGMAs
+// from the filepath (see mattnDSNPragmas), whereas the [modernc.org/sqlite] library
+// runs any PRAGMA provided.
+func pragmasNotInURL(pragmas []string, lib library) (notInURL []string) {
+	if lib != SQLiteLibraryMattn {
+		return
+	}
+
+	for _, p := range pragmas {
+		if !strings.Contains(p, "=") {
+			continue
+		}
+
+		if !isOneOf(pragmaName(p), mattnDSNPragmas) {
+			notInURL = append(notInURL, p)
+		}
+	}
+
+	return
+}
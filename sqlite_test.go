@@ -37,7 +37,7 @@ func TestGetSQLiteVersion(t *testing.T) {
 func TestPragmasToURLValues(t *testing.T) {
 	//Test with mattn, single PRAGMA.
 	t.Run("mattn", func(t *testing.T) {
-		lib := sqliteLibraryMattn
+		lib := SQLiteLibraryMattn
 
 		pragmas := []string{
 			"PRAGMA busy_timeout = 5000",
@@ -57,7 +57,7 @@ func TestPragmasToURLValues(t *testing.T) {
 
 	//Test with mattn, multiple PRAGMAs.
 	t.Run("mattn-multi", func(t *testing.T) {
-		lib := sqliteLibraryMattn
+		lib := SQLiteLibraryMattn
 
 		pragmas := []string{
 			"PRAGMA busy_timeout = 5000",
@@ -80,7 +80,7 @@ func TestPragmasToURLValues(t *testing.T) {
 
 	//Test with modernc, single PRAGMA
 	t.Run("modernc", func(t *testing.T) {
-		lib := sqliteLibraryModernc
+		lib := SQLiteLibraryModernc
 
 		pragmas := []string{
 			"PRAGMA busy_timeout = 5000",
@@ -100,7 +100,7 @@ func TestPragmasToURLValues(t *testing.T) {
 
 	//Test with modernc, multiple PRAGMAs.
 	t.Run("modernc-multi", func(t *testing.T) {
-		lib := sqliteLibraryModernc
+		lib := SQLiteLibraryModernc
 
 		pragmas := []string{
 			"PRAGMA busy_timeout = 5000",
@@ -126,4 +126,118 @@ func TestPragmasToURLValues(t *testing.T) {
 			return
 		}
 	})
+
+	//Test with ncruces, single PRAGMA
+	t.Run("ncruces", func(t *testing.T) {
+		lib := SQLiteLibraryNcruces
+
+		pragmas := []string{
+			"PRAGMA busy_timeout = 5000",
+		}
+
+		got := pragmasToURLValues(pragmas, lib)
+
+		expected := url.Values{}
+		expected.Add("_pragma", "busy_timeout=5000")
+
+		if got.Encode() != expected.Encode() {
+			t.Log("Got:", got)
+			t.Log("Exp:", expected)
+			t.Fatal("mismatch for ncruces library")
+		}
+	})
+
+	//Test with ncruces, multiple PRAGMAs.
+	t.Run("ncruces-multi", func(t *testing.T) {
+		lib := SQLiteLibraryNcruces
+
+		pragmas := []string{
+			"PRAGMA busy_timeout = 5000",
+			"PRAGMA journal_mode = WAL",
+		}
+
+		got := pragmasToURLValues(pragmas, lib)
+
+		expected := url.Values{}
+		expected.Add("_pragma", "busy_timeout=5000")
+		expected.Add("_pragma", "journal_mode=wal")
+
+		if got.Encode() != expected.Encode() {
+			t.Log("Got:", got)
+			t.Log("Exp:", expected)
+			t.Fatal("mismatch for ncruces library")
+			return
+		}
+		if strings.Count(got.Encode(), "_pragma") != len(pragmas) {
+			t.Log("Got:", got.Encode())
+			t.Log("Exp:", expected.Encode())
+			t.Fatal("mismatch for ncruces library")
+			return
+		}
+	})
+}
+
+func TestSQLiteLibraries(t *testing.T) {
+	libs := SQLiteLibraries()
+	if len(libs) == 0 {
+		t.Fatal("No SQLite libraries built into binary.")
+		return
+	}
+	if defaultSQLiteLibrary() != libs[0] {
+		t.Fatal("Default SQLite library mismatch.", defaultSQLiteLibrary(), libs[0])
+		return
+	}
+
+	//Config without a library chosen uses the default.
+	c := NewSQLite(SQLiteInMemoryFilepathRacy)
+	if c.GetSQLiteLibrary() != defaultSQLiteLibrary() {
+		t.Fatal("Config should use default SQLite library.", c.GetSQLiteLibrary())
+		return
+	}
+
+	//Library not built into binary.
+	c.SQLiteLibrary = SQLiteLibrary("example.com/not/a/library")
+	err := c.validate()
+	if err == nil {
+		t.Fatal("Error about SQLite library not being built in should have occured.")
+		return
+	}
+}
+
+func TestConnectSQLiteLibrary(t *testing.T) {
+	//Connect with each library built into the binary. Build with -tags
+	//mattn,modernc to test more than one library.
+	for _, lib := range SQLiteLibraries() {
+		t.Run(string(lib), func(t *testing.T) {
+			c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+			c.SQLiteLibrary = lib
+			c.SQLitePragmas = []string{
+				"PRAGMA busy_timeout = 1234",
+			}
+
+			err := c.Connect()
+			if err != nil {
+				t.Fatal(err)
+				return
+			}
+			defer c.Close()
+
+			if c.GetSQLiteLibrary() != lib {
+				t.Fatal("SQLite library mismatch.", c.GetSQLiteLibrary(), lib)
+				return
+			}
+
+			//Make sure PRAGMAs were translated for the chosen library.
+			var busyTimeout int
+			err = c.Connection().Get(&busyTimeout, "PRAGMA busy_timeout")
+			if err != nil {
+				t.Fatal(err)
+				return
+			}
+			if busyTimeout != 1234 {
+				t.Fatal("PRAGMA busy_timeout not set for library.", lib, busyTimeout)
+				return
+			}
+		})
+	}
 }
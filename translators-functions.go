@@ -0,0 +1,531 @@
+package sqldb
+
+import (
+	"errors"
+	"strings"
+)
+
+/*
+This file handles translating SQL functions and clauses (ex.: NOW(), IFNULL(),
+LIMIT) from one database dialect to another. Unlike the CREATE TABLE translators,
+these translators are meant for any query, typically runtime queries run with DB().
+
+Some constructs cannot be translated (ex.: GROUP_CONCAT(DISTINCT ...) for MSSQL).
+Instead of returning the query as-is, which would just result in a confusing error
+from the database, or worse, an incorrect result, a *TranslationError is returned.
+Therefore these translators are CheckedTranslators, not Translators.
+*/
+
+// ErrUnsupportedTranslation is returned when a translator is not available for the
+// given source and target database types.
+var ErrUnsupportedTranslation = errors.New("sqldb: unsupported translation")
+
+// TranslationError is returned when a query contains a construct that cannot be
+// translated to the target database type.
+type TranslationError struct {
+	From, To  dbType
+	Construct string //the function or clause that could not be translated, ex.: GROUP_CONCAT.
+	Reason    string
+	Query     string //the query being translated, set by RunTranslators().
+}
+
+// Error implements the error interface.
+func (e *TranslationError) Error() string {
+	return "sqldb: cannot translate " + e.Construct + " from " + string(e.From) + " to " + string(e.To) + ", " + e.Reason
+}
+
+// RunTranslators runs each translator, in order, on a query and returns the
+// translated query. If a translator returns an error, such as a *TranslationError
+// when the query cannot be translated (see FunctionTranslator), the error is returned
+// along with the query as-is.
+func RunTranslators(query string, translators []QueryTranslator) (out string, err error) {
+	out = query
+	for _, t := range translators {
+		out, err = t.TranslateQuery(out)
+		if err != nil {
+			var te *TranslationError
+			if errors.As(err, &te) && te.Query == "" {
+				te.Query = query
+			}
+
+			return query, err
+		}
+	}
+
+	return
+}
+
+// FunctionTranslator returns a CheckedTranslator that translates SQL functions and
+// clauses from one database type to another. This is meant for runtime queries, used
+// with RuntimeQueryCheckedTranslators, but can also be used with
+// DeployQueryCheckedTranslators and UpdateQueryCheckedTranslators. Use Translator()
+// on the returned CheckedTranslator to use it as a Translator.
+//
+// Supported translations:
+//   - MariaDB/MySQL to SQLite: NOW(), UTC_TIMESTAMP(), CURDATE(), IFNULL(), CONCAT(),
+//     and GROUP_CONCAT(). Note that SQLite has no time zone, so NOW() returns UTC.
+//   - MariaDB/MySQL to MSSQL: the above, plus LIMIT and OFFSET to OFFSET...FETCH.
+//   - SQLite to MariaDB/MySQL: datetime('now'), date('now'), and group_concat().
+//
+// Column names, string literals, and comments are never modified, except that
+// backslashes in strings are escaped when translating from SQLite to MariaDB/MySQL.
+//
+// If a query contains a construct that cannot be translated, the returned
+// CheckedTranslator returns a *TranslationError which is also returned by
+// RunTranslators(), DeploySchema(), UpdateSchema(), and the methods of TranslatedDB.
+//
+// ErrUnsupportedTranslation is returned if the translation is not supported. If from
+// and to are the same, a CheckedTranslator that returns queries as-is is returned.
+func FunctionTranslator(from, to dbType) (t CheckedTranslator, err error) {
+	if from == to || (isMariaDBOrMySQL(from) && isMariaDBOrMySQL(to)) {
+		t = func(in string) (string, error) { return in, nil }
+		return
+	}
+
+	var ct *callTranslator
+	switch {
+	case isMariaDBOrMySQL(from) && to == DBTypeSQLite:
+		ct = &callTranslator{calls: mariaDBCallsToSQLite}
+	case isMariaDBOrMySQL(from) && to == DBTypeMSSQL:
+		ct = &callTranslator{calls: mariaDBCallsToMSSQL, words: mariaDBWordsToMSSQL, offsetFetch: true}
+	case from == DBTypeSQLite && isMariaDBOrMySQL(to):
+		ct = &callTranslator{calls: sqliteCallsToMariaDB, noConcatOperator: true}
+	default:
+		err = ErrUnsupportedTranslation
+		return
+	}
+
+	ct.from = from
+	ct.to = to
+
+	t = CheckedTokenTranslatorDialect(from, func(stmt []Token) ([]Token, error) {
+		//Use a copy so that the error from translating one query isn't shared with
+		//other queries being translated at the same time.
+		run := *ct
+		out := run.translate(stmt)
+		if run.err != nil {
+			return stmt, run.err
+		}
+
+		//Escape backslashes in strings since MariaDB treats a backslash as an
+		//escape character but SQLite does not.
+		if from == DBTypeSQLite {
+			for i, tok := range out {
+				if tok.Kind == TokenString && strings.Contains(tok.Text, `\`) {
+					out[i].Text = strings.ReplaceAll(tok.Text, `\`, `\\`)
+				}
+			}
+		}
+
+		return out, nil
+	})
+	return
+}
+
+// isMariaDBOrMySQL returns true if t is MariaDB or MySQL. These are treated as the
+// same dialect for translating.
+func isMariaDBOrMySQL(t dbType) bool {
+	return t == DBTypeMariaDB || t == DBTypeMySQL
+}
+
+// callRewrite returns the text to replace a function call with given the function's
+// arguments. Each argument has already been translated.
+type callRewrite func(ct *callTranslator, args [][]Token) string
+
+// callTranslator translates function calls, and some clauses, from one database type
+// to another.
+type callTranslator struct {
+	from, to dbType
+
+	//calls are the functions to translate, keyed by the uppercased function name.
+	calls map[string]callRewrite
+
+	//words are bare words, not function calls, to translate keyed by the uppercased
+	//word (ex.: UTC_TIMESTAMP without parenthesis).
+	words map[string]string
+
+	//offsetFetch translates LIMIT to OFFSET...FETCH.
+	offsetFetch bool
+
+	//noConcatOperator reports an error for the || operator since it means OR in
+	//MariaDB/MySQL by default.
+	noConcatOperator bool
+
+	//err is the first construct that could not be translated, see fail().
+	err *TranslationError
+}
+
+// fail records that a construct cannot be translated. Only the first construct is
+// recorded. Translating stops once an error is recorded, however, the caller must
+// still return since the arguments may not be valid.
+func (ct *callTranslator) fail(construct, reason string) {
+	if ct.err != nil {
+		return
+	}
+
+	ct.err = &TranslationError{
+		From:      ct.from,
+		To:        ct.to,
+		Construct: construct,
+		Reason:    reason,
+	}
+}
+
+// translate translates the function calls and clauses in tokens. Parenthesized
+// expressions, including function arguments and subqueries, are translated
+// recursively.
+func (ct *callTranslator) translate(tokens []Token) (out []Token) {
+	first := nextSignificant(tokens, 0)
+	isSelect := first < len(tokens) && tokens[first].Is("SELECT", "WITH")
+	orderBy := false
+
+	for i := 0; i < len(tokens) && ct.err == nil; i++ {
+		t := tokens[i]
+
+		switch {
+		case t.IsPunctuation("("):
+			close := matchingParen(tokens, i)
+			if close == -1 {
+				break
+			}
+
+			out = append(out, t)
+			out = append(out, ct.translate(tokens[i+1:close])...)
+			out = append(out, tokens[close])
+			i = close
+			continue
+
+		case t.IsPunctuation("||") && ct.noConcatOperator:
+			ct.fail("||", "use CONCAT() instead")
+			return
+
+		case t.IsWord():
+			word := strings.ToUpper(t.Text)
+
+			if rewrite, ok := ct.calls[word]; ok {
+				open := nextSignificant(tokens, i+1)
+				if open < len(tokens) && tokens[open].IsPunctuation("(") {
+					if close := matchingParen(tokens, open); close != -1 {
+						args := splitArgs(tokens[open+1 : close])
+						for j := range args {
+							args[j] = ct.translate(args[j])
+						}
+
+						out = append(out, TokenizeDialect(ct.from, rewrite(ct, args))...)
+						i = close
+						continue
+					}
+				}
+			}
+
+			if text, ok := ct.words[word]; ok {
+				out = append(out, TokenizeDialect(ct.from, text)...)
+				continue
+			}
+
+			if matchWords(tokens, i, "ORDER", "BY") != -1 {
+				orderBy = true
+			}
+
+			if word == "LIMIT" && ct.offsetFetch {
+				end, text := ct.limitToOffsetFetch(tokens, i, isSelect, orderBy)
+				out = append(out, TokenizeDialect(ct.from, text)...)
+				i = end - 1
+				continue
+			}
+		}
+
+		out = append(out, t)
+	}
+
+	return
+}
+
+// limitToOffsetFetch translates the LIMIT clause starting at i to an OFFSET...FETCH
+// clause. Both the LIMIT count OFFSET offset and LIMIT offset, count forms are handled. The
+// index after the LIMIT clause is returned.
+func (ct *callTranslator) limitToOffsetFetch(tokens []Token, i int, isSelect, orderBy bool) (end int, text string) {
+	end = len(tokens)
+	if !isSelect {
+		ct.fail("LIMIT", "LIMIT is only supported in a SELECT query")
+		return
+	}
+	if !orderBy {
+		ct.fail("LIMIT", "OFFSET...FETCH requires an ORDER BY clause")
+		return
+	}
+
+	//value returns the LIMIT or OFFSET value at j.
+	value := func(j int) (Token, int) {
+		j = nextSignificant(tokens, j)
+		if j >= len(tokens) || (tokens[j].Kind != TokenNumber && tokens[j].Kind != TokenPlaceholder) {
+			ct.fail("LIMIT", "LIMIT values must be numbers or placeholders")
+			return Token{}, len(tokens)
+		}
+		return tokens[j], j + 1
+	}
+
+	count, end := value(i + 1)
+	offset := Token{Kind: TokenNumber, Text: "0"}
+	if ct.err != nil {
+		return
+	}
+
+	next := nextSignificant(tokens, end)
+	switch {
+	case next < len(tokens) && tokens[next].IsPunctuation(","):
+		//LIMIT offset, count. The order of the values is the same as OFFSET...FETCH
+		//so any placeholders are still in the correct order.
+		offset = count
+		count, end = value(next + 1)
+		if ct.err != nil {
+			return
+		}
+
+	case next < len(tokens) && tokens[next].Is("OFFSET"):
+		//LIMIT count OFFSET offset. If both values are placeholders, the order of the
+		//bindvars would need to be swapped.
+		offset, end = value(next + 1)
+		if ct.err != nil {
+			return
+		}
+		if count.Kind == TokenPlaceholder && offset.Kind == TokenPlaceholder {
+			ct.fail("LIMIT ? OFFSET ?", "the placeholders would be out of order, use LIMIT ?, ? instead")
+			return
+		}
+	}
+
+	text = "OFFSET " + offset.Text + " ROWS FETCH NEXT " + count.Text + " ROWS ONLY"
+	return
+}
+
+// splitArgs splits the tokens between a function's parenthesis into arguments. The
+// whitespace around each argument is removed.
+func splitArgs(tokens []Token) (args [][]Token) {
+	if nextSignificant(tokens, 0) >= len(tokens) {
+		return
+	}
+
+	start := 0
+	for i := 0; i < len(tokens); i++ {
+		switch {
+		case tokens[i].IsPunctuation("("):
+			if close := matchingParen(tokens, i); close != -1 {
+				i = close
+			}
+		case tokens[i].IsPunctuation(","):
+			args = append(args, trimTokens(tokens[start:i]))
+			start = i + 1
+		}
+	}
+
+	args = append(args, trimTokens(tokens[start:]))
+	return
+}
+
+// trimTokens removes leading and trailing whitespace tokens.
+func trimTokens(tokens []Token) []Token {
+	for len(tokens) > 0 && tokens[0].Kind == TokenWhitespace {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].Kind == TokenWhitespace {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	return tokens
+}
+
+// joinArgs joins translated function arguments with sep.
+func joinArgs(args [][]Token, sep string) string {
+	s := make([]string, len(args))
+	for i, a := range args {
+		s[i] = JoinTokens(a)
+	}
+
+	return strings.Join(s, sep)
+}
+
+// noArgs returns a callRewrite that replaces a function that takes no arguments.
+func noArgs(name, text string) callRewrite {
+	return func(ct *callTranslator, args [][]Token) string {
+		if len(args) > 0 {
+			ct.fail(name+"()", "arguments are not supported")
+			return ""
+		}
+		return text
+	}
+}
+
+// coalesce translates IFNULL() to COALESCE().
+func coalesce(ct *callTranslator, args [][]Token) string {
+	return "COALESCE(" + joinArgs(args, ", ") + ")"
+}
+
+// groupConcat is a parsed GROUP_CONCAT() call.
+type groupConcat struct {
+	distinct  bool
+	exprs     [][]Token
+	orderBy   string
+	separator string
+}
+
+// parseGroupConcat parses the arguments of a GROUP_CONCAT() call, ex.:
+// GROUP_CONCAT(DISTINCT Name ORDER BY Name SEPARATOR ';').
+func parseGroupConcat(ct *callTranslator, args [][]Token) (g groupConcat) {
+	if len(args) == 0 {
+		ct.fail("GROUP_CONCAT()", "an expression is required")
+		return
+	}
+
+	//DISTINCT is before the first expression.
+	if j := matchWords(args[0], 0, "DISTINCT"); j != -1 {
+		g.distinct = true
+		args[0] = trimTokens(args[0][j:])
+	}
+
+	//SEPARATOR is always last.
+	g.separator = "','"
+	last := len(args) - 1
+	if s := findWord(args[last], 0, "SEPARATOR"); s != -1 {
+		g.separator = JoinTokens(trimTokens(args[last][s+1:]))
+		args[last] = trimTokens(args[last][:s])
+	}
+
+	//ORDER BY is after the expressions. The ORDER BY clause can include commas so
+	//it may span multiple arguments.
+	for i, a := range args {
+		if o := findWord(a, 0, "ORDER"); o != -1 && matchWords(a, o, "ORDER", "BY") != -1 {
+			g.exprs = append(g.exprs, trimTokens(a[:o]))
+			g.orderBy = joinArgs(append([][]Token{a[o:]}, args[i+1:]...), ", ")
+			break
+		}
+		g.exprs = append(g.exprs, a)
+	}
+
+	return
+}
+
+// mariaDBCallsToSQLite are the MariaDB functions translated for SQLite.
+var mariaDBCallsToSQLite = map[string]callRewrite{
+	"NOW":               noArgs("NOW", "datetime('now')"),
+	"CURRENT_TIMESTAMP": noArgs("CURRENT_TIMESTAMP", "datetime('now')"),
+	"UTC_TIMESTAMP":     noArgs("UTC_TIMESTAMP", "datetime('now')"),
+	"CURDATE":           noArgs("CURDATE", "date('now')"),
+	"UTC_DATE":          noArgs("UTC_DATE", "date('now')"),
+	"IFNULL":            coalesce,
+	"CONCAT": func(ct *callTranslator, args [][]Token) string {
+		if len(args) == 0 {
+			ct.fail("CONCAT()", "at least one argument is required")
+			return ""
+		}
+		return "(" + joinArgs(args, " || ") + ")"
+	},
+	"GROUP_CONCAT": func(ct *callTranslator, args [][]Token) string {
+		g := parseGroupConcat(ct, args)
+		if ct.err != nil {
+			return ""
+		}
+		if g.orderBy != "" {
+			ct.fail("GROUP_CONCAT(... ORDER BY)", "ORDER BY is not supported")
+			return ""
+		}
+
+		expr := joinArgs(g.exprs, " || ")
+		if g.distinct {
+			if g.separator != "','" {
+				ct.fail("GROUP_CONCAT(DISTINCT ... SEPARATOR)", "DISTINCT cannot be used with a separator")
+				return ""
+			}
+			return "group_concat(DISTINCT " + expr + ")"
+		}
+
+		return "group_concat(" + expr + ", " + g.separator + ")"
+	},
+}
+
+// mariaDBCallsToMSSQL are the MariaDB functions translated for MSSQL.
+var mariaDBCallsToMSSQL = map[string]callRewrite{
+	"NOW":               noArgs("NOW", "GETDATE()"),
+	"CURRENT_TIMESTAMP": noArgs("CURRENT_TIMESTAMP", "GETDATE()"),
+	"UTC_TIMESTAMP":     noArgs("UTC_TIMESTAMP", "SYSUTCDATETIME()"),
+	"CURDATE":           noArgs("CURDATE", "CAST(GETDATE() AS DATE)"),
+	"UTC_DATE":          noArgs("UTC_DATE", "CAST(SYSUTCDATETIME() AS DATE)"),
+	"IFNULL":            coalesce,
+	"CONCAT": func(ct *callTranslator, args [][]Token) string {
+		switch len(args) {
+		case 0:
+			ct.fail("CONCAT()", "at least one argument is required")
+			return ""
+		case 1:
+			//MSSQL requires at least two arguments.
+			return JoinTokens(args[0])
+		}
+		return "CONCAT(" + joinArgs(args, ", ") + ")"
+	},
+	"GROUP_CONCAT": func(ct *callTranslator, args [][]Token) string {
+		g := parseGroupConcat(ct, args)
+		if ct.err != nil {
+			return ""
+		}
+		if g.distinct {
+			ct.fail("GROUP_CONCAT(DISTINCT ...)", "STRING_AGG() does not support DISTINCT")
+			return ""
+		}
+
+		expr := JoinTokens(g.exprs[0])
+		if len(g.exprs) > 1 {
+			expr = "CONCAT(" + joinArgs(g.exprs, ", ") + ")"
+		}
+
+		s := "STRING_AGG(" + expr + ", " + g.separator + ")"
+		if g.orderBy != "" {
+			s += " WITHIN GROUP (" + g.orderBy + ")"
+		}
+		return s
+	},
+}
+
+// mariaDBWordsToMSSQL are the bare MariaDB words translated for MSSQL.
+var mariaDBWordsToMSSQL = map[string]string{
+	"UTC_TIMESTAMP": "SYSUTCDATETIME()",
+}
+
+// sqliteCallsToMariaDB are the SQLite functions translated for MariaDB.
+var sqliteCallsToMariaDB = map[string]callRewrite{
+	"DATETIME": func(ct *callTranslator, args [][]Token) string {
+		return sqliteNowToMariaDB(ct, "datetime", args, "UTC_TIMESTAMP()", "NOW()")
+	},
+	"DATE": func(ct *callTranslator, args [][]Token) string {
+		return sqliteNowToMariaDB(ct, "date", args, "UTC_DATE()", "CURDATE()")
+	},
+	"GROUP_CONCAT": func(ct *callTranslator, args [][]Token) string {
+		switch len(args) {
+		case 1:
+			return "GROUP_CONCAT(" + JoinTokens(args[0]) + ")"
+		case 2:
+			return "GROUP_CONCAT(" + JoinTokens(args[0]) + " SEPARATOR " + JoinTokens(args[1]) + ")"
+		}
+
+		ct.fail("group_concat()", "one or two arguments are required")
+		return ""
+	},
+}
+
+// sqliteNowToMariaDB translates the SQLite datetime('now') and date('now')
+// functions, with an optional 'localtime' modifier, to MariaDB. Other uses of these
+// functions cannot be translated.
+func sqliteNowToMariaDB(ct *callTranslator, name string, args [][]Token, utc, local string) string {
+	isString := func(a []Token, s string) bool {
+		return len(a) == 1 && a[0].Kind == TokenString && strings.EqualFold(a[0].Unquoted(), s)
+	}
+
+	switch {
+	case len(args) == 1 && isString(args[0], "now"):
+		return utc
+	case len(args) == 2 && isString(args[0], "now") && isString(args[1], "localtime"):
+		return local
+	}
+
+	ct.fail(name+"()", "only "+name+"('now') and "+name+"('now', 'localtime') are supported")
+	return ""
+}
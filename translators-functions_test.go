@@ -0,0 +1,295 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFunctionTranslator(t *testing.T) {
+	tt := []struct {
+		name     string
+		from     dbType
+		to       dbType
+		query    string
+		expected string
+	}{
+		{
+			name:     "mariadb to sqlite, now",
+			from:     DBTypeMariaDB,
+			to:       DBTypeSQLite,
+			query:    "SELECT NOW(), UTC_TIMESTAMP(), CURDATE(), DateNow FROM t WHERE Note = 'NOW()'",
+			expected: "SELECT datetime('now'), datetime('now'), date('now'), DateNow FROM t WHERE Note = 'NOW()'",
+		},
+		{
+			name:     "mariadb to sqlite, ifnull and concat",
+			from:     DBTypeMySQL,
+			to:       DBTypeSQLite,
+			query:    "SELECT CONCAT(Fname, ' ', IFNULL(Lname, '')) FROM users",
+			expected: "SELECT (Fname || ' ' || COALESCE(Lname, '')) FROM users",
+		},
+		{
+			name:     "mariadb to sqlite, group_concat",
+			from:     DBTypeMariaDB,
+			to:       DBTypeSQLite,
+			query:    "SELECT GROUP_CONCAT(Name SEPARATOR ';'), group_concat(DISTINCT Name) FROM users",
+			expected: "SELECT group_concat(Name, ';'), group_concat(DISTINCT Name) FROM users",
+		},
+		{
+			name:     "mariadb to sqlite, limit",
+			from:     DBTypeMariaDB,
+			to:       DBTypeSQLite,
+			query:    "SELECT * FROM users LIMIT 10 OFFSET 20",
+			expected: "SELECT * FROM users LIMIT 10 OFFSET 20",
+		},
+		{
+			name:     "mariadb to mssql, functions",
+			from:     DBTypeMariaDB,
+			to:       DBTypeMSSQL,
+			query:    "SELECT NOW(), UTC_TIMESTAMP, CONCAT(Fname, ' ', Lname), IFNULL(Age, 0) FROM users",
+			expected: "SELECT GETDATE(), SYSUTCDATETIME(), CONCAT(Fname, ' ', Lname), COALESCE(Age, 0) FROM users",
+		},
+		{
+			name:     "mariadb to mssql, group_concat",
+			from:     DBTypeMariaDB,
+			to:       DBTypeMSSQL,
+			query:    "SELECT GROUP_CONCAT(Name ORDER BY Name, ID DESC SEPARATOR ', ') FROM users",
+			expected: "SELECT STRING_AGG(Name, ', ') WITHIN GROUP (ORDER BY Name, ID DESC) FROM users",
+		},
+		{
+			name:     "mariadb to mssql, limit offset",
+			from:     DBTypeMariaDB,
+			to:       DBTypeMSSQL,
+			query:    "SELECT * FROM users ORDER BY ID LIMIT 10 OFFSET ?",
+			expected: "SELECT * FROM users ORDER BY ID OFFSET ? ROWS FETCH NEXT 10 ROWS ONLY",
+		},
+		{
+			name:     "mariadb to mssql, limit in subquery",
+			from:     DBTypeMariaDB,
+			to:       DBTypeMSSQL,
+			query:    "SELECT * FROM (SELECT * FROM users ORDER BY ID LIMIT ?, ?) AS u",
+			expected: "SELECT * FROM (SELECT * FROM users ORDER BY ID OFFSET ? ROWS FETCH NEXT ? ROWS ONLY) AS u",
+		},
+		{
+			name:     "sqlite to mariadb",
+			from:     DBTypeSQLite,
+			to:       DBTypeMariaDB,
+			query:    "SELECT datetime('now'), datetime('now', 'localtime'), date('now'), group_concat(Name, ';') FROM users",
+			expected: "SELECT UTC_TIMESTAMP(), NOW(), UTC_DATE(), GROUP_CONCAT(Name SEPARATOR ';') FROM users",
+		},
+		{
+			name:     "sqlite to mariadb, backslash in string",
+			from:     DBTypeSQLite,
+			to:       DBTypeMariaDB,
+			query:    "SELECT group_concat(Path, '\\') FROM files WHERE Path = 'C:\\' AND Created < datetime('now')",
+			expected: "SELECT GROUP_CONCAT(Path SEPARATOR '\\\\') FROM files WHERE Path = 'C:\\\\' AND Created < UTC_TIMESTAMP()",
+		},
+		{
+			name:     "same dialect",
+			from:     DBTypeMariaDB,
+			to:       DBTypeMySQL,
+			query:    "SELECT NOW()",
+			expected: "SELECT NOW()",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tr, err := FunctionTranslator(tc.from, tc.to)
+			if err != nil {
+				t.Fatal(err)
+				return
+			}
+
+			got, err := RunTranslators(tc.query, []QueryTranslator{tr})
+			if err != nil {
+				t.Fatal(err)
+				return
+			}
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad translation.")
+				return
+			}
+		})
+	}
+}
+
+func TestFunctionTranslatorErrors(t *testing.T) {
+	tt := []struct {
+		name  string
+		from  dbType
+		to    dbType
+		query string
+	}{
+		{
+			name:  "limit without order by",
+			from:  DBTypeMariaDB,
+			to:    DBTypeMSSQL,
+			query: "SELECT * FROM users LIMIT 10",
+		},
+		{
+			name:  "limit placeholders out of order",
+			from:  DBTypeMariaDB,
+			to:    DBTypeMSSQL,
+			query: "SELECT * FROM users ORDER BY ID LIMIT ? OFFSET ?",
+		},
+		{
+			name:  "limit in delete",
+			from:  DBTypeMariaDB,
+			to:    DBTypeMSSQL,
+			query: "DELETE FROM users ORDER BY ID LIMIT 10",
+		},
+		{
+			name:  "limit without value",
+			from:  DBTypeMariaDB,
+			to:    DBTypeMSSQL,
+			query: "SELECT * FROM users ORDER BY ID LIMIT",
+		},
+		{
+			name:  "group_concat without arguments",
+			from:  DBTypeMariaDB,
+			to:    DBTypeMSSQL,
+			query: "SELECT GROUP_CONCAT() FROM users",
+		},
+		{
+			name:  "group_concat distinct",
+			from:  DBTypeMariaDB,
+			to:    DBTypeMSSQL,
+			query: "SELECT GROUP_CONCAT(DISTINCT Name) FROM users",
+		},
+		{
+			name:  "group_concat order by",
+			from:  DBTypeMariaDB,
+			to:    DBTypeSQLite,
+			query: "SELECT GROUP_CONCAT(Name ORDER BY Name) FROM users",
+		},
+		{
+			name:  "now with precision",
+			from:  DBTypeMariaDB,
+			to:    DBTypeSQLite,
+			query: "SELECT NOW(3)",
+		},
+		{
+			name:  "concat operator",
+			from:  DBTypeSQLite,
+			to:    DBTypeMariaDB,
+			query: "SELECT Fname || Lname FROM users",
+		},
+		{
+			name:  "datetime modifiers",
+			from:  DBTypeSQLite,
+			to:    DBTypeMariaDB,
+			query: "SELECT datetime('now', '+1 day')",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tr, err := FunctionTranslator(tc.from, tc.to)
+			if err != nil {
+				t.Fatal(err)
+				return
+			}
+
+			got, err := RunTranslators(tc.query, []QueryTranslator{tr})
+			var te *TranslationError
+			if !errors.As(err, &te) {
+				t.Fatal("TranslationError should have occured.", got, err)
+				return
+			}
+			if te.Query != tc.query || got != tc.query {
+				t.Fatal("Query should be returned as-is.", te.Query, got)
+				return
+			}
+
+			//Calling the translator directly returns the error too.
+			got, err = tr(tc.query)
+			if !errors.As(err, &te) || got != tc.query {
+				t.Fatal("TranslationError should have occured.", got, err)
+				return
+			}
+		})
+	}
+
+	//Unsupported translation.
+	_, err := FunctionTranslator(DBTypeMSSQL, DBTypeSQLite)
+	if err != ErrUnsupportedTranslation {
+		t.Fatal("ErrUnsupportedTranslation should have occured.", err)
+		return
+	}
+}
+
+func TestRunTranslatorsMixed(t *testing.T) {
+	functions, err := FunctionTranslator(DBTypeMariaDB, DBTypeSQLite)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	translators := []QueryTranslator{
+		Translator(TranslateMariaDBToSQLite),
+		functions,
+	}
+
+	got, err := RunTranslators("CREATE TABLE t (Created DATETIME DEFAULT NOW())", translators)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	expected := "CREATE TABLE t (Created TEXT DEFAULT datetime('now'))"
+	if got != expected {
+		t.Log("Got:", got)
+		t.Log("Exp:", expected)
+		t.Fatal("Bad translation.")
+		return
+	}
+}
+
+func TestDeploySchemaTranslationError(t *testing.T) {
+	tr, err := FunctionTranslator(DBTypeSQLite, DBTypeMariaDB)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueries = []string{"CREATE TABLE users (Name TEXT DEFAULT ('a' || 'b'))"}
+	c.DeployQueryCheckedTranslators = []CheckedTranslator{tr}
+
+	err = c.DeploySchema(nil)
+	var te *TranslationError
+	if !errors.As(err, &te) {
+		t.Fatal("TranslationError should have occured.", err)
+		return
+	}
+	if c.Connected() {
+		t.Fatal("Connection should be closed after translation error.")
+		return
+	}
+}
+
+func TestCheckedTranslatorTranslator(t *testing.T) {
+	tr, err := FunctionTranslator(DBTypeSQLite, DBTypeMariaDB)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	in := "SELECT 'a' || 'b'"
+	if got := tr.Translator()(in); got != in {
+		t.Log("Got:", got)
+		t.Log("Exp:", in)
+		t.Fatal("Untranslatable query should be returned as-is.")
+		return
+	}
+
+	in = "SELECT datetime('now')"
+	expected := "SELECT UTC_TIMESTAMP()"
+	if got := tr.Translator()(in); got != expected {
+		t.Log("Got:", got)
+		t.Log("Exp:", expected)
+		t.Fatal("Bad translation.")
+		return
+	}
+}
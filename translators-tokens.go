@@ -0,0 +1,892 @@
+package sqldb
+
+import (
+	"strings"
+)
+
+/*
+This file has helpers for writing Translators that work on tokens instead of
+strings. These helpers find the parts of CREATE TABLE and ALTER TABLE queries, such as
+column names and data types, so that Translators can modify only the parts of a query
+that need to be changed.
+*/
+
+// TokenTranslator returns a Translator that tokenizes a query, calls f with the tokens
+// of each statement in the query, and joins the returned tokens back into a query.
+// Use this to write Translators that don't modify string literals, comments, or
+// identifiers by mistake.
+func TokenTranslator(f func(statement []Token) []Token) Translator {
+	return func(query string) string {
+		var out []Token
+		for _, stmt := range splitStatements(Tokenize(query)) {
+			out = append(out, f(stmt)...)
+		}
+
+		return JoinTokens(out)
+	}
+}
+
+// CheckedTokenTranslator returns a CheckedTranslator that tokenizes a query, calls f
+// with the tokens of each statement in the query, and joins the returned tokens back
+// into a query. If f returns an error for any statement, the error is returned along
+// with the query as-is. See TokenTranslator.
+func CheckedTokenTranslator(f func(statement []Token) ([]Token, error)) CheckedTranslator {
+	return CheckedTokenTranslatorDialect(DBTypeMariaDB, f)
+}
+
+// CheckedTokenTranslatorDialect is the same as CheckedTokenTranslator but tokenizes
+// queries written for the database type t. Use this when translating from a database
+// other than MariaDB or MySQL so that backslashes in strings and # are handled
+// correctly, see TokenizeDialect.
+func CheckedTokenTranslatorDialect(t dbType, f func(statement []Token) ([]Token, error)) CheckedTranslator {
+	return func(query string) (string, error) {
+		var out []Token
+		for _, stmt := range splitStatements(TokenizeDialect(t, query)) {
+			translated, err := f(stmt)
+			if err != nil {
+				return query, err
+			}
+			out = append(out, translated...)
+		}
+
+		return JoinTokens(out), nil
+	}
+}
+
+// splitStatements splits tokens into statements at each semicolon not within
+// parenthesis or a BEGIN...END block (ex.: the body of a trigger). The semicolon is
+// kept at the end of each statement so that joining the statements results in the
+// original tokens.
+func splitStatements(tokens []Token) (statements [][]Token) {
+	depth := 0
+	block := 0
+	start := 0
+	endCase := -1
+	for i, t := range tokens {
+		switch {
+		case t.IsPunctuation("("):
+			depth++
+		case t.IsPunctuation(")"):
+			depth--
+
+		//CASE...END is counted since a CASE can be used within a BEGIN...END block.
+		case t.Is("BEGIN") && depth <= 0 && !isTransactionBegin(tokens, i):
+			block++
+		case t.Is("CASE") && i != endCase:
+			block++
+		case t.Is("END") && block > 0:
+			//END IF, END WHILE, etc. end a block that wasn't counted. END CASE ends a
+			//CASE statement, the CASE is skipped so it isn't counted as a new CASE.
+			next := nextSignificant(tokens, i+1)
+			if next < len(tokens) && tokens[next].Is("IF", "WHILE", "LOOP", "REPEAT") {
+				break
+			}
+			if next < len(tokens) && tokens[next].Is("CASE") {
+				endCase = next
+			}
+			block--
+
+		case t.IsPunctuation(";") && depth <= 0 && block == 0:
+			statements = append(statements, tokens[start:i+1])
+			start = i + 1
+		}
+	}
+
+	if start < len(tokens) {
+		statements = append(statements, tokens[start:])
+	}
+
+	return
+}
+
+// isTransactionBegin returns true if the BEGIN at i starts a transaction (ex.: BEGIN;
+// or BEGIN TRANSACTION;) rather than a BEGIN...END block.
+func isTransactionBegin(tokens []Token, i int) bool {
+	next := nextSignificant(tokens, i+1)
+	return next >= len(tokens) ||
+		tokens[next].IsPunctuation(";") ||
+		tokens[next].Is("TRANSACTION", "TRAN", "WORK", "DEFERRED", "IMMEDIATE", "EXCLUSIVE", "DISTRIBUTED")
+}
+
+// SplitStatements splits a query into individual statements at each semicolon. A
+// semicolon within a string, comment, parenthesis, or BEGIN...END block (ex.: the
+// body of a trigger) does not split the query. Statements that are only whitespace
+// or comments are omitted and whitespace around each statement is removed.
+//
+// The query is tokenized as MariaDB/MySQL does, see Tokenize(). Use
+// SplitStatementsDialect() for queries written for another database type.
+func SplitStatements(query string) (statements []string) {
+	return SplitStatementsDialect(DBTypeMariaDB, query)
+}
+
+// SplitStatementsDialect splits a query, written for the database type t, into
+// individual statements the same as SplitStatements(). See TokenizeDialect().
+//
+// This is used in DeploySchema() and UpdateSchema() to run each statement of a query
+// separately since not every driver supports running multiple statements at once.
+func SplitStatementsDialect(t dbType, query string) (statements []string) {
+	for _, stmt := range splitStatements(TokenizeDialect(t, query)) {
+		if nextSignificant(stmt, 0) >= len(stmt) {
+			continue
+		}
+
+		statements = append(statements, strings.TrimSpace(JoinTokens(stmt)))
+	}
+
+	return
+}
+
+// nextSignificant returns the index of the first significant token at or after i,
+// skipping whitespace and comments. len(tokens) is returned if there is none.
+func nextSignificant(tokens []Token, i int) int {
+	for ; i < len(tokens); i++ {
+		if tokens[i].Significant() {
+			return i
+		}
+	}
+
+	return len(tokens)
+}
+
+// matchWords checks if the significant tokens starting at i are the given words, in
+// order, and returns the index after the last matched word. -1 is returned if the
+// words do not match.
+func matchWords(tokens []Token, i int, words ...string) int {
+	for _, w := range words {
+		i = nextSignificant(tokens, i)
+		if i >= len(tokens) || !tokens[i].Is(w) {
+			return -1
+		}
+		i++
+	}
+
+	return i
+}
+
+// matchingParen returns the index of the closing parenthesis matching the opening
+// parenthesis at i. -1 is returned if there is no matching parenthesis.
+func matchingParen(tokens []Token, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch {
+		case tokens[i].IsPunctuation("("):
+			depth++
+		case tokens[i].IsPunctuation(")"):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// skipName returns the index after a possibly schema-qualified name (ex.: users or
+// main.users) starting at the next significant token at or after i. -1 is returned if
+// there is no name.
+func skipName(tokens []Token, i int) int {
+	i = nextSignificant(tokens, i)
+	if i >= len(tokens) || !tokens[i].IsName() {
+		return -1
+	}
+	i++
+
+	if i+1 < len(tokens) && tokens[i].IsPunctuation(".") && tokens[i+1].IsName() {
+		i += 2
+	}
+
+	return i
+}
+
+// replaceTokens replaces tokens[start:end] with the tokens of text.
+func replaceTokens(tokens []Token, start, end int, text string) []Token {
+	out := make([]Token, 0, len(tokens))
+	out = append(out, tokens[:start]...)
+	out = append(out, Tokenize(text)...)
+	out = append(out, tokens[end:]...)
+	return out
+}
+
+// removeWord removes the token at i and the whitespace directly before it.
+func removeWord(tokens []Token, i int) []Token {
+	start := i
+	if start > 0 && tokens[start-1].Kind == TokenWhitespace {
+		start--
+	}
+
+	return append(tokens[:start:start], tokens[i+1:]...)
+}
+
+// findWord returns the index of the first significant token at or after i that is
+// one of the given words, not within parenthesis. -1 is returned if the word is not
+// found.
+func findWord(tokens []Token, i int, words ...string) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch {
+		case tokens[i].IsPunctuation("("):
+			depth++
+		case tokens[i].IsPunctuation(")"):
+			depth--
+		case depth == 0 && tokens[i].Is(words...):
+			return i
+		}
+	}
+
+	return -1
+}
+
+// tableDef is a CREATE TABLE or ALTER TABLE statement split into parts.
+//
+// For CREATE TABLE, prefix is everything up to and including the opening
+// parenthesis, each item is a column definition or table constraint, and suffix is
+// the closing parenthesis and anything after it (ex.: table options).
+//
+// For ALTER TABLE, prefix is everything up to and including the table name, each
+// item is an alteration (ex.: ADD COLUMN ...), and suffix is any trailing semicolon.
+type tableDef struct {
+	alter bool
+
+	//name is the table's name and nameIdx is the index of the name in prefix.
+	name    Token
+	nameIdx int
+
+	//schema is the schema the table's name is qualified with (ex.: archive in
+	//archive.users), blank if the name isn't qualified.
+	schema Token
+
+	prefix []Token
+	items  []tableItem
+	suffix []Token
+}
+
+// tableItem is a column definition, table constraint, or alteration, without the
+// separating comma.
+type tableItem struct {
+	tokens []Token
+
+	//removed is set to remove the item from the statement. Whitespace around the
+	//item is kept so that the formatting of the statement is kept.
+	removed bool
+}
+
+// columnDef is the location of the parts of a column definition within a tableItem's
+// tokens.
+type columnDef struct {
+	//name is the column's name and nameIdx is the index of the name in the item's
+	//tokens.
+	name    Token
+	nameIdx int
+
+	//typeStart and typeEnd are the range of tokens that are the column's data type,
+	//including any arguments and modifiers (ex.: INT(11) UNSIGNED).
+	typeStart, typeEnd int
+}
+
+// parseTableDef parses a CREATE TABLE or ALTER TABLE statement. False is returned if
+// the statement is not a CREATE TABLE or ALTER TABLE statement.
+func parseTableDef(stmt []Token) (t tableDef, ok bool) {
+	i := nextSignificant(stmt, 0)
+	if i >= len(stmt) {
+		return
+	}
+
+	switch {
+	case stmt[i].Is("CREATE"):
+		i++
+		if j := matchWords(stmt, i, "TEMPORARY"); j != -1 {
+			i = j
+		} else if j := matchWords(stmt, i, "TEMP"); j != -1 {
+			i = j
+		}
+
+	case stmt[i].Is("ALTER"):
+		t.alter = true
+		i++
+		for _, w := range []string{"ONLINE", "IGNORE"} {
+			if j := matchWords(stmt, i, w); j != -1 {
+				i = j
+			}
+		}
+
+	default:
+		return
+	}
+
+	i = matchWords(stmt, i, "TABLE")
+	if i == -1 {
+		return
+	}
+	if j := matchWords(stmt, i, "IF", "NOT", "EXISTS"); j != -1 {
+		i = j
+	} else if j := matchWords(stmt, i, "IF", "EXISTS"); j != -1 {
+		i = j
+	}
+
+	nameEnd := skipName(stmt, i)
+	if nameEnd == -1 {
+		return
+	}
+	t.nameIdx = nameEnd - 1
+	t.name = stmt[t.nameIdx]
+	if t.nameIdx >= 2 && stmt[t.nameIdx-1].IsPunctuation(".") {
+		t.schema = stmt[t.nameIdx-2]
+	}
+
+	//Trailing semicolon, and anything after it, is part of the suffix.
+	end := len(stmt)
+	for j := len(stmt) - 1; j >= nameEnd; j-- {
+		if stmt[j].IsPunctuation(";") {
+			end = j
+			break
+		}
+	}
+
+	var body []Token
+	if t.alter {
+		t.prefix = stmt[:nameEnd]
+		body = stmt[nameEnd:end]
+		t.suffix = stmt[end:]
+	} else {
+		open := nextSignificant(stmt, nameEnd)
+		if open >= len(stmt) || !stmt[open].IsPunctuation("(") {
+			return
+		}
+		close := matchingParen(stmt, open)
+		if close == -1 {
+			return
+		}
+
+		t.prefix = stmt[:open+1]
+		body = stmt[open+1 : close]
+		t.suffix = stmt[close:]
+	}
+
+	//Split the body into items at each comma not within parenthesis.
+	depth := 0
+	start := 0
+	for j, tok := range body {
+		switch {
+		case tok.IsPunctuation("("):
+			depth++
+		case tok.IsPunctuation(")"):
+			depth--
+		case tok.IsPunctuation(",") && depth == 0:
+			t.items = append(t.items, tableItem{tokens: body[start:j]})
+			start = j + 1
+		}
+	}
+	t.items = append(t.items, tableItem{tokens: body[start:]})
+
+	ok = true
+	return
+}
+
+// inSchema returns name qualified with the table's schema, if the table's name is
+// qualified.
+func (t tableDef) inSchema(name string) string {
+	if t.schema.Text == "" {
+		return name
+	}
+
+	return t.schema.Text + "." + name
+}
+
+// tokens joins the parts of the statement back together. Removed items, and the
+// comma before them, are omitted.
+func (t tableDef) tokens() (out []Token) {
+	out = append(out, t.prefix...)
+
+	written := false
+	for _, item := range t.items {
+		if item.removed {
+			out = append(out, item.outerWhitespace()...)
+			continue
+		}
+
+		if written {
+			out = append(out, Token{Kind: TokenPunctuation, Text: ","})
+		}
+		out = append(out, item.tokens...)
+		written = true
+	}
+
+	out = append(out, t.suffix...)
+	return
+}
+
+// outerWhitespace returns the whitespace before the first, and after the last,
+// significant token in the item. This is used to keep the formatting of a statement
+// when an item is removed. Whitespace before the item is only kept if it includes a
+// newline so that removing an item from a single line query doesn't leave an extra
+// space.
+func (item tableItem) outerWhitespace() (out []Token) {
+	first := item.firstWord()
+	last := len(item.tokens) - 1
+	for last >= 0 && !item.tokens[last].Significant() {
+		last--
+	}
+
+	for i, tok := range item.tokens {
+		if tok.Kind != TokenWhitespace {
+			continue
+		}
+		if (i < first && strings.Contains(tok.Text, "\n")) || i > last {
+			out = append(out, tok)
+		}
+	}
+
+	return
+}
+
+// trailing returns the index of the first token after the last significant token in
+// the item, i.e. where trailing whitespace and comments start.
+func (item tableItem) trailing() int {
+	i := len(item.tokens)
+	for i > 0 && !item.tokens[i-1].Significant() {
+		i--
+	}
+
+	return i
+}
+
+// appendText adds text to the end of the item, before any trailing whitespace.
+func (item *tableItem) appendText(text string) {
+	i := item.trailing()
+	item.tokens = replaceTokens(item.tokens, i, i, text)
+}
+
+// addItem adds a column definition or table constraint to the end of a CREATE TABLE
+// statement. The item is formatted like the existing last item; on a new line if
+// the last item is on a new line.
+func (t *tableDef) addItem(text string) {
+	if len(t.items) == 0 {
+		t.items = append(t.items, tableItem{tokens: Tokenize(text)})
+		return
+	}
+
+	last := &t.items[len(t.items)-1]
+
+	//Leading whitespace of the last item.
+	indent := " "
+	if first := last.firstWord(); first > 0 && last.tokens[first-1].Kind == TokenWhitespace {
+		if ws := last.tokens[first-1].Text; strings.Contains(ws, "\n") {
+			indent = ws[strings.LastIndex(ws, "\n"):]
+		}
+	}
+
+	//Move the last item's trailing whitespace to the new item.
+	end := last.trailing()
+	trailing := last.tokens[end:]
+	last.tokens = last.tokens[:end:end]
+
+	tokens := Tokenize(indent + text)
+	tokens = append(tokens, trailing...)
+	t.items = append(t.items, tableItem{tokens: tokens})
+}
+
+// constraintColumns returns the columns listed in the first set of parenthesis of a
+// table constraint (ex.: PRIMARY KEY (A, B) or FOREIGN KEY (A) REFERENCES...). False is
+// returned if the item is not a table constraint.
+func (item tableItem) constraintColumns() (cols []string, ok bool) {
+	tokens := item.tokens
+	i := item.firstWord()
+	if i >= len(tokens) || !tokens[i].Is(tableConstraintWords...) {
+		return
+	}
+
+	for ; i < len(tokens); i++ {
+		if !tokens[i].IsPunctuation("(") {
+			continue
+		}
+
+		close := matchingParen(tokens, i)
+		if close == -1 {
+			return
+		}
+		for _, t := range tokens[i+1 : close] {
+			if t.IsName() {
+				cols = append(cols, t.Unquoted())
+			}
+		}
+
+		ok = true
+		return
+	}
+
+	return
+}
+
+// tableConstraintWords are the words a table constraint, versus a column
+// definition, begins with in a CREATE TABLE or after ADD in an ALTER TABLE.
+var tableConstraintWords = []string{
+	"CONSTRAINT", "PRIMARY", "UNIQUE", "KEY", "INDEX", "FOREIGN", "CHECK", "FULLTEXT", "SPATIAL", "PERIOD",
+}
+
+// firstWord returns the index of the first significant token in the item.
+func (item tableItem) firstWord() int {
+	return nextSignificant(item.tokens, 0)
+}
+
+// column finds the parts of a column definition in the item. For ALTER TABLE, the
+// item must be an ADD, MODIFY, or CHANGE of a column. False is returned if the item
+// is not a column definition, for example a table constraint.
+func (item tableItem) column(alter bool) (col columnDef, ok bool) {
+	tokens := item.tokens
+	i := item.firstWord()
+	if i >= len(tokens) {
+		return
+	}
+
+	if alter {
+		change := tokens[i].Is("CHANGE")
+		if !tokens[i].Is("ADD", "MODIFY", "CHANGE") {
+			return
+		}
+		i++
+
+		if j := matchWords(tokens, i, "COLUMN"); j != -1 {
+			i = j
+		}
+		if j := matchWords(tokens, i, "IF", "NOT", "EXISTS"); j != -1 {
+			i = j
+		} else if j := matchWords(tokens, i, "IF", "EXISTS"); j != -1 {
+			i = j
+		}
+
+		//CHANGE provides the old and the new column name.
+		if change {
+			i = nextSignificant(tokens, i) + 1
+		}
+	}
+
+	i = nextSignificant(tokens, i)
+	if i >= len(tokens) || !tokens[i].IsName() || tokens[i].Is(tableConstraintWords...) {
+		return
+	}
+	col.name = tokens[i]
+	col.nameIdx = i
+
+	//Data type.
+	i = nextSignificant(tokens, i+1)
+	if i >= len(tokens) || !tokens[i].IsWord() {
+		return
+	}
+	col.typeStart = i
+	col.typeEnd = i + 1
+
+	//Data type arguments, ex.: VARCHAR(255) or DECIMAL(10,4).
+	if j := nextSignificant(tokens, col.typeEnd); j < len(tokens) && tokens[j].IsPunctuation("(") {
+		close := matchingParen(tokens, j)
+		if close == -1 {
+			return
+		}
+		col.typeEnd = close + 1
+	}
+
+	//Data type modifiers.
+	for {
+		j := nextSignificant(tokens, col.typeEnd)
+		if j >= len(tokens) || !tokens[j].Is("UNSIGNED", "SIGNED", "ZEROFILL") {
+			break
+		}
+		col.typeEnd = j + 1
+	}
+
+	ok = true
+	return
+}
+
+// typeName returns the uppercased name of the column's data type, without any
+// arguments or modifiers.
+func (col columnDef) typeName(tokens []Token) string {
+	return strings.ToUpper(tokens[col.typeStart].Text)
+}
+
+// typeWithoutModifiers returns the column's data type, with any arguments, but without
+// modifiers (ex.: UNSIGNED).
+func (col columnDef) typeWithoutModifiers(tokens []Token) string {
+	end := col.typeEnd
+	for end > col.typeStart+1 && (tokens[end-1].Is("UNSIGNED", "SIGNED", "ZEROFILL") || !tokens[end-1].Significant()) {
+		end--
+	}
+
+	return JoinTokens(tokens[col.typeStart:end])
+}
+
+// primaryKeyColumns returns the columns in a PRIMARY KEY table constraint. False is
+// returned if the item is not a PRIMARY KEY table constraint.
+func (item tableItem) primaryKeyColumns() (cols []string, ok bool) {
+	tokens := item.tokens
+	i := item.firstWord()
+
+	//Optional constraint name.
+	if j := matchWords(tokens, i, "CONSTRAINT"); j != -1 {
+		i = nextSignificant(tokens, j)
+		if i < len(tokens) && !tokens[i].Is("PRIMARY") {
+			i++
+		}
+	}
+
+	i = matchWords(tokens, i, "PRIMARY", "KEY")
+	if i == -1 {
+		return
+	}
+
+	open := nextSignificant(tokens, i)
+	if open >= len(tokens) || !tokens[open].IsPunctuation("(") {
+		return
+	}
+	close := matchingParen(tokens, open)
+	if close == -1 {
+		return
+	}
+
+	for _, t := range tokens[open+1 : close] {
+		if t.IsName() {
+			cols = append(cols, t.Unquoted())
+		}
+	}
+
+	ok = true
+	return
+}
+
+// inlineIndexDef is an inline INDEX or KEY definition in a CREATE TABLE. See
+// parseInlineIndex.
+type inlineIndexDef struct {
+	unique   bool
+	fulltext bool
+
+	//name is the index name, blank if the index isn't named.
+	name Token
+
+	//cols is the tokens of the indexed columns, without prefix lengths, and colNames
+	//is the unquoted name of each column.
+	cols     []Token
+	colNames []string
+}
+
+// parseInlineIndex parses an inline INDEX or KEY definition in a CREATE TABLE (ex.:
+// INDEX Name_idx (Name), UNIQUE KEY (Email), or FULLTEXT KEY (Notes)). False is
+// returned if the item is not an inline index.
+func (item tableItem) parseInlineIndex() (idx inlineIndexDef, ok bool) {
+	tokens := item.tokens
+	i := item.firstWord()
+	if i >= len(tokens) {
+		return
+	}
+
+	switch {
+	case tokens[i].Is("INDEX", "KEY"):
+		i++
+	case tokens[i].Is("UNIQUE", "FULLTEXT"):
+		j := matchWords(tokens, i+1, "INDEX")
+		if j == -1 {
+			j = matchWords(tokens, i+1, "KEY")
+		}
+		if j == -1 && tokens[i].Is("UNIQUE") {
+			//UNIQUE (...) is a table constraint, not an index.
+			return
+		}
+		if j == -1 {
+			j = i + 1
+		}
+
+		idx.unique = tokens[i].Is("UNIQUE")
+		idx.fulltext = tokens[i].Is("FULLTEXT")
+		i = j
+	default:
+		return
+	}
+
+	//Optional index name.
+	i = nextSignificant(tokens, i)
+	if i < len(tokens) && tokens[i].IsName() {
+		idx.name = tokens[i]
+		i = nextSignificant(tokens, i+1)
+	}
+
+	if i >= len(tokens) || !tokens[i].IsPunctuation("(") {
+		return
+	}
+	close := matchingParen(tokens, i)
+	if close == -1 {
+		return
+	}
+
+	//Remove prefix lengths, ex.: Name(10), since only MariaDB/MySQL support them.
+	for j := i + 1; j < close; j++ {
+		if tokens[j].IsPunctuation("(") {
+			j = matchingParen(tokens, j)
+			continue
+		}
+		if tokens[j].IsName() && !tokens[j].Is("ASC", "DESC") {
+			idx.colNames = append(idx.colNames, tokens[j].Unquoted())
+		}
+		idx.cols = append(idx.cols, tokens[j])
+	}
+
+	ok = true
+	return
+}
+
+// indexName returns the name of the index. An index without a name is named after
+// the table and columns.
+func (idx inlineIndexDef) indexName(table Token) string {
+	if idx.name.Text != "" {
+		return idx.name.Text
+	}
+
+	return table.Unquoted() + "_" + strings.Join(idx.colNames, "_") + "_idx"
+}
+
+// schemaIndexName returns the name of the index when index names must be unique
+// within a schema, as in SQLite and PostgreSQL, instead of within a table, as in
+// MariaDB. A named index is prefixed with the table's name (ex.: idx_name on users is
+// named users_idx_name) so that indexes with the same name on different tables don't
+// collide. An index without a name is already named after the table.
+func (idx inlineIndexDef) schemaIndexName(table Token) string {
+	if idx.name.Text == "" {
+		return idx.indexName(table)
+	}
+
+	name := table.Unquoted() + "_" + idx.name.Unquoted()
+	if idx.name.Kind == TokenQuotedIdentifier || idx.name.Kind == TokenDoubleQuoted {
+		return idx.name.Text[:1] + name + idx.name.Text[len(idx.name.Text)-1:]
+	}
+
+	return name
+}
+
+// createIndex returns a CREATE INDEX statement for the index on table t. A FULLTEXT
+// index is created as a regular index. The index is named with schemaIndexName.
+//
+// If the table's name is qualified with a schema, the index's name is qualified when
+// qualifyIndex is true (ex.: CREATE INDEX archive.idx ON users), as SQLite requires.
+// Otherwise the table's name is qualified (ex.: CREATE INDEX idx ON archive.users),
+// as PostgreSQL requires.
+func (idx inlineIndexDef) createIndex(t tableDef, ifNotExists, qualifyIndex bool) (stmt string) {
+	stmt = "CREATE INDEX "
+	if idx.unique {
+		stmt = "CREATE UNIQUE INDEX "
+	}
+	if ifNotExists {
+		stmt += "IF NOT EXISTS "
+	}
+
+	if qualifyIndex {
+		stmt += t.inSchema(idx.schemaIndexName(t.name)) + " ON " + t.name.Text
+	} else {
+		stmt += idx.schemaIndexName(t.name) + " ON " + t.inSchema(t.name.Text)
+	}
+	stmt += " (" + JoinTokens(idx.cols) + ")"
+
+	return
+}
+
+// inlineIndex returns a CREATE INDEX statement for an inline INDEX or KEY definition
+// in a CREATE TABLE (ex.: INDEX Name_idx (Name) or UNIQUE KEY (Email)). False is
+// returned if the item is not an inline index. An index without a name is named after
+// the table and columns. See createIndex.
+func (item tableItem) inlineIndex(t tableDef, ifNotExists, qualifyIndex bool) (stmt string, ok bool) {
+	idx, ok := item.parseInlineIndex()
+	if !ok {
+		return "", false
+	}
+
+	return idx.createIndex(t, ifNotExists, qualifyIndex), true
+}
+
+// addStatements adds statements after a CREATE TABLE or ALTER TABLE statement, ex.:
+// CREATE INDEX statements for inline indexes. Each statement is separated by a
+// semicolon.
+func (t *tableDef) addStatements(statements []string) {
+	if len(statements) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	for _, s := range statements {
+		b.WriteString(";\n")
+		b.WriteString(s)
+	}
+
+	//The suffix of a CREATE TABLE starts with the closing parenthesis.
+	i := 0
+	if !t.alter {
+		i = 1
+	}
+	t.suffix = replaceTokens(t.suffix, i, i, b.String())
+}
+
+// parseCreateIndex parses a CREATE INDEX statement and returns the table and columns
+// the index is on. False is returned if the statement is not a CREATE INDEX
+// statement.
+func parseCreateIndex(stmt []Token) (table string, cols []string, ok bool) {
+	i := matchWords(stmt, 0, "CREATE")
+	if i == -1 {
+		return
+	}
+	if j := matchWords(stmt, i, "UNIQUE"); j != -1 {
+		i = j
+	}
+	i = matchWords(stmt, i, "INDEX")
+	if i == -1 {
+		return
+	}
+	if j := matchWords(stmt, i, "IF", "NOT", "EXISTS"); j != -1 {
+		i = j
+	}
+
+	i = skipName(stmt, i)
+	if i == -1 {
+		return
+	}
+	i = matchWords(stmt, i, "ON")
+	if i == -1 {
+		return
+	}
+	i = skipName(stmt, i)
+	if i == -1 {
+		return
+	}
+	table = stmt[i-1].Unquoted()
+
+	open := nextSignificant(stmt, i)
+	if open >= len(stmt) || !stmt[open].IsPunctuation("(") {
+		return
+	}
+	close := matchingParen(stmt, open)
+	if close == -1 {
+		return
+	}
+
+	//Only the first name of each indexed expression is used, this skips ASC, DESC,
+	//COLLATE, and prefix lengths.
+	first := true
+	for _, t := range stmt[open+1 : close] {
+		switch {
+		case t.IsPunctuation(","):
+			first = true
+		case first && t.IsName():
+			cols = append(cols, t.Unquoted())
+			first = false
+		}
+	}
+
+	ok = true
+	return
+}
+
+// backtickQuote returns a double quoted identifier quoted with backticks instead,
+// the quoting MariaDB/MySQL uses for identifiers. Other tokens are returned as-is.
+func backtickQuote(t Token) string {
+	if t.Kind != TokenDoubleQuoted {
+		return t.Text
+	}
+
+	name := strings.ReplaceAll(t.Unquoted(), `""`, `"`)
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
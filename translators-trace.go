@@ -0,0 +1,191 @@
+package sqldb
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+/*
+This file handles tracing translators, recording how each translator in a list of
+translators modified a query. This is used to debug a query that is translated
+incorrectly when more than one translator is used since otherwise there is no way
+to tell which translator changed what.
+*/
+
+// TranslatorTrace is the result of running a list of translators on a query, with
+// the change made by each translator. See TraceTranslators().
+type TranslatorTrace struct {
+	Query      string           //the query before any translator was run.
+	Translated string           //the query after every translator was run.
+	Steps      []TranslatorStep //one step for each translator that was run, in order.
+}
+
+// TranslatorStep is the change made to a query by one translator.
+type TranslatorStep struct {
+	Translator string //name of the translator func, ex.: sqldb.TranslateMariaDBToSQLite.
+	Before     string
+	After      string
+	Diff       string //line based diff of Before and After, blank if nothing changed.
+}
+
+// Changed returns true if the translator modified the query.
+func (s TranslatorStep) Changed() bool {
+	return s.Before != s.After
+}
+
+// String formats the trace for logging. Only the translators that changed the query
+// are included.
+func (t TranslatorTrace) String() string {
+	var b strings.Builder
+	b.WriteString("Translating query:\n")
+	b.WriteString(t.Query)
+
+	for i, s := range t.Steps {
+		if !s.Changed() {
+			continue
+		}
+
+		b.WriteString("\n--- translator ")
+		b.WriteString(strconv.Itoa(i + 1))
+		b.WriteString(": ")
+		b.WriteString(s.Translator)
+		b.WriteString("\n")
+		b.WriteString(s.Diff)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// TraceTranslators runs each translator, in order, on a query and returns the
+// translated query along with the change made by each translator. This performs the
+// same translation as RunTranslators().
+//
+// If a translator reports that the query cannot be translated, the error is returned
+// and the trace includes the steps run before the failing translator.
+func TraceTranslators(query string, translators []QueryTranslator) (trace TranslatorTrace, err error) {
+	trace.Query = query
+
+	//Wrap each translator to record its input and output so that the error handling
+	//in RunTranslators() is reused.
+	wrapped := make([]QueryTranslator, len(translators))
+	for i, t := range translators {
+		name := funcName(t)
+		wrapped[i] = CheckedTranslator(func(in string) (string, error) {
+			out, err := t.TranslateQuery(in)
+			if err != nil {
+				return out, err
+			}
+
+			trace.Steps = append(trace.Steps, TranslatorStep{
+				Translator: name,
+				Before:     in,
+				After:      out,
+				Diff:       diffLines(in, out),
+			})
+			return out, nil
+		})
+	}
+
+	trace.Translated, err = RunTranslators(query, wrapped)
+	return
+}
+
+// runTranslators runs translators, and then checked translators, on a query, tracing
+// the translators if a TranslatorTracer is set.
+func (c *Config) runTranslators(query string, plain []Translator, checked []CheckedTranslator) (out string, err error) {
+	translators := queryTranslators(plain, checked)
+
+	if c.TranslatorTracer == nil {
+		return RunTranslators(query, translators)
+	}
+
+	trace, err := TraceTranslators(query, translators)
+	c.TranslatorTracer(trace)
+	return trace.Translated, err
+}
+
+// funcName returns the name of a func, such as a translator or error handler, without
+// the package path. Funcs defined as closures are named after the enclosing func,
+// ex.: sqldb.TokenTranslator.func1. A value that isn't a func is named after its type.
+func funcName(t any) string {
+	v := reflect.ValueOf(t)
+	if v.Kind() != reflect.Func {
+		return strings.TrimPrefix(fmt.Sprintf("%T", t), "*")
+	}
+
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return "unknown"
+	}
+
+	//The package path of a module with a major version suffix ends with the version,
+	//ex.: github.com/c9845/sqldb/v3.TranslateMariaDBToSQLite. Use the package name
+	//before the version instead.
+	path, name := "", f.Name()
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		path, name = name[:i], name[i+1:]
+	}
+	if pkg, rest, ok := strings.Cut(name, "."); ok && isMajorVersion(pkg) && path != "" {
+		name = path[strings.LastIndex(path, "/")+1:] + "." + rest
+	}
+
+	return name
+}
+
+// isMajorVersion returns true if s is a module major version suffix, ex.: v3.
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
+
+// diffLines returns a line based diff of two strings. Removed lines are prefixed
+// with "-" and added lines with "+". Unchanged lines are omitted. A blank string is
+// returned if the strings are the same.
+func diffLines(before, after string) string {
+	if before == after {
+		return ""
+	}
+
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+
+	//Longest common subsequence of lines, lcs[i][j] is the length for a[i:] and
+	//b[j:]. Queries are short so the quadratic size doesn't matter.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,92 @@
+package sqldb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTraceTranslators(t *testing.T) {
+	upper := func(in string) string {
+		return strings.ToUpper(in)
+	}
+
+	q := "CREATE TABLE users (\n\tID INT,\n\tactive BOOL\n)"
+	trace, err := TraceTranslators(q, QueryTranslators(TranslateMariaDBToSQLite, upper, TranslateMariaDBToSQLite))
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if len(trace.Steps) != 3 {
+		t.Fatal("Bad number of steps.", len(trace.Steps))
+		return
+	}
+	if trace.Steps[0].Translator != "sqldb.TranslateMariaDBToSQLite" {
+		t.Fatal("Bad translator name.", trace.Steps[0].Translator)
+		return
+	}
+	if trace.Steps[2].Changed() || trace.Steps[2].Diff != "" {
+		t.Fatal("Last translator should not have changed the query.")
+		return
+	}
+
+	expectedDiff := "-\tID INT,\n-\tactive BOOL\n+\tID INTEGER,\n+\tactive INTEGER\n"
+	if trace.Steps[0].Diff != expectedDiff {
+		t.Log("Got:", trace.Steps[0].Diff)
+		t.Log("Exp:", expectedDiff)
+		t.Fatal("Bad diff.")
+		return
+	}
+
+	expected := "CREATE TABLE USERS (\n\tID INTEGER,\n\tACTIVE INTEGER\n)"
+	if trace.Translated != expected {
+		t.Log("Got:", trace.Translated)
+		t.Log("Exp:", expected)
+		t.Fatal("Bad translation.")
+		return
+	}
+
+	//Only changed steps are included when formatted.
+	s := trace.String()
+	if !strings.Contains(s, "translator 2: sqldb.TestTraceTranslators.func1") || strings.Contains(s, "translator 3") {
+		t.Fatal("Bad trace formatting.", s)
+		return
+	}
+
+	//Translation error.
+	tr, err := FunctionTranslator(DBTypeMariaDB, DBTypeSQLite)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	_, err = TraceTranslators("SELECT GROUP_CONCAT(Name ORDER BY Name) FROM users", []QueryTranslator{Translator(upper), tr})
+	var te *TranslationError
+	if !errors.As(err, &te) {
+		t.Fatal("TranslationError should have occured.", err)
+		return
+	}
+}
+
+func TestTranslatorTracer(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueryTranslators = []Translator{TranslateMariaDBToSQLite}
+	c.DeployQueries = []string{"CREATE TABLE users (ID INT)"}
+
+	var traces []TranslatorTrace
+	c.TranslatorTracer = func(trace TranslatorTrace) {
+		traces = append(traces, trace)
+	}
+
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	if len(traces) != 1 || traces[0].Translated != "CREATE TABLE users (ID INTEGER)" {
+		t.Fatal("Bad traces.", traces)
+		return
+	}
+}
@@ -1,6 +1,7 @@
 package sqldb
 
 import (
+	"strconv"
 	"strings"
 )
 
@@ -25,131 +26,1271 @@ translate a DeployQuery or UpdateQuery from one SQL database dialect to another.
 //
 //	  return strings.Replace(in, "DATETIME", "TEXT")
 //	 }
+//
+// A Translator cannot report that a query cannot be translated, use a
+// CheckedTranslator for that.
 type Translator func(string) string
 
+// TranslateQuery implements QueryTranslator. A Translator never returns an error.
+func (t Translator) TranslateQuery(query string) (string, error) {
+	return t(query), nil
+}
+
+// CheckedTranslator is a function that translates a query from one SQL dialect to
+// another, returning an error if the query contains something that cannot be
+// translated (see TranslationError). Use this instead of a Translator when passing
+// the query through as-is would just result in a confusing error from the database,
+// or worse, an incorrect result.
+//
+// CheckedTranslators are set in a config's DeployQueryCheckedTranslators,
+// UpdateQueryCheckedTranslators, and RuntimeQueryCheckedTranslators fields.
+type CheckedTranslator func(query string) (string, error)
+
+// TranslateQuery implements QueryTranslator.
+func (t CheckedTranslator) TranslateQuery(query string) (string, error) {
+	return t(query)
+}
+
+// QueryTranslator is implemented by Translator and CheckedTranslator so that both
+// types of translators can be run together, in order, by RunTranslators() and
+// TraceTranslators().
+//
+// Ex:
+//
+//	translators := []sqldb.QueryTranslator{
+//	  sqldb.Translator(sqldb.TranslateMariaDBToSQLite),
+//	  functions, //a CheckedTranslator returned by FunctionTranslator().
+//	}
+type QueryTranslator interface {
+	TranslateQuery(query string) (string, error)
+}
+
+// QueryTranslators returns a list of Translators as QueryTranslators. This is a
+// shortcut for converting each func to a Translator.
+//
+// Ex:
+//
+//	trace, err := sqldb.TraceTranslators(q, sqldb.QueryTranslators(sqldb.TranslateMariaDBToSQLite))
+func QueryTranslators(translators ...Translator) (qts []QueryTranslator) {
+	qts = make([]QueryTranslator, len(translators))
+	for i, t := range translators {
+		qts[i] = t
+	}
+
+	return
+}
+
+// queryTranslators returns a list of Translators followed by a list of
+// CheckedTranslators as one list, the order the translators in a config are run.
+func queryTranslators(plain []Translator, checked []CheckedTranslator) (qts []QueryTranslator) {
+	qts = QueryTranslators(plain...)
+	for _, t := range checked {
+		qts = append(qts, t)
+	}
+
+	return
+}
+
+// Translator returns the CheckedTranslator as a Translator so that it can be used
+// where only a Translator can be, such as DeployQueryTranslators. The query is
+// returned as-is if it cannot be translated, so the error is lost; use the
+// CheckedTranslators fields of a config to have the error returned instead.
+func (t CheckedTranslator) Translator() Translator {
+	return func(query string) string {
+		out, err := t(query)
+		if err != nil {
+			return query
+		}
+
+		return out
+	}
+}
+
 // TranslateMariaDBToSQLite translates a query written in MariaDB format to SQLite
 // format. This translator is meant to be used for CREATE TABLE and ALTER TABLE
-// queries only.
+// queries, and upserts (INSERT...ON DUPLICATE KEY UPDATE and INSERT IGNORE), other
+// queries are returned as-is.
+//
+// Only data types, column options, inline indexes, and table options are translated.
+// Column names, quoted identifiers, string literals (ex.: DEFAULT values), and
+// comments are never modified.
+//
+// Some MariaDB definitions are translated into more than one SQLite statement,
+// separated by semicolons:
+//   - Inline INDEX and KEY definitions in a CREATE TABLE are moved to CREATE INDEX IF
+//     NOT EXISTS statements after the CREATE TABLE. FULLTEXT indexes are created as
+//     regular indexes. Index names are prefixed with the table's name since SQLite
+//     index names must be unique within a schema, not just within a table.
+//   - ON UPDATE CURRENT_TIMESTAMP is removed from the column and an AFTER UPDATE
+//     trigger that sets the column is created instead. The trigger uses the rowid so
+//     it does not work with WITHOUT ROWID tables.
+//
+// ENUM columns are translated to TEXT with a CHECK constraint on the allowed values
+// and table options (ex.: ENGINE=InnoDB) are removed.
+//
+// A statement that cannot be translated, such as an INSERT...SELECT upsert or an
+// AUTO_INCREMENT column in a composite primary key, is returned as-is. Use
+// TryTranslateMariaDBToSQLite to get an error instead.
 func TranslateMariaDBToSQLite(query string) string {
-	//Change INT to INTEGER since SQLite defines INTEGER as a data type and INT as a
-	//type affinity. This is really just an idiomatic thing and doesn't affect queries
-	//at all.
+	return TokenTranslator(keepUntranslatable(mariaDBToSQLite))(query)
+}
+
+// TryTranslateMariaDBToSQLite translates a query the same as TranslateMariaDBToSQLite
+// but returns a *TranslationError if a statement cannot be translated, such as an
+// INSERT...SELECT upsert. Use this as a CheckedTranslator.
+//
+// Ex:
+//
+//	c.UpdateQueryCheckedTranslators = []sqldb.CheckedTranslator{
+//	  sqldb.TryTranslateMariaDBToSQLite,
+//	}
+func TryTranslateMariaDBToSQLite(query string) (string, error) {
+	return CheckedTokenTranslator(mariaDBToSQLite)(query)
+}
+
+// keepUntranslatable returns a func for TokenTranslator that returns a statement as-is
+// if f returns an error. This is used for Translators since a Translator cannot
+// return an error.
+func keepUntranslatable(f func(stmt []Token) ([]Token, error)) func(stmt []Token) []Token {
+	return func(stmt []Token) []Token {
+		out, err := f(stmt)
+		if err != nil {
+			return stmt
+		}
+
+		return out
+	}
+}
+
+// mariaDBToSQLite translates a single CREATE TABLE, ALTER TABLE, or INSERT statement
+// from MariaDB to SQLite. See TranslateMariaDBToSQLite.
+func mariaDBToSQLite(stmt []Token) ([]Token, error) {
+	if first := nextSignificant(stmt, 0); first < len(stmt) && stmt[first].Is("INSERT") {
+		return mariaDBUpsertToSQLite(stmt)
+	}
+
+	t, ok := parseTableDef(stmt)
+	if !ok {
+		return stmt, nil
+	}
+
+	//Track the AUTO_INCREMENT columns since these columns are defined as the
+	//primary key in the column definition and therefore any PRIMARY KEY table
+	//constraint for the column must be removed.
+	autoIncrement := make(map[string]bool)
+
+	//Statements to run after the CREATE TABLE or ALTER TABLE, ex.: CREATE INDEX.
+	var statements []string
+
+	for i := range t.items {
+		item := &t.items[i]
+
+		//Inline indexes. SQLite only supports indexes created with CREATE INDEX.
+		//SQLite doesn't have FULLTEXT indexes so these are created as a regular
+		//index.
+		if !t.alter {
+			if idx, ok := item.parseInlineIndex(); ok {
+				statements = append(statements, idx.createIndex(t, true, true))
+				item.removed = true
+				continue
+			}
+		}
+
+		col, ok := item.column(t.alter)
+		if !ok {
+			continue
+		}
+
+		newType := mariaDBToSQLiteType(col.typeName(item.tokens))
+
+		//Change an ENUM to TEXT with a CHECK constraint limiting the column to the
+		//ENUM's values.
+		if newType == "TEXT" && col.typeName(item.tokens) == "ENUM" {
+			open := nextSignificant(item.tokens, col.typeStart+1)
+			if close := matchingParen(item.tokens, open); open < col.typeEnd && close != -1 {
+				values := JoinTokens(item.tokens[open+1 : close])
+				item.appendText(" CHECK (" + col.name.Text + " IN (" + values + "))")
+			}
+		}
+
+		//Remove ON UPDATE CURRENT_TIMESTAMP and use a trigger to set the column
+		//instead since SQLite doesn't support ON UPDATE for columns.
+		if o, end := onUpdateTimestamp(item.tokens, col.typeEnd); o != -1 {
+			item.tokens = replaceTokens(item.tokens, o, end, "")
+			statements = append(statements, sqliteOnUpdateTrigger(t, col.name))
+		}
+
+		//Change UTC_TIMESTAMP to CURRENT_TIMESTAMP. SQLite doesn't have
+		//UTC_TIMESTAMP, but CURRENT_TIMESTAMP returns a UTC datetime. The default
+		//may be wrapped in parenthesis, ex.: DEFAULT (UTC_TIMESTAMP()).
+		if d := findWord(item.tokens, col.typeEnd, "DEFAULT"); d != -1 {
+			start := nextSignificant(item.tokens, d+1)
+			v, close := start, -1
+			if v < len(item.tokens) && item.tokens[v].IsPunctuation("(") {
+				close = matchingParen(item.tokens, v)
+				v = nextSignificant(item.tokens, v+1)
+			}
+
+			if v < len(item.tokens) && item.tokens[v].Is("UTC_TIMESTAMP") {
+				end := v + 1
+				if end+1 < len(item.tokens) && item.tokens[end].IsPunctuation("(") && item.tokens[end+1].IsPunctuation(")") {
+					end += 2
+				}
+
+				switch {
+				case start == v:
+					item.tokens = replaceTokens(item.tokens, v, end, "CURRENT_TIMESTAMP")
+				case close != -1 && nextSignificant(item.tokens, end) == close:
+					item.tokens = replaceTokens(item.tokens, start, close+1, "CURRENT_TIMESTAMP")
+				}
+			}
+		}
+
+		//Reformat an AUTO_INCREMENT column. SQLite only allows AUTOINCREMENT on an
+		//INTEGER PRIMARY KEY column, and the primary key is defined as part of the
+		//column definition. The column can't be added to an existing table so this
+		//is only done for CREATE TABLE.
+		if a := findWord(item.tokens, col.typeEnd, "AUTO_INCREMENT"); a != -1 && !t.alter {
+			item.tokens = removeWord(item.tokens, a)
+			if k := findWord(item.tokens, col.typeEnd, "PRIMARY"); k != -1 && matchWords(item.tokens, k+1, "KEY") != -1 {
+				item.tokens = removeWord(item.tokens, nextSignificant(item.tokens, k+1))
+				item.tokens = removeWord(item.tokens, k)
+			}
+
+			newType = "INTEGER PRIMARY KEY AUTOINCREMENT"
+			autoIncrement[strings.ToLower(col.name.Unquoted())] = true
+		}
+
+		//Replace the data type last since the above uses indexes after the data type.
+		if newType != "" {
+			item.tokens = replaceTokens(item.tokens, col.typeStart, col.typeEnd, newType)
+		}
+	}
+
+	//Remove the PRIMARY KEY table constraint for an AUTO_INCREMENT column. SQLite
+	//doesn't use PRIMARY KEY(ID), the primary key is defined as part of the column
+	//definition (see above).
 	//
-	//Spaces around INT and INTEGER to try and prevent mistaken replacement if the
-	//characters "int" end up in another word, i.e.: a column name.
-	before := " INT "
-	after := " INTEGER "
-	query = strings.ReplaceAll(query, before, after)
-
-	//Reformat the ID column.
-	before = "ID INTEGER NOT NULL AUTO_INCREMENT"
-	after = "ID INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL"
-	query = strings.Replace(query, before, after, 1)
-
-	//Remove the PRIMARY KEY definition. SQLite doesn't use PRIMARY KEY(ID), the
-	//primary key is defined as part of the column definition (see above).
-	before = "PRIMARY KEY(ID)"
-	after = ""
-	if strings.Contains(query, before) {
-		//Find the "PRIMARY KEY(ID)" in the query.
-		primaryKeyIndex := strings.Index(query, before)
-
-		//Get the query up to the "PRIMARY KEY(ID)".
-		beforePrimaryKeyDeclaration := query[:primaryKeyIndex]
-
-		//Find the last comma before the "PRIMARY KEY(ID)". We need to remove this
-		//comma otherwise we will get an invalid query format error, aka an extra
-		//comma exists somewhere.
-		lastCommaIndex := strings.LastIndex(beforePrimaryKeyDeclaration, ",")
-
-		//Remove the comma.
-		query = query[:lastCommaIndex] + query[lastCommaIndex+1:]
-
-		//Remove the "PRIMARY KEY(ID)". We don't need to worry if a comma exists after
-		//the "PRIMARY KEY(ID)" since even if one does, we already removed a preceeding
-		//comma so we won't end up with two commas in a row.
-		query = strings.Replace(query, before, after, 1)
-	}
-
-	//Change UTC_TIMESTAMP to CURRENT_TIMESTAMP. SQLite doesn't have UTC_TIMESTAMP,
-	//but CURRENT_TIMESTAMP returns a UTC datetime.
-	before = "DEFAULT UTC_TIMESTAMP"
-	after = "DEFAULT CURRENT_TIMESTAMP"
-	query = strings.ReplaceAll(query, before, after)
-
-	//Change DATETIME columns to TEXT. SQLite doesn't have a DATETIME column type,
-	//and using it (as column type affinity) can cause issues due to the way each
-	//SQLite library handles data conversion.
+	//SQLite only allows AUTOINCREMENT on a single column primary key, so an
+	//AUTO_INCREMENT column in a composite primary key cannot be translated.
+	for i := range t.items {
+		cols, ok := t.items[i].primaryKeyColumns()
+		if !ok {
+			continue
+		}
+
+		for _, col := range cols {
+			if len(cols) > 1 && autoIncrement[strings.ToLower(col)] {
+				return stmt, &TranslationError{
+					From:      DBTypeMariaDB,
+					To:        DBTypeSQLite,
+					Construct: "AUTO_INCREMENT",
+					Reason:    "SQLite only supports AUTOINCREMENT on a single column primary key",
+				}
+			}
+		}
+
+		if len(cols) == 1 && autoIncrement[strings.ToLower(cols[0])] {
+			t.items[i].removed = true
+		}
+	}
+
+	if !t.alter {
+		t.suffix = removeTableOptions(t.suffix)
+	}
+
+	t.addStatements(statements)
+
+	return t.tokens(), nil
+}
+
+// onUpdateTimestamp returns the range of tokens of an ON UPDATE CURRENT_TIMESTAMP
+// column option, including the leading whitespace. NOW() and UTC_TIMESTAMP() are
+// also matched, with an optional precision. -1 is returned if the column doesn't
+// have the option.
+func onUpdateTimestamp(tokens []Token, i int) (start, end int) {
+	start, end = -1, -1
+	for i < len(tokens) {
+		o := findWord(tokens, i, "ON")
+		if o == -1 {
+			return
+		}
+		i = o + 1
+
+		//Skip ON DELETE/ON UPDATE of a REFERENCES clause, ex.: ON UPDATE CASCADE.
+		v := matchWords(tokens, o, "ON", "UPDATE")
+		if v == -1 {
+			continue
+		}
+		v = nextSignificant(tokens, v)
+		if v >= len(tokens) || !tokens[v].Is("CURRENT_TIMESTAMP", "NOW", "UTC_TIMESTAMP", "LOCALTIMESTAMP") {
+			continue
+		}
+
+		end = v + 1
+		if p := nextSignificant(tokens, end); p < len(tokens) && tokens[p].IsPunctuation("(") {
+			if close := matchingParen(tokens, p); close != -1 {
+				end = close + 1
+			}
+		}
+
+		start = o
+		if start > 0 && tokens[start-1].Kind == TokenWhitespace {
+			start--
+		}
+		return
+	}
+
+	return
+}
+
+// sqliteOnUpdateTrigger returns a CREATE TRIGGER statement that sets a column to the
+// current timestamp when a row is updated, replicating MariaDB's ON UPDATE
+// CURRENT_TIMESTAMP. The column is only set if the update didn't set it so that the
+// trigger doesn't fire for its own update.
+//
+// If the table is in a schema, the trigger is created in the same schema. SQLite
+// doesn't allow qualified table names within a trigger, the table is always in the
+// trigger's schema.
+func sqliteOnUpdateTrigger(t tableDef, column Token) string {
+	table := t.name
+	name := t.inSchema(table.Unquoted() + "_" + column.Unquoted() + "_on_update")
+	return "CREATE TRIGGER IF NOT EXISTS " + name + " AFTER UPDATE ON " + table.Text + " FOR EACH ROW" +
+		" WHEN NEW." + column.Text + " IS OLD." + column.Text +
+		" BEGIN UPDATE " + table.Text + " SET " + column.Text + " = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid; END"
+}
+
+// mariaDBUpsertToSQLite translates a MariaDB upsert to SQLite. INSERT IGNORE is
+// translated to INSERT OR IGNORE and ON DUPLICATE KEY UPDATE is translated to ON
+// CONFLICT DO UPDATE SET. References to the inserted values, VALUES(col) or an alias
+// for the inserted row (ex.: VALUES (?) AS new...new.col), are translated to
+// excluded.col.
+//
+// The conflict target is omitted since MariaDB applies the update to a conflict on
+// any unique index. This requires SQLite 3.35.0 or newer.
+//
+// An INSERT...SELECT upsert cannot be translated and a *TranslationError is returned.
+func mariaDBUpsertToSQLite(stmt []Token) ([]Token, error) {
+	insert := nextSignificant(stmt, 0)
+	if ignore := matchWords(stmt, insert, "INSERT", "IGNORE"); ignore != -1 {
+		stmt = replaceTokens(stmt, insert, ignore, "INSERT OR IGNORE")
+	}
+
+	//Find ON DUPLICATE KEY UPDATE.
+	on, update := -1, -1
+	for i := findWord(stmt, 0, "ON"); i != -1; i = findWord(stmt, i+1, "ON") {
+		if j := matchWords(stmt, i, "ON", "DUPLICATE", "KEY", "UPDATE"); j != -1 {
+			on, update = i, j
+			break
+		}
+	}
+	if on == -1 {
+		return stmt, nil
+	}
+
+	//SQLite requires a WHERE clause in an INSERT...SELECT upsert to prevent the ON
+	//from being parsed as a join, which can't be reliably added here.
+	values := findWord(stmt, 0, "VALUES", "VALUE")
+	if values == -1 || values > on {
+		return stmt, &TranslationError{
+			From:      DBTypeMariaDB,
+			To:        DBTypeSQLite,
+			Construct: "ON DUPLICATE KEY UPDATE",
+			Reason:    "only INSERT...VALUES upserts are supported",
+		}
+	}
+
+	//Get the alias for the inserted row, if any, used in the assignments. Column
+	//aliases, ex.: AS new(a, b), are mapped to the inserted columns by position.
+	alias := ""
+	aliasCols := make(map[string]string)
+	if as := findWord(stmt, values, "AS"); as != -1 && as < on {
+		if a := nextSignificant(stmt, as+1); a < on {
+			alias = stmt[a].Unquoted()
+
+			if p := nextSignificant(stmt, a+1); p < on && stmt[p].IsPunctuation("(") {
+				names := parenNames(stmt, p)
+
+				var cols []string
+				if p := findPunctuation(stmt[:values], "("); p != -1 {
+					cols = parenNames(stmt, p)
+				}
+				if len(cols) != len(names) {
+					return stmt, &TranslationError{
+						From:      DBTypeMariaDB,
+						To:        DBTypeSQLite,
+						Construct: "AS " + alias + "(...)",
+						Reason:    "column aliases require a matching list of inserted columns",
+					}
+				}
+
+				for i, n := range names {
+					aliasCols[strings.ToLower(n)] = cols[i]
+				}
+			}
+		}
+	}
+
+	//Translate the assignments before replacing ON DUPLICATE KEY UPDATE and
+	//removing the alias since doing so changes the indexes.
+	set := stmt[update:]
+	for i := 0; i < len(set); i++ {
+		t := set[i]
+
+		//VALUES(col).
+		if t.Is("VALUES") {
+			open := nextSignificant(set, i+1)
+			if open < len(set) && set[open].IsPunctuation("(") {
+				if close := matchingParen(set, open); close != -1 {
+					col := nextSignificant(set, open+1)
+					set = replaceTokens(set, i, close+1, "excluded."+set[col].Text)
+					continue
+				}
+			}
+		}
+
+		//new.col, where new is the alias for the inserted row.
+		if alias != "" && t.IsName() && strings.EqualFold(t.Unquoted(), alias) && i+2 < len(set) && set[i+1].IsPunctuation(".") {
+			set[i] = Token{Kind: TokenIdentifier, Text: "excluded"}
+			if col, ok := aliasCols[strings.ToLower(set[i+2].Unquoted())]; ok {
+				set[i+2] = Token{Kind: TokenIdentifier, Text: col}
+			}
+		}
+	}
+
+	stmt = append(stmt[:update:update], set...)
+	stmt = replaceTokens(stmt, on, update, "ON CONFLICT DO UPDATE SET")
+
+	//Remove the alias, including any column aliases, ex.: AS new(a, b).
+	if alias != "" {
+		as := findWord(stmt, values, "AS")
+		end := nextSignificant(stmt, as+1) + 1
+		if p := nextSignificant(stmt, end); p < len(stmt) && stmt[p].IsPunctuation("(") {
+			end = matchingParen(stmt, p) + 1
+		}
+		stmt = removeWord(replaceTokens(stmt, as+1, end, ""), as)
+	}
+
+	return stmt, nil
+}
+
+// parenNames returns the names in the comma separated list of names within the
+// parenthesis starting at open, such as the columns of an INSERT.
+func parenNames(tokens []Token, open int) (names []string) {
+	close := matchingParen(tokens, open)
+	if close == -1 {
+		return
+	}
+
+	for _, t := range tokens[open+1 : close] {
+		if t.IsName() {
+			names = append(names, t.Text)
+		}
+	}
+
+	return
+}
+
+// findPunctuation returns the index of the first punctuation token p. -1 is returned
+// if p is not found.
+func findPunctuation(tokens []Token, p string) int {
+	for i, t := range tokens {
+		if t.IsPunctuation(p) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// mariaDBToSQLiteType returns the SQLite data type to use for a MariaDB data type. A
+// blank string is returned if the data type does not need to be translated.
+func mariaDBToSQLiteType(typ string) string {
+	switch typ {
+	//Change INT to INTEGER since SQLite defines INTEGER as a data type and INT as
+	//a type affinity. Display widths and UNSIGNED are removed since SQLite only
+	//treats a column as an alias for the rowid if the type is exactly INTEGER.
+	//
+	//BOOL and BOOLEAN are INTEGER since SQLite doesn't use BOOL.
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "BOOL", "BOOLEAN":
+		return "INTEGER"
+
+	//Change DATETIME, and other date and time, columns to TEXT. SQLite doesn't have
+	//a DATETIME column type, and using it (as column type affinity) can cause issues
+	//due to the way each SQLite library handles data conversion.
 	//
 	//The mattn/go-sqlite3 library converts DATETIME columns with data stored in
 	//yyyy-mm-dd hh:mm:ss format to yyyy-mm-ddThh:mm:ssZ upon returning values (via
 	//a SELECT query) which is unexpected. There may be other ways around preventing
-	//this conversion, but converting to TEXT works and is inline with SQLite's column
-	//types.
-	before = "DATETIME"
-	after = "TEXT"
-	query = strings.ReplaceAll(query, before, after)
+	//this conversion, but converting to TEXT works and is inline with SQLite's
+	//column types.
+	case "DATETIME", "TIMESTAMP", "DATE", "TIME":
+		return "TEXT"
+
+	//Convert VARCHAR(...), and other text, definitions to TEXT since SQLite doesn't
+	//use VARCHAR.
+	case "CHAR", "VARCHAR", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT":
+		return "TEXT"
+
+	//Change ENUM to TEXT. The allowed values are added as a CHECK constraint.
+	case "ENUM":
+		return "TEXT"
 
 	//Change TINYBLOB, MEDIUMBLOB, and LONGBLOB columns to just BLOB.
-	query = strings.ReplaceAll(query, "TINYBLOB", "BLOB")
-	query = strings.ReplaceAll(query, "MEDIUMBLOB", "BLOB")
-	query = strings.ReplaceAll(query, "LONGBLOB", "BLOB")
+	case "TINYBLOB", "MEDIUMBLOB", "LONGBLOB":
+		return "BLOB"
 
-	//Convert VARCHAR(...) definitions to TEXT since SQLite doesn't use VARCHAR.
-	//Note for/while loop, since a query could use VARCHAR multiple times!
-	for {
-		const searchFor = "VARCHAR"
-		const swapWith = "TEXT"
+	//Convert DECIMAL(...) definitions to REAL since SQLite doesn't use DECIMAL.
+	//DOUBLE and FLOAT are REAL as well, the data type SQLite uses for floating point
+	//numbers.
+	case "DECIMAL", "DOUBLE", "FLOAT":
+		return "REAL"
+	}
 
-		if !strings.Contains(query, searchFor) {
-			break
+	return ""
+}
+
+// indexedTextLength is the length used for TEXT columns that are translated to
+// VARCHAR since the column is used in an index. 255 characters fits within
+// MariaDB's index key length limit when using the utf8mb4 character set.
+const indexedTextLength = "255"
+
+// TranslateSQLiteToMariaDB translates a query written in SQLite format to MariaDB
+// format. This translator is meant to be used for CREATE TABLE, ALTER TABLE, and
+// CREATE INDEX queries only, other queries are returned as-is.
+//
+// TEXT columns used in a PRIMARY KEY, UNIQUE, or other index are translated to
+// VARCHAR since MariaDB can't index TEXT columns without a prefix length. Indexes
+// created with CREATE INDEX are only known if the CREATE INDEX statement is in the
+// same query as the CREATE TABLE statement.
+//
+// Double quoted identifiers for table and column names are changed to backticks
+// since MariaDB treats double quoted text as a string. Backslashes in strings are
+// escaped since SQLite doesn't treat a backslash as an escape character.
+func TranslateSQLiteToMariaDB(query string) string {
+	statements := splitStatements(TokenizeDialect(DBTypeSQLite, query))
+
+	//Find the columns used in CREATE INDEX statements, keyed by table.column.
+	indexed := make(map[string]bool)
+	for _, stmt := range statements {
+		table, cols, ok := parseCreateIndex(stmt)
+		if !ok {
+			continue
 		}
 
-		//Get index of start of VARCHAR.
-		start := strings.Index(query, searchFor)
+		for _, col := range cols {
+			indexed[strings.ToLower(table+"."+col)] = true
+		}
+	}
+
+	var out []Token
+	for _, stmt := range statements {
+		out = append(out, sqliteToMariaDB(stmt, indexed)...)
+	}
+
+	//Escape backslashes in strings since MariaDB treats a backslash as an escape
+	//character but SQLite does not.
+	for i, t := range out {
+		if t.Kind == TokenString && strings.Contains(t.Text, `\`) {
+			out[i].Text = strings.ReplaceAll(t.Text, `\`, `\\`)
+		}
+	}
 
-		//Get index of closing parenthesis after VARCHAR.
-		end := strings.Index(query[start:], ")") + 1 + start
+	return JoinTokens(out)
+}
 
-		//Get text between start and end, i.e. the VARCHAR(...) definition.
-		definition := query[start:end]
+// sqliteToMariaDB translates a single CREATE TABLE or ALTER TABLE statement from
+// SQLite to MariaDB. indexed is the list of table.column used in CREATE INDEX
+// statements. See TranslateSQLiteToMariaDB.
+func sqliteToMariaDB(stmt []Token, indexed map[string]bool) []Token {
+	t, ok := parseTableDef(stmt)
+	if !ok {
+		return stmt
+	}
+	table := strings.ToLower(t.name.Unquoted())
 
-		//Replace VARCHAR(...) with TEXT.
-		query = strings.Replace(query, definition, swapWith, 1)
+	//Find the columns used in indexes within the statement.
+	for _, item := range t.items {
+		if cols, ok := item.constraintColumns(); ok {
+			for _, col := range cols {
+				indexed[table+"."+strings.ToLower(col)] = true
+			}
+		} else if col, ok := item.column(t.alter); ok && findWord(item.tokens, col.typeEnd, "PRIMARY", "UNIQUE") != -1 {
+			indexed[table+"."+strings.ToLower(col.name.Unquoted())] = true
+		}
 	}
 
-	//Convert DECIMAL(...) definitions to REAL since SQLite doesn't use DECIMAL.
-	//Note for/while loop, since a query could use DECIMAL multiple times!
-	for {
-		const searchFor = "DECIMAL"
-		const swapWith = "REAL"
+	primaryKey := ""
+	for i := range t.items {
+		item := &t.items[i]
+		col, ok := item.column(t.alter)
+		if !ok {
+			continue
+		}
+
+		name := backtickQuote(col.name)
+		item.tokens = replaceTokens(item.tokens, col.nameIdx, col.nameIdx+1, name)
+
+		newType := sqliteToMariaDBType(col.typeName(item.tokens), indexed[table+"."+strings.ToLower(col.name.Unquoted())])
+
+		//Change CURRENT_TIMESTAMP to UTC_TIMESTAMP since SQLite's CURRENT_TIMESTAMP
+		//is in UTC but MariaDB's CURRENT_TIMESTAMP is in the server's timezone.
+		//MariaDB only allows a function as a default value for date and time
+		//columns so the column is a DATETIME. The default may be wrapped in
+		//parenthesis, ex.: DEFAULT (CURRENT_TIMESTAMP).
+		if d := findWord(item.tokens, col.typeEnd, "DEFAULT"); d != -1 {
+			start := nextSignificant(item.tokens, d+1)
+			v, close := start, -1
+			if v < len(item.tokens) && item.tokens[v].IsPunctuation("(") {
+				close = matchingParen(item.tokens, v)
+				v = nextSignificant(item.tokens, v+1)
+			}
 
-		if !strings.Contains(query, searchFor) {
+			if v < len(item.tokens) && item.tokens[v].Is("CURRENT_TIMESTAMP") {
+				switch {
+				case start == v:
+					item.tokens = replaceTokens(item.tokens, v, v+1, "UTC_TIMESTAMP")
+					newType = "DATETIME"
+				case close != -1 && nextSignificant(item.tokens, v+1) == close:
+					item.tokens = replaceTokens(item.tokens, start, close+1, "UTC_TIMESTAMP")
+					newType = "DATETIME"
+				}
+			}
+		}
+
+		//Reformat an INTEGER PRIMARY KEY column, an alias for the rowid that is
+		//assigned automatically, to an AUTO_INCREMENT column with the primary key
+		//defined as a table constraint.
+		if col.typeName(item.tokens) == "INTEGER" && !t.alter {
+			if k := findWord(item.tokens, col.typeEnd, "PRIMARY"); k != -1 && matchWords(item.tokens, k+1, "KEY") != -1 {
+				item.tokens = removeWord(item.tokens, nextSignificant(item.tokens, k+1))
+				item.tokens = removeWord(item.tokens, k)
+				if a := findWord(item.tokens, col.typeEnd, "AUTOINCREMENT"); a != -1 {
+					item.tokens = removeWord(item.tokens, a)
+				}
+
+				item.appendText(" AUTO_INCREMENT")
+				primaryKey = name
+			}
+		}
+
+		//Replace the data type last since the above uses indexes after the data type.
+		if newType != "" {
+			item.tokens = replaceTokens(item.tokens, col.typeStart, col.typeEnd, newType)
+		}
+	}
+
+	if primaryKey != "" {
+		t.addItem("PRIMARY KEY(" + primaryKey + ")")
+	}
+
+	t.prefix = replaceTokens(t.prefix, t.nameIdx, t.nameIdx+1, backtickQuote(t.name))
+
+	//Remove SQLite specific table options.
+	if !t.alter {
+		t.suffix = removeSQLiteTableOptions(t.suffix)
+	}
+
+	return t.tokens()
+}
+
+// sqliteToMariaDBType returns the MariaDB data type to use for a SQLite data type. A
+// blank string is returned if the data type does not need to be translated.
+func sqliteToMariaDBType(typ string, indexed bool) string {
+	switch typ {
+	//MariaDB's REAL is a DOUBLE unless the REAL_AS_FLOAT SQL mode is set, so use
+	//DOUBLE to be explicit.
+	case "REAL":
+		return "DOUBLE"
+
+	//MariaDB can't use a TEXT column in an index without a prefix length.
+	case "TEXT":
+		if indexed {
+			return "VARCHAR(" + indexedTextLength + ")"
+		}
+	}
+
+	return ""
+}
+
+// removeSQLiteTableOptions removes the STRICT and WITHOUT ROWID table options from
+// the suffix of a CREATE TABLE statement (the closing parenthesis and anything
+// after it).
+func removeSQLiteTableOptions(suffix []Token) []Token {
+	out := make([]Token, 0, len(suffix))
+	for _, t := range suffix {
+		if t.Is("STRICT", "WITHOUT", "ROWID") || t.IsPunctuation(",") {
+			if len(out) > 0 && out[len(out)-1].Kind == TokenWhitespace {
+				out = out[:len(out)-1]
+			}
+			continue
+		}
+
+		out = append(out, t)
+	}
+
+	return out
+}
+
+// TranslateMariaDBToMSSQL translates a query written in MariaDB format to Microsoft
+// SQL Server (T-SQL) format. This translator is meant to be used for CREATE TABLE and
+// ALTER TABLE queries, although backtick quoted identifiers, double quoted strings,
+// and UTC_TIMESTAMP are translated in any query.
+//
+// CREATE TABLE IF NOT EXISTS is translated to a CREATE TABLE wrapped in an IF
+// OBJECT_ID(...) IS NULL check since SQL Server doesn't support IF NOT EXISTS. Table
+// options (ex.: ENGINE=InnoDB) are removed.
+//
+// ENUM columns are translated to NVARCHAR with a CHECK constraint on the allowed
+// values. Inline INDEX and KEY definitions are translated to SQL Server's inline
+// INDEX and UNIQUE KEY definitions to UNIQUE constraints. UNSIGNED and ZEROFILL are
+// removed, and AFTER and FIRST are removed from an ALTER TABLE since SQL Server
+// always adds columns last. An ALTER TABLE with more than one ADD is translated to a
+// single ADD followed by the list of columns and constraints.
+//
+// A statement that cannot be translated, such as one using ON UPDATE
+// CURRENT_TIMESTAMP, a FULLTEXT index, or an ALTER TABLE that combines ADD with other
+// alterations, is returned as-is. Use
+// TryTranslateMariaDBToMSSQL to get an error instead.
+func TranslateMariaDBToMSSQL(query string) string {
+	return TokenTranslator(keepUntranslatable(mariaDBToMSSQL))(query)
+}
+
+// TryTranslateMariaDBToMSSQL translates a query the same as TranslateMariaDBToMSSQL
+// but returns a *TranslationError if a statement cannot be translated, such as one
+// using ON UPDATE CURRENT_TIMESTAMP. Use this as a CheckedTranslator.
+func TryTranslateMariaDBToMSSQL(query string) (string, error) {
+	return CheckedTokenTranslator(mariaDBToMSSQL)(query)
+}
+
+// mariaDBToMSSQL translates a single statement from MariaDB to SQL Server. See
+// TranslateMariaDBToMSSQL.
+func mariaDBToMSSQL(stmt []Token) ([]Token, error) {
+	if err := mariaDBToMSSQLUntranslatable(stmt); err != nil {
+		return stmt, err
+	}
+
+	stmt = mariaDBToMSSQLTokens(stmt)
+
+	t, ok := parseTableDef(stmt)
+	if !ok {
+		return stmt, nil
+	}
+
+	for i := range t.items {
+		item := &t.items[i]
+
+		//Inline indexes. SQL Server supports inline indexes, but only with a name,
+		//and unique indexes are defined as UNIQUE constraints.
+		if !t.alter {
+			if idx, ok := item.parseInlineIndex(); ok {
+				text := "INDEX " + idx.indexName(t.name) + " (" + JoinTokens(idx.cols) + ")"
+				if idx.unique {
+					text = "UNIQUE (" + JoinTokens(idx.cols) + ")"
+					if idx.name.Text != "" {
+						text = "CONSTRAINT " + idx.name.Text + " " + text
+					}
+				}
+
+				item.tokens = replaceTokens(item.tokens, item.firstWord(), item.trailing(), text)
+				continue
+			}
+		}
+
+		col, ok := item.column(t.alter)
+		if !ok {
+			continue
+		}
+
+		newType := mariaDBToMSSQLType(col.typeName(item.tokens))
+
+		//Change an ENUM to NVARCHAR with a CHECK constraint limiting the column to
+		//the ENUM's values. SQL Server doesn't allow a CHECK constraint with ALTER
+		//COLUMN.
+		if col.typeName(item.tokens) == "ENUM" {
+			if t.alter && !item.tokens[item.firstWord()].Is("ADD") {
+				return stmt, &TranslationError{
+					From:      DBTypeMariaDB,
+					To:        DBTypeMSSQL,
+					Construct: "ENUM",
+					Reason:    "SQL Server can't add a CHECK constraint when altering a column",
+				}
+			}
+
+			open := nextSignificant(item.tokens, col.typeStart+1)
+			if close := matchingParen(item.tokens, open); open < col.typeEnd && close != -1 {
+				length := 1
+				for _, v := range item.tokens[open+1 : close] {
+					if v.Kind == TokenString && len([]rune(v.Unquoted())) > length {
+						length = len([]rune(v.Unquoted()))
+					}
+				}
+
+				newType = "NVARCHAR(" + strconv.Itoa(length) + ")"
+				values := JoinTokens(item.tokens[open+1 : close])
+				item.appendText(" CHECK (" + col.name.Text + " IN (" + values + "))")
+			}
+		}
+
+		//Change AUTO_INCREMENT to IDENTITY.
+		if a := findWord(item.tokens, col.typeEnd, "AUTO_INCREMENT"); a != -1 {
+			item.tokens = replaceTokens(item.tokens, a, a+1, "IDENTITY(1,1)")
+		}
+
+		//Remove the column position, SQL Server always adds columns last.
+		if t.alter {
+			if a := findWord(item.tokens, col.typeEnd, "AFTER"); a != -1 {
+				item.tokens = removeWord(item.tokens, nextSignificant(item.tokens, a+1))
+				item.tokens = removeWord(item.tokens, a)
+			}
+			if f := findWord(item.tokens, col.typeEnd, "FIRST"); f != -1 {
+				item.tokens = removeWord(item.tokens, f)
+			}
+		}
+
+		//SQL Server doesn't support UNSIGNED or ZEROFILL for any numeric type.
+		if newType == "" {
+			newType = col.typeWithoutModifiers(item.tokens)
+		}
+
+		//Replace the data type last since the above uses indexes after the data type.
+		item.tokens = replaceTokens(item.tokens, col.typeStart, col.typeEnd, newType)
+
+		//SQL Server uses ADD without COLUMN, and ALTER COLUMN instead of MODIFY.
+		if t.alter {
+			first := item.firstWord()
+			switch {
+			case item.tokens[first].Is("ADD"):
+				if c := nextSignificant(item.tokens, first+1); item.tokens[c].Is("COLUMN") {
+					item.tokens = removeWord(item.tokens, c)
+				}
+			case item.tokens[first].Is("MODIFY"):
+				end := first + 1
+				if c := nextSignificant(item.tokens, first+1); item.tokens[c].Is("COLUMN") {
+					end = c + 1
+				}
+				item.tokens = replaceTokens(item.tokens, first, end, "ALTER COLUMN")
+			}
+		}
+	}
+
+	//SQL Server uses a single ADD followed by a list of columns and constraints, ex.:
+	//ADD A INT, B INT instead of ADD A INT, ADD B INT.
+	if t.alter {
+		for i := 1; i < len(t.items); i++ {
+			item := &t.items[i]
+			if first := item.firstWord(); first < len(item.tokens) && item.tokens[first].Is("ADD") {
+				item.tokens = replaceTokens(item.tokens, first, nextSignificant(item.tokens, first+1), "")
+			}
+		}
+
+		return t.tokens(), nil
+	}
+
+	t.suffix = removeTableOptions(t.suffix)
+
+	//Wrap CREATE TABLE IF NOT EXISTS with an IF OBJECT_ID check.
+	start := nextSignificant(t.prefix, 0)
+	tbl := matchWords(t.prefix, start, "CREATE", "TABLE")
+	if tbl == -1 {
+		return t.tokens(), nil
+	}
+	ifNotExists := matchWords(t.prefix, tbl, "IF", "NOT", "EXISTS")
+	if ifNotExists == -1 {
+		return t.tokens(), nil
+	}
+
+	name := JoinTokens(t.prefix[nextSignificant(t.prefix, ifNotExists) : t.nameIdx+1])
+	name = strings.NewReplacer("[", "", "]", "", "'", "''").Replace(name)
+
+	//Indent the CREATE TABLE the same as the IF OBJECT_ID.
+	indent := ""
+	if start > 0 && t.prefix[start-1].Kind == TokenWhitespace {
+		ws := t.prefix[start-1].Text
+		indent = ws[strings.LastIndex(ws, "\n")+1:]
+	}
+
+	t.prefix = replaceTokens(t.prefix, tbl, ifNotExists, "")
+	t.prefix = replaceTokens(t.prefix, start, start, "IF OBJECT_ID(N'"+name+"', N'U') IS NULL\n"+indent)
+
+	return t.tokens(), nil
+}
+
+// mariaDBToMSSQLUntranslatable returns a *TranslationError if a CREATE TABLE or ALTER
+// TABLE statement uses something SQL Server has no equivalent for.
+func mariaDBToMSSQLUntranslatable(stmt []Token) error {
+	t, ok := parseTableDef(stmt)
+	if !ok {
+		return nil
+	}
+
+	//SQL Server's ALTER TABLE can add more than one column or constraint with a single
+	//ADD, but can't add and alter, or drop, in the same statement.
+	if t.alter {
+		adds := 0
+		for _, item := range t.items {
+			if first := item.firstWord(); first < len(item.tokens) && item.tokens[first].Is("ADD") {
+				adds++
+			}
+		}
+		if adds > 0 && adds < len(t.items) {
+			return &TranslationError{
+				From:      DBTypeMariaDB,
+				To:        DBTypeMSSQL,
+				Construct: "ALTER TABLE",
+				Reason:    "SQL Server can't combine ADD with other alterations in one ALTER TABLE, use separate statements",
+			}
+		}
+	}
+
+	for _, item := range t.items {
+		if idx, ok := item.parseInlineIndex(); ok && idx.fulltext {
+			return &TranslationError{
+				From:      DBTypeMariaDB,
+				To:        DBTypeMSSQL,
+				Construct: "FULLTEXT",
+				Reason:    "SQL Server full-text indexes require a full-text catalog, use CREATE FULLTEXT INDEX",
+			}
+		}
+
+		if col, ok := item.column(t.alter); ok {
+			if o, _ := onUpdateTimestamp(item.tokens, col.typeEnd); o != -1 {
+				return &TranslationError{
+					From:      DBTypeMariaDB,
+					To:        DBTypeMSSQL,
+					Construct: "ON UPDATE CURRENT_TIMESTAMP",
+					Reason:    "SQL Server doesn't support ON UPDATE for columns, use a trigger",
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// mariaDBToMSSQLTokens translates tokens that are written differently in SQL Server
+// regardless of where they are used in a statement.
+func mariaDBToMSSQLTokens(stmt []Token) (out []Token) {
+	for i := 0; i < len(stmt); i++ {
+		t := stmt[i]
+
+		switch {
+		//Change backtick quoted identifiers to brackets.
+		case t.Kind == TokenQuotedIdentifier && strings.HasPrefix(t.Text, "`"):
+			name := strings.ReplaceAll(t.Unquoted(), "``", "`")
+			t.Text = "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+
+		//Change double quoted strings to single quoted strings since SQL Server
+		//treats double quoted text as an identifier.
+		case t.Kind == TokenDoubleQuoted:
+			s := strings.NewReplacer(`""`, `"`, `\"`, `"`).Replace(t.Unquoted())
+			t = Token{Kind: TokenString, Text: "'" + strings.ReplaceAll(s, "'", "''") + "'"}
+
+		//Change UTC_TIMESTAMP, or UTC_TIMESTAMP(), to SYSUTCDATETIME().
+		case t.Is("UTC_TIMESTAMP"):
+			if i+2 < len(stmt) && stmt[i+1].IsPunctuation("(") && stmt[i+2].IsPunctuation(")") {
+				i += 2
+			}
+			out = append(out, Tokenize("SYSUTCDATETIME()")...)
+			continue
+		}
+
+		out = append(out, t)
+	}
+
+	return
+}
+
+// mariaDBToMSSQLType returns the SQL Server data type to use for a MariaDB data type.
+// A blank string is returned if the data type does not need to be translated.
+func mariaDBToMSSQLType(typ string) string {
+	switch typ {
+	//SQL Server doesn't support display widths or UNSIGNED for integers.
+	case "TINYINT", "SMALLINT", "INT", "BIGINT":
+		return typ
+	case "INTEGER", "MEDIUMINT":
+		return "INT"
+
+	case "BOOL", "BOOLEAN":
+		return "BIT"
+
+	//DATETIME2 has a larger range and precision than DATETIME. TIMESTAMP in SQL
+	//Server is a row version, not a date and time.
+	case "DATETIME", "TIMESTAMP":
+		return "DATETIME2"
+
+	case "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT":
+		return "NVARCHAR(MAX)"
+
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB":
+		return "VARBINARY(MAX)"
+
+	case "DOUBLE":
+		return "FLOAT"
+	}
+
+	return ""
+}
+
+// removeTableOptions removes the MariaDB table options (ex.: ENGINE=InnoDB) from the
+// suffix of a CREATE TABLE statement (the closing parenthesis and anything after it).
+// Anything between the closing parenthesis and a semicolon is removed, except for
+// trailing whitespace so that the formatting of the statement is kept.
+func removeTableOptions(suffix []Token) []Token {
+	end := len(suffix)
+	for j, tok := range suffix {
+		if tok.IsPunctuation(";") {
+			end = j
 			break
 		}
+	}
+	for end > 1 && suffix[end-1].Kind == TokenWhitespace {
+		end--
+	}
 
-		//Get index of start of DECIMAL.
-		start := strings.Index(query, searchFor)
+	return append(suffix[:1:1], suffix[end:]...)
+}
 
-		//Get index of closing parenthesis after DECIMAL.
-		end := strings.Index(query[start:], ")") + 1 + start
+// TranslateMariaDBToPostgres translates a query written in MariaDB format to
+// PostgreSQL format. This translator is meant to be used for CREATE TABLE and ALTER
+// TABLE queries, although backtick quoted identifiers, double quoted strings, and
+// UTC_TIMESTAMP are translated in any query.
+//
+// Inline INDEX and KEY definitions in a CREATE TABLE are moved to separate CREATE
+// INDEX statements after the CREATE TABLE, separated by semicolons, since PostgreSQL
+// doesn't support inline indexes. FULLTEXT indexes are created as regular indexes.
+// Index names are prefixed with the table's name since PostgreSQL index names must be
+// unique within a schema, not just within a table. Table options (ex.: ENGINE=InnoDB)
+// are removed.
+//
+// ENUM columns are translated to TEXT with a CHECK constraint on the allowed values.
+// AFTER and FIRST are removed from an ALTER TABLE since PostgreSQL always adds
+// columns last.
+//
+// A statement that cannot be translated, such as one using ON UPDATE
+// CURRENT_TIMESTAMP or an ALTER TABLE that uses MODIFY or CHANGE, is returned as-is.
+// Use TryTranslateMariaDBToPostgres to get an error instead.
+//
+// Note that this package does not support connecting to PostgreSQL, this translator
+// is provided for generating PostgreSQL schemas from MariaDB schemas.
+func TranslateMariaDBToPostgres(query string) string {
+	return TokenTranslator(keepUntranslatable(mariaDBToPostgres))(query)
+}
 
-		//Get text between start and end, i.e. the DECIMAL(...) definition.
-		definition := query[start:end]
+// TryTranslateMariaDBToPostgres translates a query the same as
+// TranslateMariaDBToPostgres but returns a *TranslationError if a statement cannot be
+// translated, such as one using ON UPDATE CURRENT_TIMESTAMP. Use this as a
+// CheckedTranslator.
+func TryTranslateMariaDBToPostgres(query string) (string, error) {
+	return CheckedTokenTranslator(mariaDBToPostgres)(query)
+}
 
-		//Replace DECIMAL(...) with TEXT.
-		query = strings.Replace(query, definition, swapWith, 1)
+// mariaDBToPostgres translates a single statement from MariaDB to PostgreSQL. See
+// TranslateMariaDBToPostgres.
+func mariaDBToPostgres(stmt []Token) ([]Token, error) {
+	if err := mariaDBToPostgresUntranslatable(stmt); err != nil {
+		return stmt, err
 	}
 
-	//Convert BOOL or BOOLEAN columns to INTEGER since SQLite doesn't use BOOL.
-	query = strings.ReplaceAll(query, " BOOLEAN", " INTEGER")
-	query = strings.ReplaceAll(query, " BOOL", " INTEGER")
+	stmt = mariaDBToPostgresTokens(stmt)
+
+	t, ok := parseTableDef(stmt)
+	if !ok {
+		return stmt, nil
+	}
 
-	//Convert DATE columns to TEXT since SQLite doesn't use DATE.
-	query = strings.ReplaceAll(query, " DATE", " TEXT")
+	//Only use IF NOT EXISTS for indexes if the table uses IF NOT EXISTS so that the
+	//schema can be deployed more than once.
+	ifNotExists := matchWords(t.prefix, nextSignificant(t.prefix, 0), "CREATE", "TABLE", "IF", "NOT", "EXISTS") != -1
+
+	var indexes []string
+	for i := range t.items {
+		item := &t.items[i]
+
+		//Inline indexes.
+		if !t.alter {
+			if idx, ok := item.inlineIndex(t, ifNotExists, false); ok {
+				indexes = append(indexes, idx)
+				item.removed = true
+				continue
+			}
+		}
+
+		col, ok := item.column(t.alter)
+		if !ok {
+			continue
+		}
+
+		newType := mariaDBToPostgresType(item.tokens[col.typeStart:col.typeEnd])
+
+		//Change an ENUM to TEXT with a CHECK constraint limiting the column to the
+		//ENUM's values.
+		if col.typeName(item.tokens) == "ENUM" {
+			open := nextSignificant(item.tokens, col.typeStart+1)
+			if close := matchingParen(item.tokens, open); open < col.typeEnd && close != -1 {
+				values := JoinTokens(item.tokens[open+1 : close])
+				item.appendText(" CHECK (" + col.name.Text + " IN (" + values + "))")
+			}
+		}
+
+		//Change AUTO_INCREMENT to an identity column.
+		if a := findWord(item.tokens, col.typeEnd, "AUTO_INCREMENT"); a != -1 {
+			item.tokens = replaceTokens(item.tokens, a, a+1, "GENERATED ALWAYS AS IDENTITY")
+		}
 
-	//Convert TIME columns to TEXT since SQLite doesn't use TIME.
-	query = strings.ReplaceAll(query, " TIME", " TEXT")
+		//Remove the column position, PostgreSQL always adds columns last.
+		if t.alter {
+			if a := findWord(item.tokens, col.typeEnd, "AFTER"); a != -1 {
+				item.tokens = removeWord(item.tokens, nextSignificant(item.tokens, a+1))
+				item.tokens = removeWord(item.tokens, a)
+			}
+			if f := findWord(item.tokens, col.typeEnd, "FIRST"); f != -1 {
+				item.tokens = removeWord(item.tokens, f)
+			}
+		}
+
+		//PostgreSQL doesn't cast integer defaults to booleans.
+		if newType == "BOOLEAN" {
+			if d := findWord(item.tokens, col.typeEnd, "DEFAULT"); d != -1 {
+				v := nextSignificant(item.tokens, d+1)
+				if v < len(item.tokens) && item.tokens[v].Kind == TokenNumber {
+					b := "TRUE"
+					if item.tokens[v].Text == "0" {
+						b = "FALSE"
+					}
+					item.tokens = replaceTokens(item.tokens, v, v+1, b)
+				}
+			}
+		}
+
+		//Replace the data type last since the above uses indexes after the data type.
+		item.tokens = replaceTokens(item.tokens, col.typeStart, col.typeEnd, newType)
+	}
+
+	if t.alter {
+		return t.tokens(), nil
+	}
+
+	t.suffix = removeTableOptions(t.suffix)
+
+	//Add the CREATE INDEX statements after the CREATE TABLE.
+	t.addStatements(indexes)
+
+	return t.tokens(), nil
+}
+
+// mariaDBToPostgresUntranslatable returns a *TranslationError if a CREATE TABLE or
+// ALTER TABLE statement uses something PostgreSQL has no equivalent for.
+func mariaDBToPostgresUntranslatable(stmt []Token) error {
+	t, ok := parseTableDef(stmt)
+	if !ok {
+		return nil
+	}
+
+	for _, item := range t.items {
+		//PostgreSQL changes a column with ALTER COLUMN, and a separate clause for
+		//each of the column's type, default, and nullability.
+		if t.alter {
+			if first := item.firstWord(); first < len(item.tokens) && item.tokens[first].Is("MODIFY", "CHANGE") {
+				return &TranslationError{
+					From:      DBTypeMariaDB,
+					To:        dbTypePostgres,
+					Construct: strings.ToUpper(item.tokens[first].Text),
+					Reason:    "PostgreSQL changes columns with ALTER COLUMN, use ALTER COLUMN ... TYPE, SET DEFAULT, or SET NOT NULL",
+				}
+			}
+		}
+
+		if col, ok := item.column(t.alter); ok {
+			if o, _ := onUpdateTimestamp(item.tokens, col.typeEnd); o != -1 {
+				return &TranslationError{
+					From:      DBTypeMariaDB,
+					To:        dbTypePostgres,
+					Construct: "ON UPDATE CURRENT_TIMESTAMP",
+					Reason:    "PostgreSQL doesn't support ON UPDATE for columns, use a trigger",
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// mariaDBToPostgresTokens translates tokens that are written differently in
+// PostgreSQL regardless of where they are used in a statement.
+func mariaDBToPostgresTokens(stmt []Token) (out []Token) {
+	for i := 0; i < len(stmt); i++ {
+		t := stmt[i]
+
+		switch {
+		//Change backtick quoted identifiers to double quotes.
+		case t.Kind == TokenQuotedIdentifier && strings.HasPrefix(t.Text, "`"):
+			name := strings.ReplaceAll(t.Unquoted(), "``", "`")
+			t = Token{Kind: TokenDoubleQuoted, Text: `"` + strings.ReplaceAll(name, `"`, `""`) + `"`}
+
+		//Change double quoted strings to single quoted strings since PostgreSQL
+		//treats double quoted text as an identifier.
+		case t.Kind == TokenDoubleQuoted:
+			s := strings.NewReplacer(`""`, `"`, `\"`, `"`).Replace(t.Unquoted())
+			t = Token{Kind: TokenString, Text: "'" + strings.ReplaceAll(s, "'", "''") + "'"}
+
+		//Change UTC_TIMESTAMP, or UTC_TIMESTAMP(), to the current time in UTC.
+		case t.Is("UTC_TIMESTAMP"):
+			if i+2 < len(stmt) && stmt[i+1].IsPunctuation("(") && stmt[i+2].IsPunctuation(")") {
+				i += 2
+			}
+			out = append(out, Tokenize("(NOW() AT TIME ZONE 'UTC')")...)
+			continue
+		}
+
+		out = append(out, t)
+	}
+
+	return
+}
+
+// mariaDBToPostgresType returns the PostgreSQL data type to use for a MariaDB data
+// type, given the data type's tokens including any arguments and modifiers. UNSIGNED
+// and ZEROFILL are always removed since PostgreSQL doesn't support them.
+func mariaDBToPostgresType(tokens []Token) string {
+	typ := strings.ToUpper(tokens[0].Text)
+
+	//Data type arguments, ex.: (10,2) from DECIMAL(10,2).
+	args := ""
+	if j := nextSignificant(tokens, 1); j < len(tokens) && tokens[j].IsPunctuation("(") {
+		if close := matchingParen(tokens, j); close != -1 {
+			args = JoinTokens(tokens[j : close+1])
+		}
+	}
+
+	switch typ {
+	//TINYINT(1) is the common way of storing booleans in MariaDB.
+	case "BOOL", "BOOLEAN":
+		return "BOOLEAN"
+	case "TINYINT":
+		if strings.ReplaceAll(args, " ", "") == "(1)" {
+			return "BOOLEAN"
+		}
+		return "SMALLINT"
+
+	//PostgreSQL doesn't support display widths for integers.
+	case "SMALLINT", "BIGINT", "INTEGER":
+		return typ
+	case "INT", "MEDIUMINT":
+		return "INTEGER"
+
+	case "DATETIME":
+		return "TIMESTAMP" + args
+
+	case "TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "ENUM":
+		return "TEXT"
+
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB":
+		return "BYTEA"
+
+	case "DOUBLE":
+		return "DOUBLE PRECISION"
+	}
 
-	return query
+	return tokens[0].Text + args
 }
@@ -1,6 +1,7 @@
 package sqldb
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -87,3 +88,617 @@ func TestRunTranslators(t *testing.T) {
 		t.Fatal("Bad translation.")
 	}
 }
+
+func TestTranslateMariaDBToSQLiteTokens(t *testing.T) {
+	tt := []struct {
+		name     string
+		mariadb  string
+		expected string
+	}{
+		{
+			name:     "column names are not types",
+			mariadb:  "CREATE TABLE t (DATE_CREATED DATE, TimeZone VARCHAR(10), `Int` INT, \"Time\" TIME)",
+			expected: "CREATE TABLE t (DATE_CREATED TEXT, TimeZone TEXT, `Int` INTEGER, \"Time\" TEXT)",
+		},
+		{
+			name:     "timestamp is not time",
+			mariadb:  "CREATE TABLE t (Created TIMESTAMP NOT NULL DEFAULT UTC_TIMESTAMP())",
+			expected: "CREATE TABLE t (Created TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+		},
+		{
+			name:     "default in parenthesis",
+			mariadb:  "CREATE TABLE t (Created DATETIME NOT NULL DEFAULT (UTC_TIMESTAMP()), Updated DATETIME DEFAULT ( UTC_TIMESTAMP ))",
+			expected: "CREATE TABLE t (Created TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP, Updated TEXT DEFAULT CURRENT_TIMESTAMP)",
+		},
+		{
+			name:     "strings and comments are not modified",
+			mariadb:  "CREATE TABLE t (\n\tKind VARCHAR(20) DEFAULT 'VARCHAR(20) DATETIME', -- INT column\n\tAmount DECIMAL(10,2) /* DECIMAL */\n)",
+			expected: "CREATE TABLE t (\n\tKind TEXT DEFAULT 'VARCHAR(20) DATETIME', -- INT column\n\tAmount REAL /* DECIMAL */\n)",
+		},
+		{
+			name:     "unsigned auto increment with quoted primary key",
+			mariadb:  "CREATE TABLE t (`ID` INT(10) UNSIGNED NOT NULL AUTO_INCREMENT, Name TEXT, PRIMARY KEY (`ID`))",
+			expected: "CREATE TABLE t (`ID` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL, Name TEXT)",
+		},
+		{
+			name:     "composite primary key is kept",
+			mariadb:  "CREATE TABLE t (A INT NOT NULL, B BIGINT NOT NULL, PRIMARY KEY(A, B))",
+			expected: "CREATE TABLE t (A INTEGER NOT NULL, B INTEGER NOT NULL, PRIMARY KEY(A, B))",
+		},
+		{
+			name:     "alter table modify and change",
+			mariadb:  "ALTER TABLE t MODIFY COLUMN Active BOOL NOT NULL, CHANGE OldName NewName DATETIME;",
+			expected: "ALTER TABLE t MODIFY COLUMN Active INTEGER NOT NULL, CHANGE OldName NewName TEXT;",
+		},
+		{
+			name:     "other queries are not modified",
+			mariadb:  "INSERT INTO t (Date) VALUES ('INT DATETIME')",
+			expected: "INSERT INTO t (Date) VALUES ('INT DATETIME')",
+		},
+		{
+			name:     "multiple statements",
+			mariadb:  "CREATE TABLE a (X INT); CREATE TABLE b (Y BOOL);",
+			expected: "CREATE TABLE a (X INTEGER); CREATE TABLE b (Y INTEGER);",
+		},
+		{
+			name:     "inline indexes",
+			mariadb:  "CREATE TABLE t (ID INT, Email VARCHAR(255), Name VARCHAR(255), UNIQUE KEY Email_idx (Email), INDEX (Name(10), ID))",
+			expected: "CREATE TABLE t (ID INTEGER, Email TEXT, Name TEXT);\nCREATE UNIQUE INDEX IF NOT EXISTS t_Email_idx ON t (Email);\nCREATE INDEX IF NOT EXISTS t_Name_ID_idx ON t (Name, ID)",
+		},
+		{
+			name:     "fulltext index",
+			mariadb:  "CREATE TABLE t (ID INT, Notes TEXT, FULLTEXT KEY Notes_ft (Notes), FULLTEXT (Notes, ID))",
+			expected: "CREATE TABLE t (ID INTEGER, Notes TEXT);\nCREATE INDEX IF NOT EXISTS t_Notes_ft ON t (Notes);\nCREATE INDEX IF NOT EXISTS t_Notes_ID_idx ON t (Notes, ID)",
+		},
+		{
+			name:     "auto increment in composite primary key is kept",
+			mariadb:  "CREATE TABLE t (ID INT AUTO_INCREMENT, A INT, PRIMARY KEY (ID, A))",
+			expected: "CREATE TABLE t (ID INT AUTO_INCREMENT, A INT, PRIMARY KEY (ID, A))",
+		},
+		{
+			name:     "enum",
+			mariadb:  "CREATE TABLE t (Status ENUM('active','disabled') NOT NULL DEFAULT 'active')",
+			expected: "CREATE TABLE t (Status TEXT NOT NULL DEFAULT 'active' CHECK (Status IN ('active','disabled')))",
+		},
+		{
+			name:     "table options",
+			mariadb:  "CREATE TABLE t (ID INT UNSIGNED) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+			expected: "CREATE TABLE t (ID INTEGER);",
+		},
+		{
+			name:     "on update current timestamp",
+			mariadb:  "CREATE TABLE t (Updated DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP(), ParentID INT REFERENCES p (ID) ON UPDATE CASCADE)",
+			expected: "CREATE TABLE t (Updated TEXT DEFAULT CURRENT_TIMESTAMP, ParentID INTEGER REFERENCES p (ID) ON UPDATE CASCADE);\nCREATE TRIGGER IF NOT EXISTS t_Updated_on_update AFTER UPDATE ON t FOR EACH ROW WHEN NEW.Updated IS OLD.Updated BEGIN UPDATE t SET Updated = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid; END",
+		},
+		{
+			name:     "alter table on update",
+			mariadb:  "ALTER TABLE t ADD COLUMN Updated DATETIME ON UPDATE NOW();",
+			expected: "ALTER TABLE t ADD COLUMN Updated TEXT;\nCREATE TRIGGER IF NOT EXISTS t_Updated_on_update AFTER UPDATE ON t FOR EACH ROW WHEN NEW.Updated IS OLD.Updated BEGIN UPDATE t SET Updated = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid; END;",
+		},
+		{
+			name:     "same index name on two tables",
+			mariadb:  "CREATE TABLE a (Name TEXT, INDEX `idx_name` (Name)); CREATE TABLE b (Name TEXT, INDEX `idx_name` (Name));",
+			expected: "CREATE TABLE a (Name TEXT);\nCREATE INDEX IF NOT EXISTS `a_idx_name` ON a (Name); CREATE TABLE b (Name TEXT);\nCREATE INDEX IF NOT EXISTS `b_idx_name` ON b (Name);",
+		},
+		{
+			name:     "table in schema",
+			mariadb:  "CREATE TABLE archive.t (Name VARCHAR(255), Updated DATETIME ON UPDATE NOW(), INDEX idx_name (Name))",
+			expected: "CREATE TABLE archive.t (Name TEXT, Updated TEXT);\nCREATE TRIGGER IF NOT EXISTS archive.t_Updated_on_update AFTER UPDATE ON t FOR EACH ROW WHEN NEW.Updated IS OLD.Updated BEGIN UPDATE t SET Updated = CURRENT_TIMESTAMP WHERE rowid = NEW.rowid; END;\nCREATE INDEX IF NOT EXISTS archive.t_idx_name ON t (Name)",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := TranslateMariaDBToSQLite(tc.mariadb)
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad translation.")
+				return
+			}
+		})
+	}
+
+	//An AUTO_INCREMENT column in a composite primary key cannot be translated.
+	_, err := TryTranslateMariaDBToSQLite("CREATE TABLE t (ID INT AUTO_INCREMENT, A INT, PRIMARY KEY (ID, A))")
+	if te, ok := err.(*TranslationError); !ok || te.Construct != "AUTO_INCREMENT" {
+		t.Fatal("TranslationError should have occured.", err)
+		return
+	}
+}
+
+func TestTranslateSQLiteToMariaDB(t *testing.T) {
+	tt := []struct {
+		name     string
+		sqlite   string
+		expected string
+	}{
+		{
+			name:     "integer primary key",
+			sqlite:   "CREATE TABLE t (ID INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL, Amount REAL)",
+			expected: "CREATE TABLE t (ID INTEGER NOT NULL AUTO_INCREMENT, Amount DOUBLE, PRIMARY KEY(ID))",
+		},
+		{
+			name:     "indexed text",
+			sqlite:   "CREATE TABLE t (Code TEXT PRIMARY KEY, Email TEXT, Notes TEXT, UNIQUE(Email))",
+			expected: "CREATE TABLE t (Code VARCHAR(255) PRIMARY KEY, Email VARCHAR(255), Notes TEXT, UNIQUE(Email))",
+		},
+		{
+			name:     "indexed text with create index",
+			sqlite:   "CREATE TABLE t (Name TEXT, Notes TEXT); CREATE INDEX IF NOT EXISTS t__Name_idx ON t (Name ASC);",
+			expected: "CREATE TABLE t (Name VARCHAR(255), Notes TEXT); CREATE INDEX IF NOT EXISTS t__Name_idx ON t (Name ASC);",
+		},
+		{
+			name:     "current timestamp",
+			sqlite:   "CREATE TABLE t (Created TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP, Note TEXT DEFAULT 'CURRENT_TIMESTAMP')",
+			expected: "CREATE TABLE t (Created DATETIME NOT NULL DEFAULT UTC_TIMESTAMP, Note TEXT DEFAULT 'CURRENT_TIMESTAMP')",
+		},
+		{
+			name:     "current timestamp in parenthesis",
+			sqlite:   "CREATE TABLE t (Created TEXT NOT NULL DEFAULT (CURRENT_TIMESTAMP), Updated TEXT DEFAULT ( CURRENT_TIMESTAMP ), Day TEXT DEFAULT (date(CURRENT_TIMESTAMP)))",
+			expected: "CREATE TABLE t (Created DATETIME NOT NULL DEFAULT UTC_TIMESTAMP, Updated DATETIME DEFAULT UTC_TIMESTAMP, Day TEXT DEFAULT (date(CURRENT_TIMESTAMP)))",
+		},
+		{
+			name:     "table options and double quoted names",
+			sqlite:   `CREATE TABLE "t" ("ID" INTEGER PRIMARY KEY, "Time" TEXT) STRICT, WITHOUT ROWID;`,
+			expected: "CREATE TABLE `t` (`ID` INTEGER AUTO_INCREMENT, `Time` TEXT, PRIMARY KEY(`ID`));",
+		},
+		{
+			name:     "alter table",
+			sqlite:   "ALTER TABLE t ADD COLUMN Amount REAL NOT NULL DEFAULT 0",
+			expected: "ALTER TABLE t ADD COLUMN Amount DOUBLE NOT NULL DEFAULT 0",
+		},
+		{
+			name:     "backslash in string",
+			sqlite:   `CREATE TABLE t (Dir TEXT DEFAULT 'C:\', Amount REAL)`,
+			expected: `CREATE TABLE t (Dir TEXT DEFAULT 'C:\\', Amount DOUBLE)`,
+		},
+		{
+			name:     "new line formatting",
+			sqlite:   "CREATE TABLE t (\n\tID INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,\n\tName TEXT\n)",
+			expected: "CREATE TABLE t (\n\tID INTEGER NOT NULL AUTO_INCREMENT,\n\tName TEXT,\n\tPRIMARY KEY(ID)\n)",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := TranslateSQLiteToMariaDB(tc.sqlite)
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad translation.")
+				return
+			}
+		})
+	}
+}
+
+func TestTranslateRoundTrip(t *testing.T) {
+	//Queries from TestRunTranslators and TestTranslateMariaDBToSQLiteTokens.
+	queries := []string{
+		`
+		CREATE TABLE IF NOT EXISTS users (
+			ID INT NOT NULL AUTO_INCREMENT,
+			Username VARCHAR(255) NOT NULL,
+			Password TEXT NOT NULL,
+			DatetimeCreated DATETIME DEFAULT UTC_TIMESTAMP,
+			FileBlob MEDIUMBLOB NOT NULL DEFAULT "",
+			IntColumn INT NOT NULL,
+			VarcharToText VARCHAR(255) NOT NULL,
+			DecimalToReal DECIMAL(10,4) NOT NULL DEFAULT 1.1234,
+			BoolToInt BOOL NOT NULL DEFAULT 0,
+			DateToText DATE NOT NULL,
+			TimeToText TIME NOT NULL,
+			
+			PRIMARY KEY(ID)
+		)
+		`,
+		"ALTER TABLE users ADD COLUMN BoolToInteger BOOL NOT NULL DEFAULT 0",
+		"CREATE TABLE t (Created TIMESTAMP NOT NULL DEFAULT UTC_TIMESTAMP())",
+		"CREATE TABLE t (`ID` INT(10) UNSIGNED NOT NULL AUTO_INCREMENT, Name TEXT, PRIMARY KEY (`ID`))",
+		"CREATE TABLE t (A INT NOT NULL, B BIGINT NOT NULL, PRIMARY KEY(A, B))",
+	}
+
+	//Whitespace isn't kept exactly when removing and adding the PRIMARY KEY.
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	}
+
+	for _, q := range queries {
+		//MariaDB to SQLite, back to MariaDB, and back to SQLite should result in the
+		//same SQLite query.
+		sqlite := TranslateMariaDBToSQLite(q)
+		mariadb := TranslateSQLiteToMariaDB(sqlite)
+		roundTrip := TranslateMariaDBToSQLite(mariadb)
+
+		if normalize(roundTrip) != normalize(sqlite) {
+			t.Log("SQLite:    ", sqlite)
+			t.Log("MariaDB:   ", mariadb)
+			t.Log("Round trip:", roundTrip)
+			t.Fatal("Round trip translation mismatch.")
+			return
+		}
+	}
+}
+
+func TestTranslateMariaDBToMSSQL(t *testing.T) {
+	tt := []struct {
+		name     string
+		mariadb  string
+		expected string
+	}{
+		{
+			name:     "create table if not exists",
+			mariadb:  "CREATE TABLE IF NOT EXISTS users (ID INT(10) UNSIGNED NOT NULL AUTO_INCREMENT, Active BOOL NOT NULL DEFAULT 1, PRIMARY KEY(ID)) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+			expected: "IF OBJECT_ID(N'users', N'U') IS NULL\nCREATE TABLE users (ID INT NOT NULL IDENTITY(1,1), Active BIT NOT NULL DEFAULT 1, PRIMARY KEY(ID));",
+		},
+		{
+			name:     "indented create table with quoted name",
+			mariadb:  "\n\t\tCREATE TABLE IF NOT EXISTS `user's` (`Date` DATETIME)",
+			expected: "\n\t\tIF OBJECT_ID(N'user''s', N'U') IS NULL\n\t\tCREATE TABLE [user's] ([Date] DATETIME2)",
+		},
+		{
+			name:     "data types",
+			mariadb:  "CREATE TABLE t (Notes TEXT, Body LONGTEXT, File MEDIUMBLOB, Created TIMESTAMP, Amount DOUBLE, Name VARCHAR(255))",
+			expected: "CREATE TABLE t (Notes NVARCHAR(MAX), Body NVARCHAR(MAX), File VARBINARY(MAX), Created DATETIME2, Amount FLOAT, Name VARCHAR(255))",
+		},
+		{
+			name:     "defaults",
+			mariadb:  `CREATE TABLE t (Created DATETIME DEFAULT UTC_TIMESTAMP(), Name TEXT NOT NULL DEFAULT "it's")`,
+			expected: "CREATE TABLE t (Created DATETIME2 DEFAULT SYSUTCDATETIME(), Name NVARCHAR(MAX) NOT NULL DEFAULT 'it''s')",
+		},
+		{
+			name:     "alter table add column",
+			mariadb:  "ALTER TABLE `users` ADD COLUMN Active BOOL NOT NULL DEFAULT 0",
+			expected: "ALTER TABLE [users] ADD Active BIT NOT NULL DEFAULT 0",
+		},
+		{
+			name:     "alter table modify column",
+			mariadb:  "ALTER TABLE users MODIFY COLUMN Notes TEXT NOT NULL",
+			expected: "ALTER TABLE users ALTER COLUMN Notes NVARCHAR(MAX) NOT NULL",
+		},
+		{
+			name:     "other queries",
+			mariadb:  "SELECT `Name` FROM users WHERE Created < UTC_TIMESTAMP AND Notes = 'TEXT'",
+			expected: "SELECT [Name] FROM users WHERE Created < SYSUTCDATETIME() AND Notes = 'TEXT'",
+		},
+		{
+			name:     "unsigned non-integer types",
+			mariadb:  "CREATE TABLE t (Price DECIMAL(10,2) UNSIGNED NOT NULL, Rate FLOAT UNSIGNED, Count INT(5) UNSIGNED ZEROFILL)",
+			expected: "CREATE TABLE t (Price DECIMAL(10,2) NOT NULL, Rate FLOAT, Count INT)",
+		},
+		{
+			name:     "enum",
+			mariadb:  "CREATE TABLE t (Status ENUM('active','disabled') NOT NULL DEFAULT 'active')",
+			expected: "CREATE TABLE t (Status NVARCHAR(8) NOT NULL DEFAULT 'active' CHECK (Status IN ('active','disabled')))",
+		},
+		{
+			name:     "alter table add enum",
+			mariadb:  "ALTER TABLE t ADD COLUMN Status ENUM(\"a\", \"b\")",
+			expected: "ALTER TABLE t ADD Status NVARCHAR(1) CHECK (Status IN ('a', 'b'))",
+		},
+		{
+			name:     "inline indexes",
+			mariadb:  "CREATE TABLE t (\n\tID INT NOT NULL,\n\tEmail VARCHAR(255),\n\tName VARCHAR(255),\n\tPRIMARY KEY (ID),\n\tUNIQUE KEY Email_uq (Email),\n\tUNIQUE INDEX (Name, ID),\n\tKEY Name_idx (Name(10)),\n\tINDEX (ID DESC)\n)",
+			expected: "CREATE TABLE t (\n\tID INT NOT NULL,\n\tEmail VARCHAR(255),\n\tName VARCHAR(255),\n\tPRIMARY KEY (ID),\n\tCONSTRAINT Email_uq UNIQUE (Email),\n\tUNIQUE (Name, ID),\n\tINDEX Name_idx (Name),\n\tINDEX t_ID_idx (ID DESC)\n)",
+		},
+		{
+			name:     "alter table column position",
+			mariadb:  "ALTER TABLE t ADD COLUMN Notes TEXT NOT NULL AFTER Name, ADD COLUMN Code INT FIRST",
+			expected: "ALTER TABLE t ADD Notes NVARCHAR(MAX) NOT NULL, Code INT",
+		},
+		{
+			name:     "alter table add multiple columns",
+			mariadb:  "ALTER TABLE users ADD COLUMN a INT, ADD COLUMN c TEXT, ADD CONSTRAINT a_uq UNIQUE (a);",
+			expected: "ALTER TABLE users ADD a INT, c NVARCHAR(MAX), CONSTRAINT a_uq UNIQUE (a);",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := TranslateMariaDBToMSSQL(tc.mariadb)
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad translation.")
+				return
+			}
+		})
+	}
+}
+
+func TestTranslateMariaDBToMSSQLErrors(t *testing.T) {
+	tt := []struct {
+		name      string
+		mariadb   string
+		construct string
+	}{
+		{
+			name:      "on update current timestamp",
+			mariadb:   "CREATE TABLE t (Updated DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP)",
+			construct: "ON UPDATE CURRENT_TIMESTAMP",
+		},
+		{
+			name:      "on update utc timestamp",
+			mariadb:   "ALTER TABLE t MODIFY Updated DATETIME ON UPDATE UTC_TIMESTAMP()",
+			construct: "ON UPDATE CURRENT_TIMESTAMP",
+		},
+		{
+			name:      "fulltext",
+			mariadb:   "CREATE TABLE t (Notes TEXT, FULLTEXT KEY Notes_ft (Notes))",
+			construct: "FULLTEXT",
+		},
+		{
+			name:      "modify enum",
+			mariadb:   "ALTER TABLE t MODIFY Status ENUM('a','b')",
+			construct: "ENUM",
+		},
+		{
+			name:      "add and drop",
+			mariadb:   "ALTER TABLE t ADD COLUMN a INT, DROP COLUMN b",
+			construct: "ALTER TABLE",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			//The query is returned as-is, the error is only returned by
+			//TryTranslateMariaDBToMSSQL.
+			if got := TranslateMariaDBToMSSQL(tc.mariadb); got != tc.mariadb {
+				t.Fatal("Query should be returned as-is.", got)
+				return
+			}
+
+			got, err := TryTranslateMariaDBToMSSQL(tc.mariadb)
+			te, ok := err.(*TranslationError)
+			if !ok || got != tc.mariadb {
+				t.Fatal("TranslationError should have occured.", got, err)
+				return
+			}
+			if te.Construct != tc.construct || te.To != DBTypeMSSQL {
+				t.Fatal("Bad error.", te)
+				return
+			}
+		})
+	}
+}
+
+func TestTranslateMariaDBToPostgres(t *testing.T) {
+	tt := []struct {
+		name     string
+		mariadb  string
+		expected string
+	}{
+		{
+			name:     "create table",
+			mariadb:  "CREATE TABLE `users` (ID INT(10) UNSIGNED NOT NULL AUTO_INCREMENT, Active TINYINT(1) NOT NULL DEFAULT 1, Age TINYINT UNSIGNED, Created DATETIME DEFAULT UTC_TIMESTAMP, PRIMARY KEY(ID)) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+			expected: `CREATE TABLE "users" (ID INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY, Active BOOLEAN NOT NULL DEFAULT TRUE, Age SMALLINT, Created TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'UTC'), PRIMARY KEY(ID));`,
+		},
+		{
+			name:     "data types",
+			mariadb:  "CREATE TABLE t (Active BOOL DEFAULT 0, Notes LONGTEXT, File BLOB, Amount DOUBLE, Price DECIMAL(10,2) UNSIGNED, Name VARCHAR(255))",
+			expected: "CREATE TABLE t (Active BOOLEAN DEFAULT FALSE, Notes TEXT, File BYTEA, Amount DOUBLE PRECISION, Price DECIMAL(10,2), Name VARCHAR(255))",
+		},
+		{
+			name:     "inline indexes",
+			mariadb:  "CREATE TABLE users (Name VARCHAR(255), Email VARCHAR(255), INDEX Name_idx (Name(10)), UNIQUE KEY (`Email`, Name DESC));",
+			expected: "CREATE TABLE users (Name VARCHAR(255), Email VARCHAR(255));\nCREATE INDEX users_Name_idx ON users (Name);\nCREATE UNIQUE INDEX users_Email_Name_idx ON users (\"Email\", Name DESC);",
+		},
+		{
+			name:     "inline indexes if not exists",
+			mariadb:  "CREATE TABLE IF NOT EXISTS users (Name TEXT, KEY (Name))",
+			expected: "CREATE TABLE IF NOT EXISTS users (Name TEXT);\nCREATE INDEX IF NOT EXISTS users_Name_idx ON users (Name)",
+		},
+		{
+			name:     "same index name on two tables",
+			mariadb:  "CREATE TABLE a (Name TEXT, INDEX idx_name (Name)); CREATE TABLE b (Name TEXT, INDEX idx_name (Name));",
+			expected: "CREATE TABLE a (Name TEXT);\nCREATE INDEX a_idx_name ON a (Name); CREATE TABLE b (Name TEXT);\nCREATE INDEX b_idx_name ON b (Name);",
+		},
+		{
+			name:     "inline indexes table in schema",
+			mariadb:  "CREATE TABLE archive.users (Name TEXT, INDEX Name_idx (Name))",
+			expected: "CREATE TABLE archive.users (Name TEXT);\nCREATE INDEX users_Name_idx ON archive.users (Name)",
+		},
+		{
+			name:     "fulltext index",
+			mariadb:  "CREATE TABLE t (Notes TEXT, FULLTEXT KEY Notes_ft (Notes))",
+			expected: "CREATE TABLE t (Notes TEXT);\nCREATE INDEX t_Notes_ft ON t (Notes)",
+		},
+		{
+			name:     "enum",
+			mariadb:  "CREATE TABLE t (Status ENUM('a', \"b\") NOT NULL DEFAULT 'a')",
+			expected: "CREATE TABLE t (Status TEXT NOT NULL DEFAULT 'a' CHECK (Status IN ('a', 'b')))",
+		},
+		{
+			name:     "unique constraint",
+			mariadb:  "CREATE TABLE users (Email TEXT, UNIQUE (Email))",
+			expected: "CREATE TABLE users (Email TEXT, UNIQUE (Email))",
+		},
+		{
+			name:     "alter table",
+			mariadb:  "ALTER TABLE users ADD COLUMN Active TINYINT(1) UNSIGNED NOT NULL DEFAULT 0",
+			expected: "ALTER TABLE users ADD COLUMN Active BOOLEAN NOT NULL DEFAULT FALSE",
+		},
+		{
+			name:     "alter table column position",
+			mariadb:  "ALTER TABLE users ADD COLUMN Status ENUM('a','b') AFTER Name, ADD COLUMN Code INT FIRST",
+			expected: "ALTER TABLE users ADD COLUMN Status TEXT CHECK (Status IN ('a','b')), ADD COLUMN Code INTEGER",
+		},
+		{
+			name:     "other queries",
+			mariadb:  "SELECT `Name` FROM users WHERE Created < UTC_TIMESTAMP() AND Name = \"it's\"",
+			expected: `SELECT "Name" FROM users WHERE Created < (NOW() AT TIME ZONE 'UTC') AND Name = 'it''s'`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := TranslateMariaDBToPostgres(tc.mariadb)
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad translation.")
+				return
+			}
+		})
+	}
+}
+
+func TestTranslateMariaDBToPostgresErrors(t *testing.T) {
+	tt := []struct {
+		name      string
+		mariadb   string
+		construct string
+	}{
+		{
+			name:      "on update current timestamp",
+			mariadb:   "CREATE TABLE t (Updated DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP)",
+			construct: "ON UPDATE CURRENT_TIMESTAMP",
+		},
+		{
+			name:      "modify",
+			mariadb:   "ALTER TABLE t MODIFY Status ENUM('a','b')",
+			construct: "MODIFY",
+		},
+		{
+			name:      "change",
+			mariadb:   "ALTER TABLE t CHANGE COLUMN Name FullName TEXT",
+			construct: "CHANGE",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			//The query is returned as-is, the error is only returned by
+			//TryTranslateMariaDBToPostgres.
+			if got := TranslateMariaDBToPostgres(tc.mariadb); got != tc.mariadb {
+				t.Fatal("Query should be returned as-is.", got)
+				return
+			}
+
+			got, err := TryTranslateMariaDBToPostgres(tc.mariadb)
+			te, ok := err.(*TranslationError)
+			if !ok || got != tc.mariadb {
+				t.Fatal("TranslationError should have occured.", got, err)
+				return
+			}
+			if te.Construct != tc.construct || te.To != dbTypePostgres {
+				t.Fatal("Bad error.", te)
+				return
+			}
+		})
+	}
+}
+
+func TestTranslateMariaDBUpsertToSQLite(t *testing.T) {
+	tt := []struct {
+		name     string
+		mariadb  string
+		expected string
+	}{
+		{
+			name:     "values function",
+			mariadb:  "INSERT INTO users (Email, Name) VALUES (?, ?) ON DUPLICATE KEY UPDATE Name=VALUES(Name), Logins = Logins + 1",
+			expected: "INSERT INTO users (Email, Name) VALUES (?, ?) ON CONFLICT DO UPDATE SET Name=excluded.Name, Logins = Logins + 1",
+		},
+		{
+			name:     "row alias",
+			mariadb:  "INSERT INTO users (Email, Name) VALUES (?, ?) AS new ON DUPLICATE KEY UPDATE Name = new.Name",
+			expected: "INSERT INTO users (Email, Name) VALUES (?, ?) ON CONFLICT DO UPDATE SET Name = excluded.Name",
+		},
+		{
+			name:     "column aliases",
+			mariadb:  "INSERT INTO users (Email, Name) VALUES (?, ?) AS new(e, n) ON DUPLICATE KEY UPDATE Name = new.n",
+			expected: "INSERT INTO users (Email, Name) VALUES (?, ?) ON CONFLICT DO UPDATE SET Name = excluded.Name",
+		},
+		{
+			name:     "insert ignore",
+			mariadb:  "INSERT IGNORE INTO users (Email) VALUES ('a')",
+			expected: "INSERT OR IGNORE INTO users (Email) VALUES ('a')",
+		},
+		{
+			name:     "not an upsert",
+			mariadb:  "INSERT INTO users (Email) VALUES ('ON DUPLICATE KEY UPDATE')",
+			expected: "INSERT INTO users (Email) VALUES ('ON DUPLICATE KEY UPDATE')",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := TranslateMariaDBToSQLite(tc.mariadb)
+			if got != tc.expected {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad translation.")
+				return
+			}
+		})
+	}
+
+	//INSERT...SELECT cannot be translated. The error is only returned by
+	//TryTranslateMariaDBToSQLite, TranslateMariaDBToSQLite returns the query as-is.
+	q := "INSERT INTO t (a) SELECT a FROM u ON DUPLICATE KEY UPDATE a = VALUES(a)"
+	if got := TranslateMariaDBToSQLite(q); got != q {
+		t.Fatal("Query should be returned as-is.", got)
+		return
+	}
+
+	got, err := TryTranslateMariaDBToSQLite(q)
+	if _, ok := err.(*TranslationError); !ok || got != q {
+		t.Fatal("TranslationError should have occured.", got, err)
+		return
+	}
+
+	_, err = RunTranslators(q, []QueryTranslator{CheckedTranslator(TryTranslateMariaDBToSQLite)})
+	var te *TranslationError
+	if !errors.As(err, &te) || te.Query != q {
+		t.Fatal("TranslationError should have occured.", err)
+		return
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tt := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "single statement",
+			query:    "SELECT 1",
+			expected: []string{"SELECT 1"},
+		},
+		{
+			name:     "semicolon in string and comment",
+			query:    "INSERT INTO t VALUES ('a;b'); -- c;d\nSELECT 1;",
+			expected: []string{"INSERT INTO t VALUES ('a;b');", "-- c;d\nSELECT 1;"},
+		},
+		{
+			name:     "trigger",
+			query:    "CREATE TABLE t (ID INTEGER);\nCREATE TRIGGER tr AFTER UPDATE ON t BEGIN UPDATE t SET ID = CASE WHEN ID > 0 THEN 1 ELSE 0 END; SELECT 1; END;\nSELECT 2",
+			expected: []string{"CREATE TABLE t (ID INTEGER);", "CREATE TRIGGER tr AFTER UPDATE ON t BEGIN UPDATE t SET ID = CASE WHEN ID > 0 THEN 1 ELSE 0 END; SELECT 1; END;", "SELECT 2"},
+		},
+		{
+			name:     "transaction",
+			query:    "BEGIN TRANSACTION; SELECT 1; COMMIT;",
+			expected: []string{"BEGIN TRANSACTION;", "SELECT 1;", "COMMIT;"},
+		},
+		{
+			name:     "comment only statement",
+			query:    "SELECT 1; -- done",
+			expected: []string{"SELECT 1;"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := SplitStatements(tc.query)
+			if strings.Join(got, "|") != strings.Join(tc.expected, "|") {
+				t.Log("Got:", got)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad split.")
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+This file handles building upsert queries, an INSERT that updates the existing row
+if the row already exists. Each database type uses a different syntax for upserts,
+so building the query for the database type allows the same code to be used with
+each database type.
+*/
+
+var (
+	//ErrNoConflictColumns is returned when building an upsert query but no conflict
+	//columns were provided.
+	ErrNoConflictColumns = errors.New("sqldb: no conflict columns provided")
+
+	//ErrUnknownUpsertColumn is returned when building an upsert query and a conflict
+	//or update column is not one of the columns being inserted.
+	ErrUnknownUpsertColumn = errors.New("sqldb: upsert column not in inserted columns")
+
+	//ErrBindvarsMismatch is returned when the number of bindvars provided does not
+	//match the number of columns.
+	ErrBindvarsMismatch = errors.New("sqldb: number of bindvars does not match number of columns")
+)
+
+// Upsert is used to build an INSERT query that updates the existing row when a row
+// with the same ConflictColumns already exists.
+//
+// Example:
+//
+//	u := Upsert{
+//	    Table:           "users",
+//	    Columns:         Columns{"Email", "Fname", "Lname"},
+//	    ConflictColumns: Columns{"Email"},
+//	}
+//	q, b, err := u.Build(DBTypeSQLite, Bindvars{"a@example.com", "John", "Doe"})
+//	//q will be "INSERT INTO users (Email,Fname,Lname) VALUES (?,?,?) ON CONFLICT (Email) DO UPDATE SET Fname=excluded.Fname,Lname=excluded.Lname"
+type Upsert struct {
+	//Table is the table to insert into.
+	Table string
+
+	//Columns are the columns to insert.
+	Columns Columns
+
+	//ConflictColumns are the columns of the primary key or unique index used to
+	//determine if a row already exists. For MariaDB/MySQL, these are only used for
+	//validation since ON DUPLICATE KEY UPDATE applies to any unique index.
+	ConflictColumns Columns
+
+	//UpdateColumns are the columns updated when a row already exists. If not
+	//provided, every column in Columns that is not a ConflictColumn is updated.
+	UpdateColumns Columns
+}
+
+// Build returns the upsert query for the database type and the Bindvars to use with
+// the query. values are the values for each column in Columns, in the same order.
+//
+// The query uses ? placeholders. For MSSQL, use the query with DB() so that the
+// placeholders are rebound. The query built for SQLite also works for PostgreSQL,
+// with placeholders rebound to $1, $2, etc.
+func (u Upsert) Build(t dbType, values Bindvars) (query string, b Bindvars, err error) {
+	colString, valString, err := u.Columns.ForInsert()
+	if err != nil {
+		return
+	}
+
+	if len(values) != len(u.Columns) {
+		err = ErrBindvarsMismatch
+		return
+	}
+
+	if len(u.ConflictColumns) == 0 {
+		err = ErrNoConflictColumns
+		return
+	}
+
+	updateCols, err := u.updateColumns()
+	if err != nil {
+		return
+	}
+
+	switch t {
+	case DBTypeMariaDB, DBTypeMySQL:
+		//Updating a column to itself is a no-op update so the row is left as-is.
+		set := make([]string, len(updateCols))
+		for i, col := range updateCols {
+			set[i] = col + "=VALUES(" + col + ")"
+		}
+		if len(set) == 0 {
+			set = []string{u.ConflictColumns[0] + "=" + u.ConflictColumns[0]}
+		}
+
+		query = "INSERT INTO " + u.Table + " (" + colString + ") VALUES (" + valString + ") ON DUPLICATE KEY UPDATE " + strings.Join(set, ",")
+
+	case DBTypeSQLite:
+		query = "INSERT INTO " + u.Table + " (" + colString + ") VALUES (" + valString + ") ON CONFLICT (" + strings.Join(u.ConflictColumns, ",") + ")"
+
+		set := make([]string, len(updateCols))
+		for i, col := range updateCols {
+			set[i] = col + "=excluded." + col
+		}
+		if len(set) == 0 {
+			query += " DO NOTHING"
+		} else {
+			query += " DO UPDATE SET " + strings.Join(set, ",")
+		}
+
+	case DBTypeMSSQL:
+		on := make([]string, len(u.ConflictColumns))
+		for i, col := range u.ConflictColumns {
+			on[i] = "target." + col + "=source." + col
+		}
+
+		set := make([]string, len(updateCols))
+		for i, col := range updateCols {
+			set[i] = "target." + col + "=source." + col
+		}
+
+		sourceCols := make([]string, len(u.Columns))
+		for i, col := range u.Columns {
+			sourceCols[i] = "source." + col
+		}
+
+		//HOLDLOCK prevents a race condition between checking if the row exists and
+		//inserting the row.
+		query = "MERGE INTO " + u.Table + " WITH (HOLDLOCK) AS target" +
+			" USING (VALUES (" + valString + ")) AS source (" + colString + ")" +
+			" ON " + strings.Join(on, " AND ")
+		if len(set) > 0 {
+			query += " WHEN MATCHED THEN UPDATE SET " + strings.Join(set, ",")
+		}
+		query += " WHEN NOT MATCHED THEN INSERT (" + colString + ") VALUES (" + strings.Join(sourceCols, ",") + ");"
+
+	default:
+		err = fmt.Errorf("sqldb: invalid database type, should be one of '%s', got '%s'", validDBTypes, t)
+		return
+	}
+
+	//Each query uses the placeholders in the same order as Columns. Copy the values
+	//so that modifying the returned Bindvars doesn't modify the provided values.
+	b = append(Bindvars{}, values...)
+	return
+}
+
+// updateColumns returns the columns to update when a row already exists, checking
+// that each conflict and update column is one of the inserted columns.
+func (u Upsert) updateColumns() (cols Columns, err error) {
+	inserted := make(map[string]bool, len(u.Columns))
+	for _, col := range u.Columns {
+		inserted[col] = true
+	}
+
+	conflict := make(map[string]bool, len(u.ConflictColumns))
+	for _, col := range u.ConflictColumns {
+		if !inserted[col] {
+			err = ErrUnknownUpsertColumn
+			return
+		}
+		conflict[col] = true
+	}
+
+	if len(u.UpdateColumns) > 0 {
+		for _, col := range u.UpdateColumns {
+			if !inserted[col] {
+				err = ErrUnknownUpsertColumn
+				return
+			}
+		}
+
+		cols = u.UpdateColumns
+		return
+	}
+
+	for _, col := range u.Columns {
+		if !conflict[col] {
+			cols = append(cols, col)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,164 @@
+package sqldb
+
+import (
+	"testing"
+)
+
+func TestUpsertBuild(t *testing.T) {
+	u := Upsert{
+		Table:           "users",
+		Columns:         Columns{"Email", "Fname", "Lname"},
+		ConflictColumns: Columns{"Email"},
+	}
+	values := Bindvars{"a@example.com", "John", "Doe"}
+
+	tt := []struct {
+		dbType   dbType
+		upsert   Upsert
+		expected string
+	}{
+		{
+			dbType:   DBTypeMariaDB,
+			upsert:   u,
+			expected: "INSERT INTO users (Email,Fname,Lname) VALUES (?,?,?) ON DUPLICATE KEY UPDATE Fname=VALUES(Fname),Lname=VALUES(Lname)",
+		},
+		{
+			dbType:   DBTypeSQLite,
+			upsert:   u,
+			expected: "INSERT INTO users (Email,Fname,Lname) VALUES (?,?,?) ON CONFLICT (Email) DO UPDATE SET Fname=excluded.Fname,Lname=excluded.Lname",
+		},
+		{
+			dbType:   DBTypeMSSQL,
+			upsert:   u,
+			expected: "MERGE INTO users WITH (HOLDLOCK) AS target USING (VALUES (?,?,?)) AS source (Email,Fname,Lname) ON target.Email=source.Email WHEN MATCHED THEN UPDATE SET target.Fname=source.Fname,target.Lname=source.Lname WHEN NOT MATCHED THEN INSERT (Email,Fname,Lname) VALUES (source.Email,source.Fname,source.Lname);",
+		},
+		{
+			dbType: DBTypeSQLite,
+			upsert: Upsert{
+				Table:           "users",
+				Columns:         Columns{"Email", "Fname", "Lname"},
+				ConflictColumns: Columns{"Email"},
+				UpdateColumns:   Columns{"Lname"},
+			},
+			expected: "INSERT INTO users (Email,Fname,Lname) VALUES (?,?,?) ON CONFLICT (Email) DO UPDATE SET Lname=excluded.Lname",
+		},
+		{
+			dbType: DBTypeSQLite,
+			upsert: Upsert{
+				Table:           "users",
+				Columns:         Columns{"Email", "Fname", "Lname"},
+				ConflictColumns: Columns{"Email", "Fname", "Lname"},
+			},
+			expected: "INSERT INTO users (Email,Fname,Lname) VALUES (?,?,?) ON CONFLICT (Email,Fname,Lname) DO NOTHING",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(string(tc.dbType), func(t *testing.T) {
+			q, b, err := tc.upsert.Build(tc.dbType, values)
+			if err != nil {
+				t.Fatal(err)
+				return
+			}
+			if q != tc.expected {
+				t.Log("Got:", q)
+				t.Log("Exp:", tc.expected)
+				t.Fatal("Bad query.")
+				return
+			}
+			if len(b) != len(values) || b[0] != values[0] {
+				t.Fatal("Bad bindvars.", b)
+				return
+			}
+		})
+	}
+}
+
+func TestUpsertBuildErrors(t *testing.T) {
+	values := Bindvars{"a@example.com", "John"}
+
+	u := Upsert{Table: "users", Columns: Columns{"Email", "Fname"}}
+	_, _, err := u.Build(DBTypeSQLite, values)
+	if err != ErrNoConflictColumns {
+		t.Fatal("ErrNoConflictColumns should have occured.", err)
+		return
+	}
+
+	u.ConflictColumns = Columns{"ID"}
+	_, _, err = u.Build(DBTypeSQLite, values)
+	if err != ErrUnknownUpsertColumn {
+		t.Fatal("ErrUnknownUpsertColumn should have occured.", err)
+		return
+	}
+
+	u.ConflictColumns = Columns{"Email"}
+	_, _, err = u.Build(DBTypeSQLite, values[:1])
+	if err != ErrBindvarsMismatch {
+		t.Fatal("ErrBindvarsMismatch should have occured.", err)
+		return
+	}
+
+	_, _, err = u.Build("bad", values)
+	if err == nil {
+		t.Fatal("Error about invalid database type should have occured.")
+		return
+	}
+}
+
+func TestUpsertSQLite(t *testing.T) {
+	c := NewSQLite(SQLiteInMemoryFilepathRaceSafe)
+	c.DeployQueries = []string{"CREATE TABLE users (Email TEXT PRIMARY KEY, Fname TEXT)"}
+	err := c.DeploySchema(&DeploySchemaOptions{CloseConnection: false})
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	defer c.Close()
+
+	u := Upsert{
+		Table:           "users",
+		Columns:         Columns{"Email", "Fname"},
+		ConflictColumns: Columns{"Email"},
+	}
+
+	for _, name := range []string{"John", "Jane"} {
+		q, b, err := u.Build(c.Type, Bindvars{"a@example.com", name})
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+
+		_, err = c.Connection().Exec(q, b...)
+		if err != nil {
+			t.Fatal(err)
+			return
+		}
+	}
+
+	var name string
+	err = c.Connection().Get(&name, "SELECT Fname FROM users WHERE Email = ?", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if name != "Jane" {
+		t.Fatal("Row not updated.", name)
+		return
+	}
+
+	//Translated MariaDB upsert.
+	q := TranslateMariaDBToSQLite("INSERT INTO users (Email, Fname) VALUES (?, ?) ON DUPLICATE KEY UPDATE Fname = VALUES(Fname)")
+	_, err = c.Connection().Exec(q, "a@example.com", "Jim")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	err = c.Connection().Get(&name, "SELECT Fname FROM users WHERE Email = ?", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+		return
+	} else if name != "Jim" {
+		t.Fatal("Row not updated by translated upsert.", name)
+		return
+	}
+}